@@ -6,89 +6,397 @@ import (
 	"fmt"
 
 	auth "grammarhive-backend/api/routes/auth"
+	"grammarhive-backend/api/routes/graph"
 	handler "grammarhive-backend/api/routes/handler"
 	middleware "grammarhive-backend/api/routes/middleware"
+	"grammarhive-backend/api/routes/openapi"
+	"grammarhive-backend/api/routes/playground"
+	"grammarhive-backend/api/routes/problem"
 	"grammarhive-backend/core/config"
 	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/grammar"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/ratelimit"
+	"grammarhive-backend/core/services"
+	"grammarhive-backend/core/tracing"
 
+	"log/slog"
 	"net/http"
+	"os"
 	"time"
 
-	"github.com/gofiber/fiber/v2/log"
 	"github.com/gorilla/mux"
 )
 
+// v1DeprecatedAt is when the v1 generation routes were superseded by /api/v2/generate
+var v1DeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// routeScopes lists the scopes a token must carry for each secured route
+var routeScopes = map[string][]string{
+	"/api/grammar/generate":            {identity.ScopeGenerate},
+	"/api/grammar/generateList":        {identity.ScopeGenerate},
+	"/api/v2/generate":                 {identity.ScopeGenerate},
+	"/api/graphql":                     {},
+	"/api/playground":                  {identity.ScopeGenerate},
+	"/api/user/profile/grammar/upload": {identity.ScopeWrite},
+	"/api/user/profile/grammar":        {identity.ScopeRead},
+	"/api/user/apikeys":                {identity.ScopeAPIKeys},
+	"/api/user/apikeys/{keyId}":        {identity.ScopeAPIKeys},
+	"/api/me":                          {},
+	"/api/me/usage":                    {},
+	"/api/audit":                       {},
+	"/api/audit/export":                {},
+
+	// Webhook management
+	"/api/webhooks":                                               {identity.ScopeWebhooks},
+	"/api/webhooks/{webhookId}":                                   {identity.ScopeWebhooks},
+	"/api/webhooks/{webhookId}/deliveries":                        {identity.ScopeWebhooks},
+	"/api/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver": {identity.ScopeWebhooks},
+}
+
 type App struct {
 	dbService     *database.MongoDB
 	authenticator *middleware.Authenticator
 	grammar       *handler.GrammarHandler
-	profile      *handler.ProfileHandler
+	graphql       *graph.Handler
+	playground    *playground.Handler
+	profile       *handler.ProfileHandler
+	apiKeys       *handler.APIKeyHandler
+	webhooks      *handler.WebhookHandler
+	dispatcher    *services.WebhookDispatcher
+	login         *auth.LoginHandler
+	users         *services.UserService
+	me            *handler.MeHandler
+	audit         *handler.AuditHandler
+	health        *handler.HealthHandler
+	limiter       *ratelimit.Limiter
+	metrics       http.HandlerFunc
+	spec          *openapi.Spec
+	shutdown      func(context.Context) error
+	cfg           config.Config
+	cors          middleware.Middleware
+	proxies       middleware.TrustedProxies
 }
 
-var app = NewApp()
+var (
+	app, startupErr = NewApp()
+	server          = newServer(app, startupErr)
+)
 
-func NewApp() *App {
+// NewApp loads the configuration and connects every dependency the routes need
+func NewApp() (*App, error) {
 	cfg := config.Load()
+	slog.SetDefault(logging.New(os.Stdout, cfg.LogLevel))
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	dbService, err := database.NewMongoDB(ctx, cfg.MongoURI)
+	spec, err := openapi.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TracingExporter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up tracing: %w", err)
+	}
+
+	dbService, err := database.NewMongoDB(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	if err := dbService.EnsureGrammarIndexes(ctx); err != nil {
+		return nil, err
+	}
+	if err := dbService.EnsureUserIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	authenticator, err := middleware.NewFromConfig(cfg)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to set up authentication: %w", err)
 	}
 
-	authenticator, err := middleware.NewAuth0(cfg.Auth0Domain, cfg.Auth0Audience)
+	limiter, err := middleware.NewLimiterFromConfig(ctx, cfg, dbService)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to set up rate limiting: %w", err)
 	}
 
-	grammar := handler.NewGrammarHandler(dbService)
-	profile := handler.NewProfileHandler(dbService)
+	proxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	cors, err := middleware.CORS(middleware.CORSPolicy{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.CORS.ExposedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           time.Duration(cfg.CORS.MaxAge) * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeyService := services.NewAPIKeyService(dbService)
+	authenticator.APIKeys = apiKeyService
+
+	webhookService := services.NewWebhookService(dbService)
+	var dispatcher *services.WebhookDispatcher
+	if cfg.Webhooks.Workers > 0 {
+		dispatcher = services.NewWebhookDispatcher(dbService, cfg.Webhooks.MaxAttempts,
+			time.Duration(cfg.Webhooks.Timeout)*time.Second, cfg.Webhooks.AllowPrivate)
+		dispatcher.Start(cfg.Webhooks.Workers)
+		webhookService.Dispatcher = dispatcher
+	}
+
+	usageService := services.NewUsageService(dbService, cfg.MonthlyQuota)
+	auditService := services.NewAuditService(dbService)
+	authenticator.Audit = auditService
+
+	grammarCache := grammar.NewCache(cfg.GrammarCacheSize)
+	grammar := handler.NewGrammarHandler(dbService, usageService, grammarCache)
+	graphql, err := graph.NewHandler(dbService, usageService, grammarCache, cfg.GraphQLMaxDepth, cfg.GraphQLMaxCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the GraphQL schema: %w", err)
+	}
+	playgroundLimits, err := playground.LimitsFromConfig(cfg.Playground)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the playground: %w", err)
+	}
+	allowOrigin, err := middleware.OriginChecker(cfg.CORS.AllowedOrigins)
+	if err != nil {
+		return nil, err
+	}
+	playground := playground.NewHandler(usageService, playgroundLimits, allowOrigin)
+	profile := handler.NewProfileHandler(dbService, auditService, webhookService)
+	apiKeys := handler.NewAPIKeyHandler(apiKeyService, auditService)
+	webhooks := handler.NewWebhookHandler(webhookService, auditService)
+	userService := services.NewUserService(dbService)
+	me := handler.NewMeHandler(userService, usageService)
+	audit := handler.NewAuditHandler(auditService)
+	login := auth.NewLoginHandler(cfg, dbService)
+	health := handler.NewHealthHandler(
+		handler.MongoCheck(dbService),
+		handler.JWKSCheck(authenticator),
+		handler.CacheCheck(grammarCache),
+	)
 
 	return &App{
 		dbService:     dbService,
 		authenticator: authenticator,
 		grammar:       grammar,
+		graphql:       graphql,
+		playground:    playground,
 		profile:       profile,
+		apiKeys:       apiKeys,
+		webhooks:      webhooks,
+		dispatcher:    dispatcher,
+		login:         login,
+		users:         userService,
+		me:            me,
+		audit:         audit,
+		health:        health,
+		limiter:       limiter,
+		metrics:       middleware.MetricsHandler(cfg.MetricsEnabled, cfg.MetricsToken),
+		spec:          spec,
+		shutdown:      shutdownTracing,
+		cfg:           cfg,
+		cors:          cors,
+		proxies:       proxies,
+	}, nil
+}
+
+// StartupError returns why NewApp failed; the handler answers every request with 503 until restarted
+func StartupError() error {
+	return startupErr
+}
+
+// Shutdown stops webhook delivery, flushes pending spans and closes the database connection
+func Shutdown(ctx context.Context) error {
+	if app == nil {
+		return nil
 	}
+	if app.dispatcher != nil {
+		if err := app.dispatcher.Stop(ctx); err != nil {
+			return err
+		}
+	}
+	if err := app.shutdown(ctx); err != nil {
+		return err
+	}
+	return app.dbService.Close(ctx)
 }
 
+// Handler is the Vercel entrypoint; cmd/debug.go serves the same handler
 func Handler(w http.ResponseWriter, r *http.Request) {
-	log.Info(fmt.Sprintf(
-		"%s: method=%s, uri=%s", r.Proto, r.Method, r.RequestURI),
-	)
+	server.ServeHTTP(w, r)
+}
+
+// newServer serves the app's routes, or a 503 for every request when the app failed to start
+func newServer(a *App, err error) http.Handler {
+	if err == nil {
+		return a.Routes()
+	}
+
+	slog.Error("startup failed", "error", err)
+	return middleware.Chain(
+		middleware.RequestID,
+		middleware.RequestLogging,
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		problem.Respond(w, r, http.StatusServiceUnavailable, problem.CodeUnavailable, "the service failed to start")
+	}))
+}
 
+// Routes builds the router and wraps it in the middleware every request passes through.
+// Per-route authentication, scopes and rate limits are applied by secure and limit
+func (a *App) Routes() http.Handler {
 	router := mux.NewRouter()
+	router.Use(
+		middleware.AnnotateRoute,
+		middleware.RecordMetrics,
+		middleware.LimitBody(a.cfg.MaxBodyBytes, map[string]int64{
+			"/api/user/profile/grammar/upload": a.cfg.MaxUploadBytes,
+		}),
+		a.spec.Validate(a.cfg.ValidateResponses),
+	)
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		problem.Respond(w, r, http.StatusNotFound, problem.CodeNotFound, "no route matches "+r.URL.Path)
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		problem.Respond(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path)
+	})
 
 	// All the routes are defined here!!
-	router.HandleFunc("/api/login", auth.HandleLogin).Methods("POST")
+	router.HandleFunc("/api/auth/authorize", a.limit("/api/auth/authorize", a.login.HandleAuthorize)).Methods("GET")
+	router.HandleFunc("/api/auth/callback", a.limit("/api/auth/callback", a.login.HandleCallback)).Methods("GET")
+	router.HandleFunc("/api/auth/refresh", a.limit("/api/auth/refresh", a.login.HandleRefresh)).Methods("POST")
+	router.HandleFunc("/api/auth/logout", a.limit("/api/auth/logout", a.login.HandleLogout)).Methods("POST")
 
 	router.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {
-        w.Write([]byte("Health good"))
-    }).Methods("GET")
+		w.Write([]byte("Health good"))
+	}).Methods("GET")
+
+	router.HandleFunc("/api/health/live", a.health.HandleLive).Methods("GET")
+	router.HandleFunc("/api/health/ready", a.health.HandleReady).Methods("GET")
+
+	router.HandleFunc("/api/openapi.json", a.spec.HandleDocument).Methods("GET")
+
+	router.HandleFunc("/metrics", a.metrics).Methods("GET")
 
 	// Secured routes
-	router.HandleFunc("/api/grammar/generate",
-		app.authenticator.Middleware(app.grammar.HandleGenerate),
-	).Methods("GET")
+	router.Handle("/api/grammar/generate", a.deprecated("/api/v2/generate",
+		a.secure("/api/grammar/generate", a.grammar.HandleGenerate),
+	)).Methods("GET")
+
+	router.Handle("/api/grammar/generateList", a.deprecated("/api/v2/generate",
+		a.secure("/api/grammar/generateList", a.grammar.HandleGenerateList),
+	)).Methods("GET")
+
+	router.HandleFunc("/api/v2/generate",
+		a.secure("/api/v2/generate", a.grammar.HandleGenerateV2),
+	).Methods("POST")
 
-	router.HandleFunc("/api/grammar/generateList",
-		app.authenticator.Middleware(app.grammar.HandleGenerateList),
+	router.HandleFunc("/api/graphql",
+		a.secure("/api/graphql", a.graphql.HandleQuery),
+	).Methods("POST")
+
+	router.HandleFunc("/api/playground",
+		playground.Credentials(a.secure("/api/playground", a.playground.HandlePlayground)),
 	).Methods("GET")
 
 	router.HandleFunc("/api/user/profile/grammar/upload",
-		app.authenticator.Middleware(app.profile.HandleUpload),
+		a.secure("/api/user/profile/grammar/upload", a.profile.HandleUpload),
 	).Methods("POST")
 
 	router.HandleFunc("/api/user/profile/grammar",
-		app.authenticator.Middleware(app.profile.HandleGetGrammarByUsername),
+		a.secure("/api/user/profile/grammar", a.profile.HandleGetGrammarByUsername),
 	).Methods("GET")
 
-	// CORS Preflight
-	if r.Method == "OPTIONS" {
-		middleware.HandleOptions(w, r)
-		return
-	}
+	router.HandleFunc("/api/user/apikeys",
+		a.secure("/api/user/apikeys", a.apiKeys.HandleList),
+	).Methods("GET")
+
+	router.HandleFunc("/api/user/apikeys",
+		a.secure("/api/user/apikeys", a.apiKeys.HandleCreate),
+	).Methods("POST")
+
+	router.HandleFunc("/api/user/apikeys/{keyId}",
+		a.secure("/api/user/apikeys/{keyId}", a.apiKeys.HandleRevoke),
+	).Methods("DELETE")
+
+	router.HandleFunc("/api/webhooks",
+		a.secure("/api/webhooks", a.webhooks.HandleList),
+	).Methods("GET")
+
+	router.HandleFunc("/api/webhooks",
+		a.secure("/api/webhooks", a.webhooks.HandleCreate),
+	).Methods("POST")
 
-	router.ServeHTTP(w, r)
+	router.HandleFunc("/api/webhooks/{webhookId}",
+		a.secure("/api/webhooks/{webhookId}", a.webhooks.HandleDelete),
+	).Methods("DELETE")
+
+	router.HandleFunc("/api/webhooks/{webhookId}/deliveries",
+		a.secure("/api/webhooks/{webhookId}/deliveries", a.webhooks.HandleListDeliveries),
+	).Methods("GET")
+
+	router.HandleFunc("/api/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver",
+		a.secure("/api/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", a.webhooks.HandleRedeliver),
+	).Methods("POST")
+
+	router.HandleFunc("/api/me",
+		a.secure("/api/me", a.me.HandleGetMe),
+	).Methods("GET")
+
+	router.HandleFunc("/api/me",
+		a.secure("/api/me", a.me.HandleUpdateMe),
+	).Methods("PATCH")
+
+	router.HandleFunc("/api/me/usage",
+		a.secure("/api/me/usage", a.me.HandleGetUsage),
+	).Methods("GET")
+
+	router.HandleFunc("/api/audit",
+		a.secure("/api/audit", a.audit.HandleList),
+	).Methods("GET")
+
+	router.HandleFunc("/api/audit/export",
+		a.secure("/api/audit/export", a.audit.HandleExport),
+	).Methods("GET")
+
+	return middleware.Chain(
+		middleware.RealIP(a.proxies),
+		middleware.RequestID,
+		middleware.RequestLogging,
+		middleware.Tracing,
+		middleware.Recover,
+		middleware.SecurityHeaders(a.cfg.HSTSMaxAge),
+		middleware.LimitRequest(a.cfg.MaxURIBytes, a.cfg.MaxHeaderBytes),
+		a.cors,
+	)(router)
+}
+
+// limit applies the route's rate limit, keyed by client IP for unauthenticated routes
+func (a *App) limit(route string, next http.HandlerFunc) http.HandlerFunc {
+	return middleware.RateLimit(a.limiter, route, next)
+}
+
+// deprecated marks a v1 route's responses as superseded by the successor route
+func (a *App) deprecated(successor string, next http.HandlerFunc) http.Handler {
+	return middleware.Deprecated(v1DeprecatedAt, successor)(next)
+}
+
+// secure wraps a handler with the pre-auth rate limit, authentication, user tracking, the
+// scopes listed for its route and its rate limit
+func (a *App) secure(route string, next http.HandlerFunc) http.HandlerFunc {
+	scopes, ok := routeScopes[route]
+	if !ok {
+		panic(fmt.Sprintf("no scopes defined for secured route %s", route))
+	}
+	return middleware.RateLimitByIP(a.limiter, middleware.PreAuthRoute, a.authenticator.Middleware(
+		middleware.TrackUsers(a.users, middleware.RequireScopes(scopes, a.limit(route, next))),
+	))
 }
@@ -2,51 +2,448 @@
 package handler
 
 import (
-	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
+	"net/url"
+	"strings"
+	"time"
+
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/config"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/logging"
+)
+
+const (
+	sessionCookie    = "gh_session"
+	loginStateCookie = "gh_login_state"
+	loginStateTTL    = 10 * time.Minute
+	sessionTTL       = 30 * 24 * time.Hour
 )
 
-func HandleLogin(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// SessionStore persists pending logins and refresh state between requests
+type SessionStore interface {
+	SaveLoginState(ctx context.Context, state *database.LoginState) error
+	ConsumeLoginState(ctx context.Context, state string) (*database.LoginState, error)
+	SaveAuthSession(ctx context.Context, session *database.AuthSession) error
+	GetAuthSession(ctx context.Context, sessionHash string) (*database.AuthSession, error)
+	UpdateAuthSession(ctx context.Context, sessionHash, sealedRefreshToken string) error
+	DeleteAuthSession(ctx context.Context, sessionHash string) (*database.AuthSession, error)
+}
+
+// LoginHandler runs the authorization code + PKCE flow against the configured OAuth server
+// and keeps refresh tokens server-side behind an opaque session cookie. Refresh tokens are
+// stored encrypted with a key derived from the cookie, which the database never sees
+type LoginHandler struct {
+	cfg      config.Config
+	sessions SessionStore
+	client   *http.Client
+}
+
+func NewLoginHandler(cfg config.Config, sessions SessionStore) *LoginHandler {
+	return &LoginHandler{
+		cfg:      cfg,
+		sessions: sessions,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	IDToken      string `json:"id_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// HandleAuthorize starts a login and redirects the browser to the authorization server. The
+// state is also set in a cookie, so the callback only completes in the browser that started it
+func (h *LoginHandler) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
+	redirectURI := r.URL.Query().Get("redirect_uri")
+	if redirectURI != "" && !h.allowedRedirect(redirectURI) {
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "redirect_uri is not allowed")
 		return
 	}
 
-	tokenEndpoint := fmt.Sprintf("https://%s/oauth/token", os.Getenv("AUTH0_DOMAIN"))
-	payload := map[string]string{
-		"grant_type":    "client_credentials",
-		"client_id":     os.Getenv("AUTH0_CLIENT_ID"),
-		"client_secret": os.Getenv("AUTH0_CLIENT_SECRET"),
-		"audience":      os.Getenv("AUTH0_AUDIENCE"),
+	state, err := randomToken(32)
+	if err != nil {
+		serverError(w, r, "Failed to start login", err)
+		return
+	}
+	verifier, err := randomToken(48)
+	if err != nil {
+		serverError(w, r, "Failed to start login", err)
+		return
 	}
 
-	payloadBytes, err := json.Marshal(payload)
+	now := time.Now()
+	err = h.sessions.SaveLoginState(r.Context(), &database.LoginState{
+		State:        state,
+		CodeVerifier: verifier,
+		RedirectURI:  redirectURI,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(loginStateTTL),
+	})
 	if err != nil {
-		http.Error(w, "Failed to create token request", http.StatusInternalServerError)
+		serverError(w, r, "Failed to start login", err)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginStateCookie,
+		Value:    state,
+		Path:     "/api/auth/callback",
+		MaxAge:   int(loginStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		// Lax, as the callback is a cross-site navigation from the authorization server
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	challenge := sha256.Sum256([]byte(verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {h.cfg.Auth0ClientID},
+		"redirect_uri":          {h.cfg.OAuthCallbackURL},
+		"scope":                 {h.cfg.OAuthScopes},
+		"audience":              {h.cfg.Auth0Audience},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	http.Redirect(w, r, h.cfg.OAuthAuthorizeURL+"?"+params.Encode(), http.StatusFound)
+}
+
+// HandleCallback exchanges the authorization code for the user's tokens
+func (h *LoginHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
+	stateCookie, _ := r.Cookie(loginStateCookie)
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginStateCookie,
+		Value:    "",
+		Path:     "/api/auth/callback",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		problem.Respond(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, fmt.Sprintf("login failed: %s", errCode))
+		return
+	}
+
+	code, state := query.Get("code"), query.Get("state")
+	if code == "" || state == "" {
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "missing code or state")
+		return
+	}
+	// Without this a link to the callback with an attacker's code would log the victim in as the attacker
+	if stateCookie == nil || subtle.ConstantTimeCompare([]byte(stateCookie.Value), []byte(state)) != 1 {
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "login was not started in this browser")
 		return
 	}
 
-	resp, err := http.Post(tokenEndpoint, "application/json", bytes.NewBuffer(payloadBytes))
+	pending, err := h.sessions.ConsumeLoginState(r.Context(), state)
 	if err != nil {
-		http.Error(w, "Failed to get token", http.StatusInternalServerError)
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "unknown or expired login state")
 		return
 	}
+
+	tokens, err := h.requestToken(r.Context(), url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"code_verifier": {pending.CodeVerifier},
+		"redirect_uri":  {h.cfg.OAuthCallbackURL},
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("authorization code exchange failed", "error", err)
+		problem.Respond(w, r, http.StatusBadGateway, problem.CodeUpstream, "failed to exchange authorization code")
+		return
+	}
+
+	if tokens.RefreshToken != "" {
+		if err := h.startSession(w, r, tokens.RefreshToken); err != nil {
+			serverError(w, r, "Failed to store session", err)
+			return
+		}
+	}
+
+	if pending.RedirectURI != "" {
+		fragment := url.Values{
+			"access_token": {tokens.AccessToken},
+			"token_type":   {tokens.TokenType},
+			"expires_in":   {fmt.Sprint(tokens.ExpiresIn)},
+		}
+		http.Redirect(w, r, pending.RedirectURI+"#"+fragment.Encode(), http.StatusFound)
+		return
+	}
+
+	writeTokens(w, tokens)
+}
+
+// HandleRefresh issues a new access token from the session's refresh token
+func (h *LoginHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := sessionIDFromRequest(r)
+	if !ok {
+		problem.Respond(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "missing session")
+		return
+	}
+	sessionHash := hashSession(sessionID)
+
+	session, err := h.sessions.GetAuthSession(r.Context(), sessionHash)
+	if err != nil {
+		problem.Respond(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "invalid session")
+		return
+	}
+	refreshToken, err := openRefreshToken(sessionID, session.SealedRefreshToken)
+	if err != nil {
+		problem.Respond(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "invalid session")
+		return
+	}
+
+	tokens, err := h.requestToken(r.Context(), url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("refresh token grant failed", "error", err)
+		problem.Respond(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "failed to refresh token")
+		return
+	}
+
+	// Authorization servers that rotate refresh tokens invalidate the old one
+	if tokens.RefreshToken != "" && tokens.RefreshToken != refreshToken {
+		sealed, err := sealRefreshToken(sessionID, tokens.RefreshToken)
+		if err == nil {
+			err = h.sessions.UpdateAuthSession(r.Context(), sessionHash, sealed)
+		}
+		if err != nil {
+			serverError(w, r, "Failed to store session", err)
+			return
+		}
+	}
+
+	writeTokens(w, tokens)
+}
+
+// HandleLogout ends the session and revokes its refresh token
+func (h *LoginHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/api/auth",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if sessionID, ok := sessionIDFromRequest(r); ok {
+		session, err := h.sessions.DeleteAuthSession(r.Context(), hashSession(sessionID))
+		if err != nil && !errors.Is(err, database.ErrSessionNotFound) {
+			serverError(w, r, "Failed to end session", err)
+			return
+		}
+		if session != nil {
+			if refreshToken, err := openRefreshToken(sessionID, session.SealedRefreshToken); err == nil {
+				h.revoke(r.Context(), refreshToken)
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *LoginHandler) startSession(w http.ResponseWriter, r *http.Request, refreshToken string) error {
+	sessionID, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	sealed, err := sealRefreshToken(sessionID, refreshToken)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = h.sessions.SaveAuthSession(r.Context(), &database.AuthSession{
+		SessionHash:        hashSession(sessionID),
+		SealedRefreshToken: sealed,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+		ExpiresAt:          now.Add(sessionTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    sessionID,
+		Path:     "/api/auth",
+		Expires:  now.Add(sessionTTL),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// requestToken calls the token endpoint with the client's credentials added to form
+func (h *LoginHandler) requestToken(ctx context.Context, form url.Values) (*tokenResponse, error) {
+	form.Set("client_id", h.cfg.Auth0ClientID)
+	if h.cfg.Auth0ClientSecret != "" {
+		form.Set("client_secret", h.cfg.Auth0ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.cfg.OAuthTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 
-	var tokenResponse struct {
-		AccessToken string `json:"access_token"`
-		TokenType   string `json:"token_type"`
-		ExpiresIn   int    `json:"expires_in"`
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var tokens tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, err
+	}
+	if tokens.AccessToken == "" {
+		return nil, errors.New("token endpoint returned no access token")
 	}
+	return &tokens, nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
-		http.Error(w, "Failed to parse token response", http.StatusInternalServerError)
+// revoke asks the authorization server to invalidate a refresh token; failures are not fatal to logout
+func (h *LoginHandler) revoke(ctx context.Context, refreshToken string) {
+	if h.cfg.OAuthRevokeURL == "" {
 		return
 	}
 
+	form := url.Values{
+		"client_id": {h.cfg.Auth0ClientID},
+		"token":     {refreshToken},
+	}
+	if h.cfg.Auth0ClientSecret != "" {
+		form.Set("client_secret", h.cfg.Auth0ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.cfg.OAuthRevokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if resp, err := h.client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+func (h *LoginHandler) allowedRedirect(redirectURI string) bool {
+	for _, allowed := range h.cfg.LoginRedirectURLs {
+		if redirectURI == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// serverError replies with the problem err maps to, or logs the cause with the request's
+// logger and replies with a generic 500
+func serverError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	problem.Error(w, r, msg, err)
+}
+
+// writeTokens returns the access token to the client; the refresh token never leaves the server
+func writeTokens(w http.ResponseWriter, tokens *tokenResponse) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(tokenResponse)
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": tokens.AccessToken,
+		"id_token":     tokens.IDToken,
+		"token_type":   tokens.TokenType,
+		"expires_in":   tokens.ExpiresIn,
+	})
+}
+
+func sessionIDFromRequest(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+func hashSession(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:])
+}
+
+// sessionCipher is AES-256-GCM keyed with an HMAC of the session ID. The ID is only stored
+// hashed, so a copy of the database holds neither the key nor the refresh token
+func sessionCipher(sessionID string) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, []byte(sessionID))
+	mac.Write([]byte("grammarhive refresh token"))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealRefreshToken encrypts the refresh token for the session, as the nonce followed by the ciphertext
+func sealRefreshToken(sessionID, refreshToken string) (string, error) {
+	aead, err := sessionCipher(sessionID)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(refreshToken), nil)), nil
+}
+
+// openRefreshToken decrypts a refresh token sealed for the session
+func openRefreshToken(sessionID, sealed string) (string, error) {
+	aead, err := sessionCipher(sessionID)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", errors.New("malformed sealed refresh token")
+	}
+	token, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(token), nil
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
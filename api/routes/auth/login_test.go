@@ -0,0 +1,349 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"grammarhive-backend/core/config"
+	"grammarhive-backend/core/database"
+)
+
+// memorySessions is a SessionStore kept in maps
+type memorySessions struct {
+	mu       sync.Mutex
+	states   map[string]*database.LoginState
+	sessions map[string]*database.AuthSession
+}
+
+func newMemorySessions() *memorySessions {
+	return &memorySessions{
+		states:   make(map[string]*database.LoginState),
+		sessions: make(map[string]*database.AuthSession),
+	}
+}
+
+func (m *memorySessions) SaveLoginState(_ context.Context, state *database.LoginState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[state.State] = state
+	return nil
+}
+
+func (m *memorySessions) ConsumeLoginState(_ context.Context, state string) (*database.LoginState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pending, ok := m.states[state]
+	if !ok {
+		return nil, database.ErrSessionNotFound
+	}
+	delete(m.states, state)
+	return pending, nil
+}
+
+func (m *memorySessions) SaveAuthSession(_ context.Context, session *database.AuthSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[session.SessionHash] = session
+	return nil
+}
+
+func (m *memorySessions) GetAuthSession(_ context.Context, sessionHash string) (*database.AuthSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[sessionHash]
+	if !ok {
+		return nil, database.ErrSessionNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (m *memorySessions) UpdateAuthSession(_ context.Context, sessionHash, sealedRefreshToken string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[sessionHash]
+	if !ok {
+		return database.ErrSessionNotFound
+	}
+	session.SealedRefreshToken = sealedRefreshToken
+	return nil
+}
+
+func (m *memorySessions) DeleteAuthSession(_ context.Context, sessionHash string) (*database.AuthSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[sessionHash]
+	if !ok {
+		return nil, database.ErrSessionNotFound
+	}
+	delete(m.sessions, sessionHash)
+	return session, nil
+}
+
+// oauthServer stands in for the authorization server's token and revocation endpoints. It
+// issues one code per challenge, and rotates refresh tokens on every refresh
+type oauthServer struct {
+	*httptest.Server
+	t          *testing.T
+	mu         sync.Mutex
+	challenges map[string]string // code to PKCE challenge
+	refreshes  int
+	revoked    []string
+}
+
+func newOAuthServer(t *testing.T) *oauthServer {
+	s := &oauthServer{t: t, challenges: make(map[string]string)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", s.handleToken)
+	mux.HandleFunc("/oauth/revoke", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.revoked = append(s.revoked, r.FormValue("token"))
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// approve is the user signing in: it returns the code the authorization server would send
+// to the callback for an authorize redirect
+func (s *oauthServer) approve(authorizeURL string) (code, state string) {
+	s.t.Helper()
+	u, err := url.Parse(authorizeURL)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != "client" {
+		s.t.Fatalf("authorize request %s lacks PKCE or the client ID", authorizeURL)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code = "code-" + query.Get("state")[:8]
+	s.challenges[code] = query.Get("code_challenge")
+	return code, query.Get("state")
+}
+
+func (s *oauthServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tokens tokenResponse
+	switch r.FormValue("grant_type") {
+	case "authorization_code":
+		challenge, ok := s.challenges[r.FormValue("code")]
+		delete(s.challenges, r.FormValue("code"))
+		verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(verifier[:]) != challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		tokens = tokenResponse{AccessToken: "access-0", RefreshToken: "refresh-0", TokenType: "Bearer", ExpiresIn: 3600}
+	case "refresh_token":
+		if r.FormValue("refresh_token") != "refresh-"+string(rune('0'+s.refreshes)) {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		s.refreshes++
+		n := string(rune('0' + s.refreshes))
+		tokens = tokenResponse{AccessToken: "access-" + n, RefreshToken: "refresh-" + n, TokenType: "Bearer", ExpiresIn: 3600}
+	default:
+		http.Error(w, `{"error":"unsupported_grant_type"}`, http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+func newTestLoginHandler(t *testing.T) (*LoginHandler, *memorySessions, *oauthServer) {
+	server := newOAuthServer(t)
+	sessions := newMemorySessions()
+	handler := NewLoginHandler(config.Config{
+		Auth0ClientID:     "client",
+		OAuthAuthorizeURL: server.URL + "/authorize",
+		OAuthTokenURL:     server.URL + "/oauth/token",
+		OAuthRevokeURL:    server.URL + "/oauth/revoke",
+		OAuthCallbackURL:  "http://api.test/api/auth/callback",
+		LoginRedirectURLs: []string{"http://app.test/auth/callback"},
+	}, sessions)
+	return handler, sessions, server
+}
+
+// startLogin calls the authorize endpoint and returns its redirect and state cookie
+func startLogin(t *testing.T, h *LoginHandler, query string) (string, *http.Cookie) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.HandleAuthorize(rec, httptest.NewRequest(http.MethodGet, "/api/auth/authorize"+query, nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("authorize status = %d: %s", rec.Code, rec.Body)
+	}
+
+	var stateCookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == loginStateCookie {
+			stateCookie = c
+		}
+	}
+	if stateCookie == nil || !stateCookie.HttpOnly || !stateCookie.Secure || stateCookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("state cookie = %+v, want an HttpOnly, Secure, SameSite=Lax cookie", stateCookie)
+	}
+	return rec.Header().Get("Location"), stateCookie
+}
+
+func callback(h *LoginHandler, code, state string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/callback?"+url.Values{"code": {code}, "state": {state}}.Encode(), nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	h.HandleCallback(rec, req)
+	return rec
+}
+
+func cookieNamed(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestLoginRefreshAndLogout(t *testing.T) {
+	h, sessions, server := newTestLoginHandler(t)
+
+	location, stateCookie := startLogin(t, h, "")
+	if !strings.HasPrefix(location, server.URL+"/authorize?") {
+		t.Fatalf("authorize redirected to %s", location)
+	}
+	code, state := server.approve(location)
+
+	rec := callback(h, code, state, stateCookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("callback status = %d: %s", rec.Code, rec.Body)
+	}
+	var tokens map[string]interface{}
+	json.NewDecoder(rec.Body).Decode(&tokens)
+	if tokens["access_token"] != "access-0" || tokens["refresh_token"] != nil {
+		t.Fatalf("callback returned %v, want the access token only", tokens)
+	}
+	if c := cookieNamed(rec, loginStateCookie); c == nil || c.MaxAge >= 0 {
+		t.Fatalf("callback did not clear the state cookie: %+v", c)
+	}
+	session := cookieNamed(rec, sessionCookie)
+	if session == nil {
+		t.Fatal("callback set no session cookie")
+	}
+
+	// The database holds neither the session ID nor the refresh token
+	stored, err := sessions.GetAuthSession(context.Background(), hashSession(session.Value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stored.SealedRefreshToken, "refresh-0") || strings.Contains(stored.SessionHash, session.Value) {
+		t.Fatalf("session stored in the clear: %+v", stored)
+	}
+	if _, err := openRefreshToken("another-session", stored.SealedRefreshToken); err == nil {
+		t.Fatal("refresh token opened without the session ID")
+	}
+
+	for _, want := range []string{"access-1", "access-2"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", nil)
+		req.AddCookie(session)
+		rec = httptest.NewRecorder()
+		h.HandleRefresh(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("refresh status = %d: %s", rec.Code, rec.Body)
+		}
+		json.NewDecoder(rec.Body).Decode(&tokens)
+		if tokens["access_token"] != want {
+			t.Fatalf("refresh returned %v, want %s", tokens["access_token"], want)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil)
+	req.AddCookie(session)
+	rec = httptest.NewRecorder()
+	h.HandleLogout(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("logout status = %d: %s", rec.Code, rec.Body)
+	}
+	if len(server.revoked) != 1 || server.revoked[0] != "refresh-2" {
+		t.Fatalf("revoked %v, want the rotated refresh token", server.revoked)
+	}
+	if _, err := sessions.GetAuthSession(context.Background(), hashSession(session.Value)); err == nil {
+		t.Fatal("session survived logout")
+	}
+}
+
+func TestCallbackRequiresTheBrowserThatStartedTheLogin(t *testing.T) {
+	h, _, server := newTestLoginHandler(t)
+
+	location, stateCookie := startLogin(t, h, "")
+	code, state := server.approve(location)
+	_, otherCookie := startLogin(t, h, "")
+
+	for name, cookies := range map[string][]*http.Cookie{
+		"no state cookie":    nil,
+		"other state cookie": {otherCookie},
+	} {
+		if rec := callback(h, code, state, cookies...); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: callback status = %d, want 400", name, rec.Code)
+		}
+	}
+
+	// Refused callbacks leave the login pending for its own browser
+	if rec := callback(h, code, state, stateCookie); rec.Code != http.StatusOK {
+		t.Fatalf("callback status = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := callback(h, code, state, stateCookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("replayed callback status = %d, want 400", rec.Code)
+	}
+}
+
+func TestCallbackRedirectsWithAccessToken(t *testing.T) {
+	h, _, server := newTestLoginHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.HandleAuthorize(rec, httptest.NewRequest(http.MethodGet, "/api/auth/authorize?redirect_uri=http://evil.test/", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unlisted redirect_uri status = %d, want 400", rec.Code)
+	}
+
+	location, stateCookie := startLogin(t, h, "?redirect_uri="+url.QueryEscape("http://app.test/auth/callback"))
+	code, state := server.approve(location)
+
+	rec = callback(h, code, state, stateCookie)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("callback status = %d: %s", rec.Code, rec.Body)
+	}
+	redirect, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fragment, _ := url.ParseQuery(redirect.Fragment)
+	if redirect.Host != "app.test" || fragment.Get("access_token") != "access-0" {
+		t.Fatalf("callback redirected to %s", redirect)
+	}
+}
+
+func TestCallbackRejectsWrongVerifier(t *testing.T) {
+	h, _, server := newTestLoginHandler(t)
+
+	location, stateCookie := startLogin(t, h, "")
+	code, state := server.approve(location)
+	server.challenges[code] = "not-the-challenge"
+
+	if rec := callback(h, code, state, stateCookie); rec.Code != http.StatusBadGateway {
+		t.Fatalf("callback status = %d, want 502", rec.Code)
+	}
+}
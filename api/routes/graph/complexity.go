@@ -0,0 +1,202 @@
+// api/routes/graph/complexity.go
+package graph
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"grammarhive-backend/api/routes/problem"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// generationCost is what each generated text costs; generating is far more expensive than
+// reading a field
+const generationCost = 10
+
+// analysisCost is what parsing grammar content costs
+const analysisCost = 5
+
+// fieldCosts weighs the fields whose cost depends on their arguments. own is the cost of
+// the field itself, and its selections are counted multiplier times; every other field
+// costs 1 and counts its selections once
+var fieldCosts = map[string]func(args func(name string) int) (own, multiplier int){
+	"User.grammars":    func(args func(string) int) (int, int) { return 1, args("limit") },
+	"Grammar.versions": func(args func(string) int) (int, int) { return 1, args("limit") },
+	"Grammar.analysis": func(args func(string) int) (int, int) { return analysisCost, 1 },
+	"Query.analyze":    func(args func(string) int) (int, int) { return analysisCost, 1 },
+	"Grammar.generate": func(args func(string) int) (int, int) { return generationCost * args("count"), 1 },
+	"Query.generate":   func(args func(string) int) (int, int) { return generationCost * args("count"), 1 },
+}
+
+// costCounter estimates the cost of an operation before it runs, so queries that would
+// fan out over many grammars or generate many texts are rejected up front
+type costCounter struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	maxDepth  int
+	maxCost   int
+}
+
+// checkComplexity rejects the operation of a validated document that nests deeper than
+// maxDepth or costs more than maxCost
+func checkComplexity(schema graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}, maxDepth, maxCost int) error {
+	c := &costCounter{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+		maxDepth:  maxDepth,
+		maxCost:   maxCost,
+	}
+
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			c.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				operation = def
+			}
+		}
+	}
+	if operation == nil || operation.Operation != ast.OperationTypeQuery {
+		// The executor reports missing and unsupported operations
+		return nil
+	}
+
+	cost, err := c.selectionCost(schema.QueryType(), operation.SelectionSet, 1)
+	if err != nil {
+		return err
+	}
+	if cost > maxCost {
+		return c.tooComplex()
+	}
+	return nil
+}
+
+// selectionCost sums the cost of set, whose fields belong to parent and sit at depth
+func (c *costCounter) selectionCost(parent *graphql.Object, set *ast.SelectionSet, depth int) (int, error) {
+	if set == nil {
+		return 0, nil
+	}
+
+	cost := 0
+	for _, selection := range set.Selections {
+		var selectionCost int
+		var err error
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			selectionCost, err = c.fieldCost(parent, selection, depth)
+		case *ast.InlineFragment:
+			selectionCost, err = c.selectionCost(parent, selection.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			// Validation has rejected unknown and cyclic fragments
+			if fragment := c.fragments[selection.Name.Value]; fragment != nil {
+				selectionCost, err = c.selectionCost(parent, fragment.SelectionSet, depth)
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		cost += selectionCost
+		if cost > c.maxCost {
+			return 0, c.tooComplex()
+		}
+	}
+	return cost, nil
+}
+
+func (c *costCounter) fieldCost(parent *graphql.Object, field *ast.Field, depth int) (int, error) {
+	name := field.Name.Value
+	if strings.HasPrefix(name, "__") {
+		// Introspection is cheap and bounded by the schema
+		return 1, nil
+	}
+	if depth > c.maxDepth {
+		return 0, problem.New(http.StatusBadRequest, problem.CodeQueryTooComplex,
+			fmt.Sprintf("query is nested deeper than %d levels", c.maxDepth))
+	}
+
+	def := parent.Fields()[name]
+	if def == nil {
+		return 0, nil
+	}
+
+	childCost := 0
+	if object, ok := namedType(def.Type).(*graphql.Object); ok {
+		var err error
+		if childCost, err = c.selectionCost(object, field.SelectionSet, depth+1); err != nil {
+			return 0, err
+		}
+	}
+
+	own, multiplier := 1, 1
+	if weigh := fieldCosts[parent.Name()+"."+name]; weigh != nil {
+		own, multiplier = weigh(func(arg string) int { return c.intArgument(def, field, arg) })
+	}
+	// Clamp both so the product cannot overflow; either one this large is over the limit anyway
+	own = min(max(own, 0), c.maxCost+1)
+	multiplier = min(max(multiplier, 0), c.maxCost+1)
+	return own + multiplier*childCost, nil
+}
+
+// intArgument returns the value of an Int argument of field, from its literal, a variable
+// or its default, or 0 when it has none
+func (c *costCounter) intArgument(def *graphql.FieldDefinition, field *ast.Field, name string) int {
+	var value interface{}
+	for _, arg := range def.Args {
+		if arg.Name() == name {
+			value = arg.DefaultValue
+		}
+	}
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != name {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			value = v.Value
+		case *ast.Variable:
+			if provided, ok := c.variables[v.Name.Value]; ok {
+				value = provided
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case int:
+		return v
+	case float64:
+		return int(min(max(v, 0), float64(c.maxCost+1)))
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+		return c.maxCost + 1
+	}
+	return 0
+}
+
+func (c *costCounter) tooComplex() error {
+	return problem.New(http.StatusBadRequest, problem.CodeQueryTooComplex,
+		fmt.Sprintf("query costs more than %d; request fewer items or texts", c.maxCost))
+}
+
+// namedType unwraps list and non-null types
+func namedType(t graphql.Type) graphql.Type {
+	for {
+		switch wrapped := t.(type) {
+		case *graphql.List:
+			t = wrapped.OfType
+		case *graphql.NonNull:
+			t = wrapped.OfType
+		default:
+			return t
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// api/routes/graph/handler.go
+package graph
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/grammar"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/services"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Handler serves /api/graphql
+type Handler struct {
+	dbService     *database.MongoDB
+	schema        graphql.Schema
+	maxDepth      int
+	maxComplexity int
+}
+
+// request is a GraphQL query sent over HTTP
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// NewHandler serves queries no deeper than maxDepth and costing no more than maxComplexity
+func NewHandler(dbService *database.MongoDB, usageService *services.UsageService, cache *grammar.Cache, maxDepth, maxComplexity int) (*Handler, error) {
+	grammarService := services.NewGrammarService(dbService)
+	grammarService.GrammarService.Cache = cache
+
+	schema, err := newSchema(&resolver{
+		grammarService: grammarService,
+		usageService:   usageService,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{
+		dbService:     dbService,
+		schema:        schema,
+		maxDepth:      maxDepth,
+		maxComplexity: maxComplexity,
+	}, nil
+}
+
+// HandleQuery runs a query. Malformed requests and queries over the limits are answered
+// with a problem; errors from the query itself are reported in the result, as GraphQL clients expect
+func (h *Handler) HandleQuery(w http.ResponseWriter, r *http.Request) {
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if p := problem.FromError(err); p != nil {
+			problem.Write(w, r, p)
+			return
+		}
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "request body must be a JSON object")
+		return
+	}
+	if req.OperationName != "" {
+		r = r.WithContext(logging.Annotate(r.Context(), "graphql_operation", req.OperationName))
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		h.respond(w, r, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+	if validation := graphql.ValidateDocument(&h.schema, doc, nil); !validation.IsValid {
+		h.respond(w, r, &graphql.Result{Errors: validation.Errors})
+		return
+	}
+	if err := checkComplexity(h.schema, doc, req.OperationName, req.Variables, h.maxDepth, h.maxComplexity); err != nil {
+		problem.Error(w, r, "GraphQL complexity check failed", err)
+		return
+	}
+
+	ctx := withLoaders(r.Context(), newLoaders(h.dbService, identity.FromContext(r.Context())))
+	h.respond(w, r, graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	}))
+}
+
+// respond writes result with its errors mapped to problem codes
+func (h *Handler) respond(w http.ResponseWriter, r *http.Request, result *graphql.Result) {
+	for i, formatted := range result.Errors {
+		result.Errors[i] = h.formatError(r, formatted)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// formatError reports the problem a field error maps to. Errors without a path come from
+// parsing, validation or variable coercion and are the client's; unknown field errors are
+// logged and reported without their details, like problem.Error does
+func (h *Handler) formatError(r *http.Request, formatted gqlerrors.FormattedError) gqlerrors.FormattedError {
+	requestID := logging.RequestID(r.Context())
+	if len(formatted.Path) == 0 {
+		formatted.Extensions = map[string]interface{}{"code": problem.CodeInvalidRequest, "requestId": requestID}
+		return formatted
+	}
+
+	err := cause(formatted)
+	p := problem.FromError(err)
+	if p == nil || p.Status >= http.StatusInternalServerError {
+		logging.FromContext(r.Context()).Error("GraphQL field failed", "error", err, "path", formatted.Path)
+	}
+	if p == nil {
+		p = problem.New(http.StatusInternalServerError, problem.CodeInternal, "internal server error")
+	}
+
+	formatted.Message = p.Error()
+	formatted.Extensions = map[string]interface{}{"code": p.Code, "status": p.Status, "requestId": requestID}
+	return formatted
+}
+
+// cause unwraps the errors the executor wraps resolver errors in; errors from deferred
+// resolvers are wrapped more than once
+func cause(err error) error {
+	for {
+		var next error
+		switch e := err.(type) {
+		case gqlerrors.FormattedError:
+			next = e.OriginalError()
+		case *gqlerrors.Error:
+			next = e.OriginalError
+		case gqlerrors.Error:
+			next = e.OriginalError
+		}
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
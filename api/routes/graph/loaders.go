@@ -0,0 +1,151 @@
+// api/routes/graph/loaders.go
+package graph
+
+import (
+	"context"
+	"sync"
+
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/identity"
+)
+
+// loader batches the keys requested while one level of a query resolves into a single
+// fetch, and caches the results for the rest of the request. Load returns a thunk, which
+// resolvers hand to the executor to call once every sibling field has queued its key
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	results map[K]V
+	errs    map[K]error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		queued:  make(map[K]bool),
+		results: make(map[K]V),
+		errs:    make(map[K]error),
+	}
+}
+
+// Load queues key for the next batch; the zero value is returned for keys the fetch did not find
+func (l *loader[K, V]) Load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		return l.wait(ctx, key)
+	}
+}
+
+// wait runs the pending batch if key has not been fetched yet
+func (l *loader[K, V]) wait(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pending) > 0 {
+		keys := l.pending
+		l.pending = nil
+
+		results, err := l.fetch(ctx, keys)
+		for _, k := range keys {
+			if err != nil {
+				l.errs[k] = err
+			} else if v, ok := results[k]; ok {
+				l.results[k] = v
+			}
+		}
+	}
+	return l.results[key], l.errs[key]
+}
+
+// loaders are the batch loaders of one request; they see only what its caller may see
+type loaders struct {
+	grammars       *loader[string, *database.Grammar]
+	userGrammars   *loader[string, []database.Grammar]
+	versions       *loader[string, []database.GrammarVersion]
+	usersBySubject *loader[string, *database.User]
+	usersByName    *loader[string, *database.User]
+}
+
+func newLoaders(db *database.MongoDB, caller *identity.Identity) *loaders {
+	var viewer string
+	if caller != nil {
+		viewer = caller.Subject
+	}
+
+	return &loaders{
+		grammars: newLoader(func(ctx context.Context, ids []string) (map[string]*database.Grammar, error) {
+			found, err := db.GetGrammarsByIDs(ctx, ids, viewer)
+			if err != nil {
+				return nil, err
+			}
+			results := make(map[string]*database.Grammar, len(found))
+			for i := range found {
+				results[found[i].GrammarID] = &found[i]
+			}
+			return results, nil
+		}),
+		userGrammars: newLoader(func(ctx context.Context, usernames []string) (map[string][]database.Grammar, error) {
+			found, err := db.GetGrammarsByUsernames(ctx, usernames, viewer)
+			if err != nil {
+				return nil, err
+			}
+			results := make(map[string][]database.Grammar, len(usernames))
+			for _, g := range found {
+				results[g.Username] = append(results[g.Username], g)
+			}
+			return results, nil
+		}),
+		versions: newLoader(func(ctx context.Context, ids []string) (map[string][]database.GrammarVersion, error) {
+			found, err := db.GetGrammarVersions(ctx, ids, viewer)
+			if err != nil {
+				return nil, err
+			}
+			results := make(map[string][]database.GrammarVersion, len(ids))
+			for _, v := range found {
+				results[v.GrammarID] = append(results[v.GrammarID], v)
+			}
+			return results, nil
+		}),
+		usersBySubject: newLoader(func(ctx context.Context, subjects []string) (map[string]*database.User, error) {
+			found, err := db.GetUsersBySubjects(ctx, subjects)
+			if err != nil {
+				return nil, err
+			}
+			results := make(map[string]*database.User, len(found))
+			for i := range found {
+				results[found[i].Subject] = &found[i]
+			}
+			return results, nil
+		}),
+		usersByName: newLoader(func(ctx context.Context, usernames []string) (map[string]*database.User, error) {
+			found, err := db.GetUsersByUsernames(ctx, usernames)
+			if err != nil {
+				return nil, err
+			}
+			results := make(map[string]*database.User, len(found))
+			for i := range found {
+				results[found[i].Username] = &found[i]
+			}
+			return results, nil
+		}),
+	}
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,418 @@
+// api/routes/graph/schema.go
+package graph
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/grammar"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/services"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits on list and generation arguments; the complexity limit bounds their product
+const (
+	defaultGrammarsLimit = 20
+	defaultVersionsLimit = 10
+	maxListLimit         = 100
+	maxGenerateCount     = 100
+)
+
+// maxSeed keeps seeds exactly representable as JSON numbers, matching /api/v2/generate
+const maxSeed = 1 << 53
+
+// generation is the result of a generate field
+type generation struct {
+	GrammarID string
+	Version   int
+	Seed      int64
+	Texts     []string
+}
+
+// resolver holds what the resolvers share across requests; per-request state, such as
+// the batch loaders, travels in the context
+type resolver struct {
+	grammarService *services.GrammarGenService
+	usageService   *services.UsageService
+}
+
+var seedType = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Seed",
+	Description: "A generation seed: an integer of magnitude at most 2^53, so it survives JSON number parsing.",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+	ParseValue: func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case float64:
+			if v == math.Trunc(v) && math.Abs(v) <= maxSeed {
+				return int64(v)
+			}
+		case int:
+			return parseSeed(int64(v))
+		case int64:
+			return parseSeed(v)
+		}
+		return nil
+	},
+	ParseLiteral: func(value ast.Value) interface{} {
+		if v, ok := value.(*ast.IntValue); ok {
+			if seed, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+				return parseSeed(seed)
+			}
+		}
+		return nil
+	},
+})
+
+func parseSeed(seed int64) interface{} {
+	if seed > maxSeed || seed < -maxSeed {
+		return nil
+	}
+	return seed
+}
+
+// newSchema builds the schema; its resolvers read through the database layer with the
+// loaders in the request context, so each level of a query costs one query per type
+func newSchema(r *resolver) (graphql.Schema, error) {
+	ruleType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Rule",
+		Fields: graphql.Fields{
+			"name":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"productions": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+		},
+	})
+
+	analysisType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Analysis",
+		Description: "What grammar content defines, and whether it compiles.",
+		Fields: graphql.Fields{
+			"valid": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*grammar.Analysis).Err == nil, nil
+				},
+			},
+			"error": &graphql.Field{
+				Type:        graphql.String,
+				Description: "Why the content does not compile, when it does not.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := p.Source.(*grammar.Analysis).Err; err != nil {
+						return err.Error(), nil
+					}
+					return nil, nil
+				},
+			},
+			"startSymbol": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"rules":       &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(ruleType)))},
+			"undefined": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+				Description: "Non-terminals referred to without a rule.",
+			},
+			"unreachable": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+				Description: "Rules that cannot be reached from the start symbol.",
+			},
+		},
+	})
+
+	generationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Generation",
+		Fields: graphql.Fields{
+			"grammarId": &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"version":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"seed":      &graphql.Field{Type: graphql.NewNonNull(seedType), Description: "Send it back to reproduce the texts."},
+			"texts":     &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+		},
+	})
+
+	versionType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "GrammarVersion",
+		Description: "A grammar as it was stored at one version.",
+		Fields: graphql.Fields{
+			"version":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"private":   &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"content":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		},
+	})
+
+	generateArgs := graphql.FieldConfigArgument{
+		"count":       &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+		"seed":        &graphql.ArgumentConfig{Type: seedType, Description: "Drawn at random when omitted."},
+		"startSymbol": &graphql.ArgumentConfig{Type: graphql.String},
+	}
+
+	var userType *graphql.Object
+	grammarType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Grammar",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.ID),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*database.Grammar).GrammarID, nil
+					},
+				},
+				"name":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"username":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"version":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"private":   &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"content":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"createdAt": &graphql.Field{Type: graphql.DateTime},
+				"updatedAt": &graphql.Field{Type: graphql.DateTime},
+				"owner": &graphql.Field{
+					Type: userType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return thunk(loadersFrom(p.Context).usersBySubject.Load(p.Context, p.Source.(*database.Grammar).Owner)), nil
+					},
+				},
+				"versions": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(versionType))),
+					Description: "Stored versions, newest first.",
+					Args: graphql.FieldConfigArgument{
+						"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultVersionsLimit},
+					},
+					Resolve: r.versions,
+				},
+				"analysis": &graphql.Field{
+					Type: graphql.NewNonNull(analysisType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return grammar.Analyze(p.Source.(*database.Grammar).Content), nil
+					},
+				},
+				"generate": &graphql.Field{
+					Type:        graphql.NewNonNull(generationType),
+					Description: "Generates texts from this version. Requires grammar:generate.",
+					Args:        generateArgs,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return r.generate(p.Context, p.Source.(*database.Grammar).GrammarID, p.Args)
+					},
+				},
+			}
+		}),
+	})
+
+	userType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"username":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"displayName": &graphql.Field{Type: graphql.String},
+			"bio":         &graphql.Field{Type: graphql.String},
+			"createdAt":   &graphql.Field{Type: graphql.DateTime},
+			"grammars": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(grammarType))),
+				Description: "Public grammars, and private ones the caller owns. Requires grammar:read.",
+				Args: graphql.FieldConfigArgument{
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultGrammarsLimit},
+				},
+				Resolve: r.userGrammars,
+			},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": &graphql.Field{
+				Type:        userType,
+				Description: "The caller, once it has a user record.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					caller := identity.FromContext(p.Context)
+					if caller == nil {
+						return nil, nil
+					}
+					return thunk(loadersFrom(p.Context).usersBySubject.Load(p.Context, caller.Subject)), nil
+				},
+			},
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"username": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return thunk(loadersFrom(p.Context).usersByName.Load(p.Context, p.Args["username"].(string))), nil
+				},
+			},
+			"grammar": &graphql.Field{
+				Type:        grammarType,
+				Description: "The latest version of a grammar. Requires grammar:read.",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := requireScope(p.Context, identity.ScopeRead); err != nil {
+						return nil, err
+					}
+					return thunk(loadersFrom(p.Context).grammars.Load(p.Context, p.Args["id"].(string))), nil
+				},
+			},
+			"analyze": &graphql.Field{
+				Type:        graphql.NewNonNull(analysisType),
+				Description: "Analyzes grammar content without storing it.",
+				Args: graphql.FieldConfigArgument{
+					"content": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return grammar.Analyze(p.Args["content"].(string)), nil
+				},
+			},
+			"generate": &graphql.Field{
+				Type:        graphql.NewNonNull(generationType),
+				Description: "Generates texts from a stored grammar. Requires grammar:generate.",
+				Args: graphql.FieldConfigArgument{
+					"grammarId":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"count":       generateArgs["count"],
+					"seed":        generateArgs["seed"],
+					"startSymbol": generateArgs["startSymbol"],
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return r.generate(p.Context, p.Args["grammarId"].(string), p.Args)
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// userGrammars resolves User.grammars
+func (r *resolver) userGrammars(p graphql.ResolveParams) (interface{}, error) {
+	if err := requireScope(p.Context, identity.ScopeRead); err != nil {
+		return nil, err
+	}
+	limit, err := limitArg(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	load := loadersFrom(p.Context).userGrammars.Load(p.Context, p.Source.(*database.User).Username)
+	return func() (interface{}, error) {
+		found, err := load()
+		if err != nil {
+			return nil, err
+		}
+		if len(found) > limit {
+			found = found[:limit]
+		}
+		grammars := make([]*database.Grammar, len(found))
+		for i := range found {
+			grammars[i] = &found[i]
+		}
+		return grammars, nil
+	}, nil
+}
+
+// versions resolves Grammar.versions; grammars stored before versions were recorded list
+// only their current version
+func (r *resolver) versions(p graphql.ResolveParams) (interface{}, error) {
+	limit, err := limitArg(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	g := p.Source.(*database.Grammar)
+	load := loadersFrom(p.Context).versions.Load(p.Context, g.GrammarID)
+	return func() (interface{}, error) {
+		found, err := load()
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 || found[0].Version < g.Version {
+			current := database.GrammarVersion{
+				GrammarID: g.GrammarID,
+				Version:   g.Version,
+				Name:      g.Name,
+				Owner:     g.Owner,
+				Private:   g.Private,
+				Content:   g.Content,
+				CreatedAt: g.UpdatedAt,
+			}
+			found = append([]database.GrammarVersion{current}, found...)
+		}
+		if len(found) > limit {
+			found = found[:limit]
+		}
+		return found, nil
+	}, nil
+}
+
+// generate checks the caller's quota, generates from a stored grammar, and meters what
+// was generated
+func (r *resolver) generate(ctx context.Context, grammarID string, args map[string]interface{}) (interface{}, error) {
+	if err := requireScope(ctx, identity.ScopeGenerate); err != nil {
+		return nil, err
+	}
+
+	count, _ := args["count"].(int)
+	if count < 1 || count > maxGenerateCount {
+		return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest,
+			fmt.Sprintf("count must be between 1 and %d", maxGenerateCount))
+	}
+	seed, ok := args["seed"].(int64)
+	if !ok {
+		seed = rand.Int63n(maxSeed)
+	}
+	startSymbol, _ := args["startSymbol"].(string)
+
+	quota, err := r.usageService.ReserveQuota(ctx, count)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result, err := r.grammarService.GenerateWith(ctx, services.GenerateRequest{
+		GrammarID: grammarID,
+		Count:     count,
+		Seed:      seed,
+		Options:   grammar.Options{StartSymbol: startSymbol},
+	})
+	if err != nil {
+		r.usageService.ReleaseQuota(ctx, quota, count)
+		return nil, err
+	}
+	r.usageService.RecordGeneration(ctx, result, time.Since(start))
+	r.usageService.ReleaseQuota(ctx, quota, count-len(result.Messages))
+
+	return &generation{
+		GrammarID: result.GrammarID,
+		Version:   result.Version,
+		Seed:      seed,
+		Texts:     result.Messages,
+	}, nil
+}
+
+// requireScope rejects callers without scope, as RequireScopes does for routes
+func requireScope(ctx context.Context, scope string) error {
+	if missing, ok := identity.FromContext(ctx).MissingScope([]string{scope}); ok {
+		return problem.New(http.StatusForbidden, problem.CodeInsufficientScope, "insufficient scope: missing "+missing)
+	}
+	return nil
+}
+
+func limitArg(args map[string]interface{}) (int, error) {
+	limit, _ := args["limit"].(int)
+	if limit < 1 || limit > maxListLimit {
+		return 0, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest,
+			fmt.Sprintf("limit must be between 1 and %d", maxListLimit))
+	}
+	return limit, nil
+}
+
+// thunk adapts a loader result to the signature the executor defers
+func thunk[V any](load func() (V, error)) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		return load()
+	}
+}
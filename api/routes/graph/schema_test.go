@@ -0,0 +1,75 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/database/dbtest"
+	"grammarhive-backend/core/grammar"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/services"
+)
+
+func TestVersionsLeaveOutPrivateSnapshots(t *testing.T) {
+	db := dbtest.Connect(t)
+	ctx := context.Background()
+
+	// Versions 1 and 2 were private; the grammar was published with version 3
+	g := &database.Grammar{GrammarID: "g1", Name: "draft", Username: "ada", Owner: "auth0|ada", Private: true, Content: "{\n<start>\na ;\n}"}
+	if err := db.StoreGrammarFor(ctx, g); err != nil {
+		t.Fatal(err)
+	}
+	for _, private := range []bool{true, false} {
+		stored, err := db.GetGrammarByID(ctx, "g1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored.Private = private
+		if err := db.StoreGrammarFor(ctx, stored); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h, err := NewHandler(db, services.NewUsageService(db, 0), grammar.NewCache(0), 10, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := func(subject string) []int {
+		caller := &identity.Identity{Subject: subject, Scopes: []string{identity.ScopeRead}}
+		body := `{"query": "{ grammar(id: \"g1\") { versions(limit: 10) { version } } }"}`
+		r := httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.HandleQuery(w, r.WithContext(identity.WithIdentity(ctx, caller)))
+
+		var resp struct {
+			Data struct {
+				Grammar struct {
+					Versions []struct {
+						Version int `json:"version"`
+					} `json:"versions"`
+				} `json:"grammar"`
+			} `json:"data"`
+			Errors []json.RawMessage `json:"errors"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || len(resp.Errors) > 0 {
+			t.Fatalf("query as %s: %d %v %s", subject, w.Code, err, resp.Errors)
+		}
+		var found []int
+		for _, v := range resp.Data.Grammar.Versions {
+			found = append(found, v.Version)
+		}
+		return found
+	}
+
+	if got := versions("auth0|bob"); len(got) != 1 || got[0] != 3 {
+		t.Fatalf("another caller sees versions %v, want only the public version 3", got)
+	}
+	if got := versions("auth0|ada"); len(got) != 3 || got[0] != 3 || got[2] != 1 {
+		t.Fatalf("the owner sees versions %v, want 3, 2 and 1", got)
+	}
+}
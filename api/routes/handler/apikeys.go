@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/services"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// maxAPIKeyExpiresIn caps the lifetime of new keys, in seconds
+const maxAPIKeyExpiresIn = 2 * 365 * 24 * 60 * 60
+
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+	auditService  *services.AuditService
+}
+
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService, auditService *services.AuditService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		auditService:  auditService,
+	}
+}
+
+// HandleCreate issues a new API key; the plaintext key is only ever returned here
+func (h *APIKeyHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name      string   `json:"name"`
+		Scopes    []string `json:"scopes"`
+		ExpiresIn int64    `json:"expiresIn"` // seconds, 0 for no expiry
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, r, "request body must be a JSON object", err)
+		return
+	}
+	if req.Name == "" || len(req.Name) > 100 {
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "name must be between 1 and 100 characters")
+		return
+	}
+	if req.ExpiresIn < 0 || req.ExpiresIn > maxAPIKeyExpiresIn {
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest,
+			fmt.Sprintf("expiresIn must be between 0 and %d seconds", maxAPIKeyExpiresIn))
+		return
+	}
+
+	key, plaintext, err := h.apiKeyService.Create(r.Context(), req.Name, req.Scopes, time.Duration(req.ExpiresIn)*time.Second)
+
+	entry := auditEntry(r, services.AuditAPIKeyCreate)
+	entry.Owner = identity.FromContext(r.Context()).Subject
+	if key != nil {
+		entry.Resource = key.KeyID
+	}
+	recordAudit(r, h.auditService, entry, err)
+
+	if err != nil {
+		serverError(w, r, "Error creating api key", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key":    plaintext,
+		"apiKey": key,
+		"status": "success",
+	})
+}
+
+// HandleList lists the caller's API keys
+func (h *APIKeyHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.apiKeyService.List(r.Context())
+	if err != nil {
+		serverError(w, r, "Error retrieving api keys", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// HandleRevoke revokes one of the caller's API keys
+func (h *APIKeyHandler) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	keyID := mux.Vars(r)["keyId"]
+
+	err := h.apiKeyService.Revoke(r.Context(), keyID)
+
+	entry := auditEntry(r, services.AuditAPIKeyRevoke)
+	entry.Owner = identity.FromContext(r.Context()).Subject
+	entry.Resource = keyID
+	recordAudit(r, h.auditService, entry, err)
+
+	if err != nil {
+		serverError(w, r, "Error revoking api key", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keyId":  keyID,
+		"status": "revoked",
+	})
+}
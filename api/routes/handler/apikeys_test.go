@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/database/dbtest"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/services"
+
+	"github.com/gorilla/mux"
+)
+
+func TestCreateAPIKeyRejectsOutOfRangeLifetimes(t *testing.T) {
+	h := NewAPIKeyHandler(nil, nil)
+
+	for _, expiresIn := range []string{"-1", "63072001", "9223372036854775807"} {
+		body := `{"name": "ci", "expiresIn": ` + expiresIn + `}`
+		w := httptest.NewRecorder()
+		h.HandleCreate(w, httptest.NewRequest(http.MethodPost, "/api/user/apikeys", strings.NewReader(body)))
+
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid_request") {
+			t.Errorf("expiresIn %s: got %d %s, want 400 invalid_request", expiresIn, w.Code, w.Body)
+		}
+	}
+}
+
+func TestAPIKeyChangesAreAudited(t *testing.T) {
+	db := dbtest.Connect(t)
+	auditService := services.NewAuditService(db)
+	h := NewAPIKeyHandler(services.NewAPIKeyService(db), auditService)
+	caller := &identity.Identity{Subject: "auth0|ada", Username: "ada", Scopes: []string{identity.ScopeAPIKeys, identity.ScopeRead}}
+	ctx := identity.WithIdentity(context.Background(), caller)
+
+	w := httptest.NewRecorder()
+	h.HandleCreate(w, httptest.NewRequest(http.MethodPost, "/api/user/apikeys",
+		strings.NewReader(`{"name": "ci", "scopes": ["grammar:read"]}`)).WithContext(ctx))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	var created struct {
+		APIKey struct {
+			KeyID string `json:"keyId"`
+		} `json:"apiKey"`
+	}
+	json.NewDecoder(w.Body).Decode(&created)
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/api/user/apikeys/"+created.APIKey.KeyID, nil).WithContext(ctx)
+	h.HandleRevoke(w, mux.SetURLVars(req, map[string]string{"keyId": created.APIKey.KeyID}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("revoke: %d %s", w.Code, w.Body)
+	}
+
+	entries, err := auditService.Query(ctx, database.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d audit entries, want 2: %+v", len(entries), entries)
+	}
+	for i, action := range []string{services.AuditAPIKeyRevoke, services.AuditAPIKeyCreate} {
+		entry := entries[i]
+		if entry.Action != action || entry.Resource != created.APIKey.KeyID || entry.Actor != caller.Subject || entry.Result != services.AuditSuccess {
+			t.Errorf("entry %d = %+v, want a successful %s of %s", i, entry, action, created.APIKey.KeyID)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	middleware "grammarhive-backend/api/routes/middleware"
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// auditEntry starts a successful audit entry for the request's client
+func auditEntry(r *http.Request, action string) database.AuditEntry {
+	return database.AuditEntry{
+		Action:    action,
+		IP:        middleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		Result:    services.AuditSuccess,
+	}
+}
+
+// recordAudit completes the entry with the outcome err describes and records it; refusals
+// are denials and other errors failures
+func recordAudit(r *http.Request, auditService *services.AuditService, entry database.AuditEntry, err error) {
+	switch {
+	case errors.Is(err, services.ErrNotOwner), errors.Is(err, services.ErrKeyCreatesKey), errors.Is(err, services.ErrScopeNotGranted):
+		entry.Result, entry.Detail = services.AuditDenied, err.Error()
+	case err != nil:
+		entry.Result, entry.Detail = services.AuditFailure, err.Error()
+	}
+	auditService.Record(r.Context(), entry)
+}
+
+// HandleList returns a page of audit entries, newest first; pass `cursor` from the previous page to continue
+func (h *AuditHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditFilterFromRequest(r)
+	if err != nil {
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	filter.Limit = defaultAuditPageSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || limit <= 0 || limit > maxAuditPageSize {
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "limit must be between 1 and 200")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.auditService.Query(r.Context(), filter)
+	if err != nil {
+		serverError(w, r, "Error retrieving audit log", err)
+		return
+	}
+
+	var nextCursor string
+	if int64(len(entries)) == filter.Limit {
+		nextCursor = entries[len(entries)-1].ID.Hex()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries":    entries,
+		"nextCursor": nextCursor,
+	})
+}
+
+// HandleExport streams every matching audit entry as JSON lines
+func (h *AuditHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditFilterFromRequest(r)
+	if err != nil {
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+
+	encoder := json.NewEncoder(w)
+	err = h.auditService.Export(r.Context(), filter, func(entry *database.AuditEntry) error {
+		return encoder.Encode(entry)
+	})
+	if err != nil {
+		// Headers are already sent once the first line is written; all we can do is stop
+		logging.FromContext(r.Context()).Error("audit export failed", "error", err)
+	}
+}
+
+func auditFilterFromRequest(r *http.Request) (database.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := database.AuditFilter{
+		Actor:     query.Get("actor"),
+		Action:    query.Get("action"),
+		GrammarID: query.Get("grammarId"),
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		before, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+		filter.Before = before
+	}
+	if v := query.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("since must be an RFC 3339 timestamp")
+		}
+		filter.Since = since
+	}
+	return filter, nil
+}
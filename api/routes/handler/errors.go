@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"grammarhive-backend/api/routes/problem"
+	"net/http"
+)
+
+// invalidBody replies to a request whose body could not be read or decoded
+func invalidBody(w http.ResponseWriter, r *http.Request, detail string, err error) {
+	if p := problem.FromError(err); p != nil {
+		problem.Write(w, r, p)
+		return
+	}
+	problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, detail)
+}
+
+// serverError replies with the problem err maps to, or logs the cause with the request's
+// logger and replies with a generic 500
+func serverError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	problem.Error(w, r, msg, err)
+}
@@ -2,20 +2,39 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"grammarhive-backend/api/routes/validation"
+	"grammarhive-backend/api/routes/problem"
 	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/grammar"
+	"grammarhive-backend/core/logging"
 	"grammarhive-backend/core/services"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultGenerateCount is how many texts generateList returns when count is omitted; a count
+// that is sent must be between 1 and maxGenerateListCount, as it always has been
+const (
+	defaultGenerateCount = 10
+	maxGenerateListCount = 9
 )
 
 type GrammarHandler struct {
 	grammarService *services.GrammarGenService
+	usageService   *services.UsageService
 }
 
-func NewGrammarHandler(dbService *database.MongoDB) *GrammarHandler {
+// NewGrammarHandler serves generations from compiled grammars kept in cache
+func NewGrammarHandler(dbService *database.MongoDB, usageService *services.UsageService, cache *grammar.Cache) *GrammarHandler {
+	grammarService := services.NewGrammarService(dbService)
+	grammarService.GrammarService.Cache = cache
+
 	return &GrammarHandler{
-		grammarService: services.NewGrammarService(dbService),
+		grammarService: grammarService,
+		usageService:   usageService,
 	}
 }
 
@@ -23,53 +42,99 @@ func NewGrammarHandler(dbService *database.MongoDB) *GrammarHandler {
 func (h *GrammarHandler) HandleGenerate(w http.ResponseWriter, r *http.Request) {
 	// Check if grammarService is initialized
 	if h.grammarService == nil {
-		http.Error(w, "Grammar service is not initialized", http.StatusInternalServerError)
+		problem.Respond(w, r, http.StatusInternalServerError, problem.CodeInternal, "Grammar service is not initialized")
 		return
 	}
 
-	grammarID, err := validation.ValidateGenerateRequest(r)
-	if err != nil {
-		http.Error(w, "Missing required parameter: grammarId", http.StatusBadRequest)
+	// grammarId has been checked against the OpenAPI document
+	grammarID := r.URL.Query().Get("grammarId")
+	r = r.WithContext(logging.Annotate(r.Context(), "grammar_id", grammarID))
+
+	quota, ok := h.reserveQuota(w, r, 1)
+	if !ok {
 		return
 	}
 
 	// Utilize the GrammarService to generate the text
-	generatedText, err := h.grammarService.Generate(grammarID)
+	start := time.Now()
+	generation, err := h.grammarService.Generate(r.Context(), grammarID)
 	if err != nil {
-		http.Error(w, "Generation failed", http.StatusInternalServerError)
+		h.usageService.ReleaseQuota(r.Context(), quota, 1)
+		serverError(w, r, "Generation failed", err)
 		return
 	}
 
+	h.recordUsage(r, quota, 1, generation, time.Since(start))
+
 	// Return the generated text
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":    generatedText,
-		"status":     "success",
-		"grammarId":  grammarID,
+		"message":   generation.Messages[0],
+		"status":    "success",
+		"grammarId": grammarID,
 	})
 }
 
 // HandleGenerateList handles the generation of multiple grammar texts
 func (h *GrammarHandler) HandleGenerateList(w http.ResponseWriter, r *http.Request) {
-	grammarID, count, err := validation.ValidateGenerateListRequest(r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	// grammarId has been checked against the OpenAPI document
+	grammarID := r.URL.Query().Get("grammarId")
+	count := defaultGenerateCount
+	if raw := r.URL.Query().Get("count"); raw != "" {
+		var err error
+		count, err = strconv.Atoi(raw)
+		if err != nil || count < 1 || count > maxGenerateListCount {
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest,
+				fmt.Sprintf("count must be between 1 and %d", maxGenerateListCount))
+			return
+		}
+	}
+	r = r.WithContext(logging.Annotate(r.Context(), "grammar_id", grammarID, "count", count))
+
+	quota, ok := h.reserveQuota(w, r, count)
+	if !ok {
 		return
 	}
 
 	// Use GrammarService to generate multiple texts
-	messages, err := h.grammarService.GenerateMultiple(grammarID, count)
+	start := time.Now()
+	generation, err := h.grammarService.GenerateMultiple(r.Context(), grammarID, count)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Generation failed: %v", err), http.StatusInternalServerError)
+		h.usageService.ReleaseQuota(r.Context(), quota, count)
+		serverError(w, r, "Generation failed", err)
 		return
 	}
 
+	h.recordUsage(r, quota, count, generation, time.Since(start))
+
 	// Return the generated texts
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"messages":   messages,
-		"count":      len(messages),
-		"status":     "success",
-		"grammarId":  grammarID,
+		"messages":  generation.Messages,
+		"count":     len(generation.Messages),
+		"status":    "success",
+		"grammarId": grammarID,
 	})
 }
+
+// reserveQuota takes count generations from the caller's quota, writing a 429 and returning
+// false when they do not fit
+func (h *GrammarHandler) reserveQuota(w http.ResponseWriter, r *http.Request, count int) (*services.Quota, bool) {
+	quota, err := h.usageService.ReserveQuota(r.Context(), count)
+	if errors.Is(err, services.ErrQuotaExceeded) {
+		retryAfter := time.Until(quota.ResetsAt).Seconds()
+		w.Header().Set("Retry-After", fmt.Sprint(int64(math.Ceil(retryAfter))))
+	}
+	if err != nil {
+		serverError(w, r, "Error checking usage quota", err)
+		return nil, false
+	}
+	return quota, true
+}
+
+// recordUsage meters the generation and records its metrics, handing back the generations
+// reserved for it that it did not deliver
+func (h *GrammarHandler) recordUsage(r *http.Request, quota *services.Quota, reserved int, generation *services.Generation, latency time.Duration) {
+	h.usageService.RecordGeneration(r.Context(), generation, latency)
+	h.usageService.ReleaseQuota(r.Context(), quota, reserved-len(generation.Messages))
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/grammar"
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/services"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output formats for /api/v2/generate
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// maxDrawnSeed keeps seeds drawn for callers exactly representable as JavaScript numbers
+const maxDrawnSeed = 1 << 53
+
+// generateRequest is the body of POST /api/v2/generate; the OpenAPI document has already
+// checked its types and ranges
+type generateRequest struct {
+	GrammarID   string            `json:"grammarId"`
+	Content     string            `json:"content"`
+	Count       int               `json:"count"`
+	Seed        *int64            `json:"seed"`
+	StartSymbol string            `json:"startSymbol"`
+	Variables   map[string]string `json:"variables"`
+	Format      string            `json:"format"`
+}
+
+// HandleGenerateV2 generates texts from a stored grammar or inline grammar content. Every
+// generation is reproducible: the seed is returned, and sending it back yields the same texts
+func (h *GrammarHandler) HandleGenerateV2(w http.ResponseWriter, r *http.Request) {
+	var req generateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, r, "request body must be a JSON object", err)
+		return
+	}
+	if (req.GrammarID == "") == (req.Content == "") {
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "exactly one of grammarId and content is required")
+		return
+	}
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.Format == "" {
+		req.Format = FormatJSON
+	}
+	if req.Seed == nil {
+		seed := rand.Int63n(maxDrawnSeed)
+		req.Seed = &seed
+	}
+
+	grammarID := req.GrammarID
+	if grammarID == "" {
+		grammarID = services.InlineGrammarID
+	}
+	r = r.WithContext(logging.Annotate(r.Context(), "grammar_id", grammarID, "count", req.Count, "seed", *req.Seed))
+
+	quota, ok := h.reserveQuota(w, r, req.Count)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	generation, err := h.grammarService.GenerateWith(r.Context(), services.GenerateRequest{
+		GrammarID: req.GrammarID,
+		Content:   req.Content,
+		Count:     req.Count,
+		Seed:      *req.Seed,
+		Options: grammar.Options{
+			StartSymbol: req.StartSymbol,
+			Variables:   req.Variables,
+		},
+	})
+	if err != nil {
+		h.usageService.ReleaseQuota(r.Context(), quota, req.Count)
+		serverError(w, r, "Generation failed", err)
+		return
+	}
+
+	h.recordUsage(r, quota, req.Count, generation, time.Since(start))
+
+	w.Header().Set("X-Generation-Seed", strconv.FormatInt(*req.Seed, 10))
+	if req.Format == FormatText {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(strings.Join(generation.Messages, "\n") + "\n"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"grammarId": generation.GrammarID,
+		"version":   generation.Version,
+		"seed":      *req.Seed,
+		"count":     len(generation.Messages),
+		"messages":  generation.Messages,
+		"status":    "success",
+	})
+}
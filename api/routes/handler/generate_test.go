@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"grammarhive-backend/api/routes/problem"
+)
+
+func TestGenerateListRejectsBadCounts(t *testing.T) {
+	// Counts are refused before the handler needs its services
+	h := &GrammarHandler{}
+	for _, count := range []string{"ten", "1.5", "0", "-1", "10", "100"} {
+		w := httptest.NewRecorder()
+		h.HandleGenerateList(w, httptest.NewRequest(http.MethodGet, "/api/grammar/generateList?grammarId=g1&count="+count, nil))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), problem.CodeInvalidRequest) {
+			t.Errorf("count=%s: got %d %s, want 400 %s", count, w.Code, w.Body, problem.CodeInvalidRequest)
+		}
+	}
+}
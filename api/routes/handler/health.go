@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	middleware "grammarhive-backend/api/routes/middleware"
+	"grammarhive-backend/core/grammar"
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/version"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout bounds each readiness check so a hung dependency reports as down
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck reports the state of one dependency; a non-nil error makes the service not ready
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) (interface{}, error)
+}
+
+// checkResult is all the public readiness endpoint tells of a check; why it failed is logged
+type checkResult struct {
+	Status string `json:"status"`
+}
+
+type HealthHandler struct {
+	checks []HealthCheck
+}
+
+func NewHealthHandler(checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// HandleLive reports that the process is up and serving requests
+func (h *HealthHandler) HandleLive(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+		"build":  version.Current(),
+	})
+}
+
+// HandleReady runs every dependency check and replies 503 unless all of them pass. Failures
+// are logged with their error and details, which are not part of the reply
+func (h *HealthHandler) HandleReady(w http.ResponseWriter, r *http.Request) {
+	results := make(map[string]checkResult, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			details, err := check.Check(ctx)
+			latency := float64(time.Since(start).Microseconds()) / 1000
+			result := checkResult{Status: "ok"}
+			if err != nil {
+				result.Status = "fail"
+				logging.FromContext(r.Context()).Warn("readiness check failed",
+					"check", check.Name, "latency_ms", latency, "details", details, "error", err)
+			} else {
+				logging.FromContext(r.Context()).Debug("readiness check passed",
+					"check", check.Name, "latency_ms", latency, "details", details)
+			}
+
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	status, code := "ready", http.StatusOK
+	for _, result := range results {
+		if result.Status != "ok" {
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+	}
+
+	writeHealth(w, code, map[string]interface{}{
+		"status": status,
+		"build":  version.Current(),
+		"checks": results,
+	})
+}
+
+// MongoCheck pings the database
+func MongoCheck(db interface {
+	Ping(ctx context.Context) error
+}) HealthCheck {
+	return HealthCheck{
+		Name: "mongo",
+		Check: func(ctx context.Context) (interface{}, error) {
+			return nil, db.Ping(ctx)
+		},
+	}
+}
+
+// JWKSCheck fails when any trusted issuer's signing keys have gone stale
+func JWKSCheck(authenticator *middleware.Authenticator) HealthCheck {
+	return HealthCheck{
+		Name: "jwks",
+		Check: func(ctx context.Context) (interface{}, error) {
+			statuses := authenticator.KeyStatus()
+			for _, status := range statuses {
+				if status.Stale {
+					return statuses, fmt.Errorf("signing keys for %s are stale", status.Issuer)
+				}
+			}
+			if len(statuses) == 0 {
+				return statuses, errors.New("no trusted issuers")
+			}
+			return statuses, nil
+		},
+	}
+}
+
+// CacheCheck logs the grammar cache's occupancy; it never fails readiness
+func CacheCheck(cache *grammar.Cache) HealthCheck {
+	return HealthCheck{
+		Name: "grammarCache",
+		Check: func(ctx context.Context) (interface{}, error) {
+			return map[string]int{
+				"entries":  cache.Len(),
+				"capacity": cache.Cap(),
+			}, nil
+		},
+	}
+}
+
+func writeHealth(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"grammarhive-backend/api/routes/openapi"
+	"grammarhive-backend/core/logging"
+
+	"github.com/gorilla/mux"
+)
+
+func TestReadinessHidesCheckErrors(t *testing.T) {
+	h := NewHealthHandler(
+		HealthCheck{Name: "mongo", Check: func(ctx context.Context) (interface{}, error) {
+			return nil, errors.New("dial tcp 10.0.0.5:27017: connection refused")
+		}},
+		HealthCheck{Name: "grammarCache", Check: func(ctx context.Context) (interface{}, error) {
+			return map[string]int{"entries": 1}, nil
+		}},
+	)
+
+	var logs bytes.Buffer
+	ctx := logging.WithLogger(context.Background(), slog.New(slog.NewTextHandler(&logs, nil)))
+	w := httptest.NewRecorder()
+	h.HandleReady(w, httptest.NewRequest(http.MethodGet, "/api/health/ready", nil).WithContext(ctx))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "10.0.0.5") || strings.Contains(body, "entries") {
+		t.Fatalf("reply exposes check errors or details: %s", body)
+	}
+	if !strings.Contains(body, `"mongo":{"status":"fail"}`) || !strings.Contains(body, `"grammarCache":{"status":"ok"}`) {
+		t.Fatalf("reply does not give each check's status: %s", body)
+	}
+	if !strings.Contains(logs.String(), "connection refused") {
+		t.Fatalf("check error was not logged: %s", logs.String())
+	}
+}
+
+func TestHealthRepliesMatchTheDocument(t *testing.T) {
+	spec, err := openapi.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, failing := range []bool{false, true} {
+		h := NewHealthHandler(HealthCheck{Name: "mongo", Check: func(ctx context.Context) (interface{}, error) {
+			if failing {
+				return nil, errors.New("connection refused")
+			}
+			return nil, nil
+		}})
+		router := mux.NewRouter()
+		router.Use(spec.Validate(true))
+		router.HandleFunc("/api/health/live", h.HandleLive).Methods("GET")
+		router.HandleFunc("/api/health/ready", h.HandleReady).Methods("GET")
+
+		for _, path := range []string{"/api/health/live", "/api/health/ready"} {
+			var logs bytes.Buffer
+			ctx := logging.WithLogger(context.Background(), slog.New(slog.NewTextHandler(&logs, nil)))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx))
+
+			if strings.Contains(logs.String(), "response does not match the OpenAPI document") {
+				t.Errorf("%s (failing check %v) replied %d %s: %s", path, failing, w.Code, w.Body.String(), logs.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/services"
+	"net/http"
+	"time"
+)
+
+// maxUsageRange bounds the days returned by a single usage query
+const maxUsageRange = 92 * 24 * time.Hour
+
+type MeHandler struct {
+	userService  *services.UserService
+	usageService *services.UsageService
+}
+
+func NewMeHandler(userService *services.UserService, usageService *services.UsageService) *MeHandler {
+	return &MeHandler{
+		userService:  userService,
+		usageService: usageService,
+	}
+}
+
+// HandleGetMe returns the caller's identity, scopes, profile, quota and grammar counts
+func (h *MeHandler) HandleGetMe(w http.ResponseWriter, r *http.Request) {
+	me, err := h.userService.Me(r.Context())
+	if err != nil {
+		serverError(w, r, "Error retrieving user", err)
+		return
+	}
+
+	me.Quota, err = h.usageService.Quota(r.Context())
+	if err != nil {
+		serverError(w, r, "Error retrieving usage quota", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(me)
+}
+
+// HandleUpdateMe updates the caller's display name and bio
+func (h *MeHandler) HandleUpdateMe(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DisplayName *string `json:"displayName"`
+		Bio         *string `json:"bio"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, r, "request body must be a JSON object", err)
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(r.Context(), req.DisplayName, req.Bio)
+	if err != nil {
+		serverError(w, r, "Error updating user", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// HandleGetUsage returns the caller's quota and per-day usage between `from` and `to`
+// (YYYY-MM-DD, UTC), defaulting to the current month
+func (h *MeHandler) HandleGetUsage(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := now
+
+	var err error
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse(database.UsageDayFormat, v); err != nil {
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "from must be a date formatted as YYYY-MM-DD")
+			return
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.Parse(database.UsageDayFormat, v); err != nil {
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "to must be a date formatted as YYYY-MM-DD")
+			return
+		}
+	}
+	if to.Before(from) || to.Sub(from) > maxUsageRange {
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "to must be after from and at most 92 days later")
+		return
+	}
+
+	quota, err := h.usageService.Quota(r.Context())
+	if err != nil {
+		serverError(w, r, "Error retrieving usage quota", err)
+		return
+	}
+
+	days, err := h.usageService.Usage(r.Context(), from, to)
+	if err != nil {
+		serverError(w, r, "Error retrieving usage", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":  from.Format(database.UsageDayFormat),
+		"to":    to.Format(database.UsageDayFormat),
+		"quota": quota,
+		"days":  days,
+	})
+}
@@ -1,48 +1,50 @@
 package handler
 
 import (
-	"context"
-	"crypto/rand"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"grammarhive-backend/api/routes/problem"
 	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/identity"
 	"grammarhive-backend/core/services"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 )
 
 type ProfileHandler struct {
 	profileService *services.ProfileService
+	auditService   *services.AuditService
 }
 
-func NewProfileHandler(dbService *database.MongoDB) *ProfileHandler {
-	return &ProfileHandler{
-		profileService: services.NewProfileService(dbService),
-	}
-}
+func NewProfileHandler(dbService *database.MongoDB, auditService *services.AuditService, webhookService *services.WebhookService) *ProfileHandler {
+	profileService := services.NewProfileService(dbService)
+	profileService.Webhooks = webhookService
 
-// GenerateRandomID generates a random hex string of specified length
-func GenerateRandomID(length int) (string, error) {
-	b := make([]byte, length)
-	if _, err := rand.Read(b); err != nil {
-		return "", fmt.Errorf("failed to generate random ID: %w", err)
+	return &ProfileHandler{
+		profileService: profileService,
+		auditService:   auditService,
 	}
-	return base64.URLEncoding.EncodeToString(b)[:length], nil
 }
 
 func (p *ProfileHandler) HandleGetGrammarByUsername(w http.ResponseWriter, r *http.Request) {
 	username := r.URL.Query().Get("username")
 	err := p.profileService.ValidateUsername(username)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
 		return
 	}
 
-	grammars, err := p.profileService.DB.GetGrammarsByUsername(username)
+	var viewer string
+	if caller := identity.FromContext(r.Context()); caller != nil {
+		viewer = caller.Subject
+	}
+
+	grammars, err := p.profileService.DB.GetGrammarsByUsername(r.Context(), username, viewer)
 	if err != nil {
-		http.Error(w, "Error retrieving grammar entries", http.StatusInternalServerError)
+		serverError(w, r, "Error retrieving grammar entries", err)
 		return
 	}
 
@@ -52,27 +54,43 @@ func (p *ProfileHandler) HandleGetGrammarByUsername(w http.ResponseWriter, r *ht
 
 func (p *ProfileHandler) HandleUpload(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		problem.Respond(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "uploads must use POST")
 		return
 	}
 
 	err := r.ParseMultipartForm(10 << 20) // 10 MB limit
+	if errors.Is(err, http.ErrNotMultipart) {
+		problem.Respond(w, r, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMediaType, "request body must be multipart/form-data")
+		return
+	}
 	if err != nil {
-		http.Error(w, "Unable to parse form", http.StatusBadRequest)
+		invalidBody(w, r, "request body is not a valid multipart form", err)
+		return
+	}
+
+	caller := identity.FromContext(r.Context())
+	if caller == nil || caller.Username == "" {
+		problem.Respond(w, r, http.StatusForbidden, problem.CodeForbidden, "token does not identify a user")
 		return
 	}
 
 	name := r.FormValue("name")
-	username := r.FormValue("username")
+	username := caller.Username
+	private, _ := strconv.ParseBool(r.FormValue("private"))
 
-	grammarID, err := GenerateRandomID(6)
+	grammarID, err := services.GenerateRandomID(services.GrammarIDLength)
 	if err != nil {
-		http.Error(w, "Error generating random value", http.StatusInternalServerError)
+		serverError(w, r, "Error generating random value", err)
+		return
 	}
 
 	file, _, err := r.FormFile("grammarFile")
+	if errors.Is(err, http.ErrMissingFile) {
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeMissingFile, "the grammarFile form field is required")
+		return
+	}
 	if err != nil {
-		http.Error(w, "Error retrieving the file", http.StatusBadRequest)
+		serverError(w, r, "Error retrieving the file", err)
 		return
 	}
 	defer file.Close()
@@ -80,19 +98,24 @@ func (p *ProfileHandler) HandleUpload(w http.ResponseWriter, r *http.Request) {
 	// validate all user generated content here
 	err = p.profileService.ValidateInput(name, username)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// validate user file here
-	if err := p.profileService.ValidateFile(file); err != nil {
-		http.Error(w, fmt.Sprintf("File validation failed: %v", err), http.StatusBadRequest)
+	err = p.profileService.ValidateFile(file)
+	if errors.Is(err, services.ErrFileTypeNotAllowed) {
+		problem.Respond(w, r, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMediaType, err.Error())
+		return
+	}
+	if err != nil {
+		problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, fmt.Sprintf("file validation failed: %v", err))
 		return
 	}
 
 	content, err := io.ReadAll(file)
 	if err != nil {
-		http.Error(w, "Error reading the file", http.StatusInternalServerError)
+		serverError(w, r, "Error reading the file", err)
 		return
 	}
 
@@ -104,18 +127,28 @@ func (p *ProfileHandler) HandleUpload(w http.ResponseWriter, r *http.Request) {
 		Version:   0,
 		Content:   string(content),
 		Username:  username,
+		Private:   private,
 		CreatedAt: currentTime,
 		UpdatedAt: currentTime,
 	}
 
-	if err = p.profileService.UploadGrammarToProfile(context.Background(), input); err != nil {
-		http.Error(w, "Error storing grammar", http.StatusInternalServerError)
+	err = p.profileService.UploadGrammarToProfile(r.Context(), input)
+
+	entry := auditEntry(r, services.AuditGrammarCreate)
+	entry.GrammarID = grammarID
+	entry.Version = input.Version + 1
+	entry.Owner = caller.Subject
+	recordAudit(r, p.auditService, entry, err)
+
+	if err != nil {
+		serverError(w, r, "Error storing grammar", err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "File uploaded and grammar stored successfully!",
-		"status":  "success",
+		"message":   "File uploaded and grammar stored successfully!",
+		"status":    "success",
+		"grammarId": grammarID,
 	})
 }
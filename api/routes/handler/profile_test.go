@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/database/dbtest"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/services"
+)
+
+func TestGrammarListingsLeaveOutTheOwner(t *testing.T) {
+	db := dbtest.Connect(t)
+	g := &database.Grammar{GrammarID: "g1", Name: "resume", Username: "ada", Owner: "https://issuer.example/|auth0|ada", Content: "{\n<start>\na ;\n}"}
+	if err := db.StoreGrammarFor(context.Background(), g); err != nil {
+		t.Fatal(err)
+	}
+	h := NewProfileHandler(db, services.NewAuditService(db), nil)
+
+	ctx := identity.WithIdentity(context.Background(), &identity.Identity{Subject: "auth0|bob", Scopes: []string{identity.ScopeRead}})
+	w := httptest.NewRecorder()
+	h.HandleGetGrammarByUsername(w, httptest.NewRequest(http.MethodGet, "/api/user/profile/grammar?username=ada", nil).WithContext(ctx))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "auth0|ada") {
+		t.Fatalf("listing exposes the owner's subject: %s", w.Body)
+	}
+
+	var listed []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil || len(listed) != 1 {
+		t.Fatalf("listing = %s, want one grammar", w.Body)
+	}
+	for _, field := range []string{"ID", "Owner"} {
+		if _, ok := listed[0][field]; ok {
+			t.Errorf("listing has %s: %s", field, w.Body)
+		}
+	}
+	for _, field := range []string{"Version", "GrammarID", "Name", "Username", "Content", "CreatedAt", "UpdatedAt"} {
+		if _, ok := listed[0][field]; !ok {
+			t.Errorf("listing lacks %s: %s", field, w.Body)
+		}
+	}
+}
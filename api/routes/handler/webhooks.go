@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultDeliveryPageSize = 20
+	maxDeliveryPageSize     = 100
+)
+
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+	auditService   *services.AuditService
+}
+
+func NewWebhookHandler(webhookService *services.WebhookService, auditService *services.AuditService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		auditService:   auditService,
+	}
+}
+
+// deliveryView is a delivery with its payload embedded as JSON rather than as a string
+type deliveryView struct {
+	database.WebhookDelivery
+	Payload json.RawMessage `json:"payload"`
+}
+
+func newDeliveryView(delivery database.WebhookDelivery) deliveryView {
+	return deliveryView{WebhookDelivery: delivery, Payload: json.RawMessage(delivery.Payload)}
+}
+
+// HandleCreate registers a webhook; its signing secret is only ever returned here
+func (h *WebhookHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL       string   `json:"url"`
+		GrammarID string   `json:"grammarId"` // empty for every grammar of the caller
+		Events    []string `json:"events"`    // empty for every event
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, r, "request body must be a JSON object", err)
+		return
+	}
+
+	webhook, secret, err := h.webhookService.Create(r.Context(), req.URL, req.GrammarID, req.Events)
+
+	entry := auditEntry(r, services.AuditWebhookCreate)
+	entry.Owner = identity.FromContext(r.Context()).Subject
+	entry.GrammarID = req.GrammarID
+	if webhook != nil {
+		entry.Resource = webhook.WebhookID
+	}
+	recordAudit(r, h.auditService, entry, err)
+
+	if err != nil {
+		serverError(w, r, "Error creating webhook", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"secret":  secret,
+		"webhook": webhook,
+		"status":  "success",
+	})
+}
+
+// HandleList lists the caller's webhooks
+func (h *WebhookHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.webhookService.List(r.Context())
+	if err != nil {
+		serverError(w, r, "Error retrieving webhooks", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// HandleDelete removes one of the caller's webhooks; deliveries still queued for it fail
+func (h *WebhookHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	webhookID := mux.Vars(r)["webhookId"]
+
+	err := h.webhookService.Delete(r.Context(), webhookID)
+
+	entry := auditEntry(r, services.AuditWebhookDelete)
+	entry.Owner = identity.FromContext(r.Context()).Subject
+	entry.Resource = webhookID
+	recordAudit(r, h.auditService, entry, err)
+
+	if err != nil {
+		serverError(w, r, "Error deleting webhook", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhookId": webhookID,
+		"status":    "deleted",
+	})
+}
+
+// HandleListDeliveries returns the latest deliveries of one of the caller's webhooks, newest
+// first, with the log of their attempts
+func (h *WebhookHandler) HandleListDeliveries(w http.ResponseWriter, r *http.Request) {
+	limit := int64(defaultDeliveryPageSize)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed <= 0 || parsed > maxDeliveryPageSize {
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidRequest,
+				fmt.Sprintf("limit must be between 1 and %d", maxDeliveryPageSize))
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.webhookService.Deliveries(r.Context(), mux.Vars(r)["webhookId"], limit)
+	if err != nil {
+		serverError(w, r, "Error retrieving webhook deliveries", err)
+		return
+	}
+
+	views := make([]deliveryView, len(deliveries))
+	for i, delivery := range deliveries {
+		views[i] = newDeliveryView(delivery)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(views)
+}
+
+// HandleRedeliver queues the payload of a delivery again; the new delivery is sent with a
+// new ID but the same event ID, so receivers can tell it is a repeat
+func (h *WebhookHandler) HandleRedeliver(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	delivery, err := h.webhookService.Redeliver(r.Context(), vars["webhookId"], vars["deliveryId"])
+	if err != nil {
+		serverError(w, r, "Error queueing webhook redelivery", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(newDeliveryView(*delivery))
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/database/dbtest"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/services"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRedeliverQueuesTheDeliveryAgain(t *testing.T) {
+	db := dbtest.Connect(t)
+	auditService := services.NewAuditService(db)
+	webhookService := services.NewWebhookService(db)
+	h := NewWebhookHandler(webhookService, auditService)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/webhooks", h.HandleCreate).Methods("POST")
+	router.HandleFunc("/api/webhooks/{webhookId}", h.HandleDelete).Methods("DELETE")
+	router.HandleFunc("/api/webhooks/{webhookId}/deliveries", h.HandleListDeliveries).Methods("GET")
+	router.HandleFunc("/api/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", h.HandleRedeliver).Methods("POST")
+
+	caller := &identity.Identity{Subject: "auth0|ada", Username: "ada", Scopes: []string{identity.ScopeWebhooks}}
+	ctx := identity.WithIdentity(context.Background(), caller)
+	serve := func(ctx context.Context, method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)).WithContext(ctx))
+		return w
+	}
+
+	w := serve(ctx, http.MethodPost, "/api/webhooks", `{"url": "https://hooks.example.com/grammarhive"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	var created struct {
+		Webhook database.Webhook `json:"webhook"`
+	}
+	json.NewDecoder(w.Body).Decode(&created)
+	webhookID := created.Webhook.WebhookID
+
+	webhookService.Notify(ctx, services.EventGrammarUpdated, &database.Grammar{GrammarID: "g1", Name: "resume", Version: 2, Owner: caller.Subject})
+
+	w = serve(ctx, http.MethodGet, "/api/webhooks/"+webhookID+"/deliveries", "")
+	var deliveries []database.WebhookDelivery
+	if err := json.NewDecoder(w.Body).Decode(&deliveries); err != nil || len(deliveries) != 1 {
+		t.Fatalf("deliveries: %d %v, want the one queued", len(deliveries), err)
+	}
+	original := deliveries[0]
+
+	w = serve(ctx, http.MethodPost, "/api/webhooks/"+webhookID+"/deliveries/"+original.DeliveryID+"/redeliver", "")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("redeliver: %d %s", w.Code, w.Body)
+	}
+	var redelivery struct {
+		database.WebhookDelivery
+		Payload json.RawMessage `json:"payload"`
+	}
+	json.NewDecoder(w.Body).Decode(&redelivery)
+	if redelivery.DeliveryID == original.DeliveryID || redelivery.RedeliveryOf != original.DeliveryID ||
+		redelivery.EventID != original.EventID || redelivery.Status != database.DeliveryPending {
+		t.Fatalf("redelivery = %+v, want a new pending delivery of event %s", redelivery.WebhookDelivery, original.EventID)
+	}
+	if !strings.Contains(string(redelivery.Payload), `"id":"g1"`) {
+		t.Fatalf("redelivery payload = %s, want the original event", redelivery.Payload)
+	}
+
+	w = serve(ctx, http.MethodGet, "/api/webhooks/"+webhookID+"/deliveries", "")
+	deliveries = nil
+	json.NewDecoder(w.Body).Decode(&deliveries)
+	if len(deliveries) != 2 {
+		t.Fatalf("got %d deliveries after redelivering, want 2", len(deliveries))
+	}
+
+	// Unknown deliveries and other callers' webhooks are not found
+	other := identity.WithIdentity(context.Background(), &identity.Identity{Subject: "auth0|bob", Scopes: []string{identity.ScopeWebhooks}})
+	for _, tt := range []struct {
+		ctx    context.Context
+		target string
+		code   string
+	}{
+		{ctx, "/api/webhooks/" + webhookID + "/deliveries/missing/redeliver", problem.CodeDeliveryNotFound},
+		{other, "/api/webhooks/" + webhookID + "/deliveries/" + original.DeliveryID + "/redeliver", problem.CodeWebhookNotFound},
+	} {
+		w := serve(tt.ctx, http.MethodPost, tt.target, "")
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), tt.code) {
+			t.Errorf("%s: got %d %s, want 404 %s", tt.target, w.Code, w.Body, tt.code)
+		}
+	}
+
+	if w := serve(ctx, http.MethodDelete, "/api/webhooks/"+webhookID, ""); w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body)
+	}
+	entries, err := auditService.Query(ctx, database.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Action != services.AuditWebhookDelete || entries[1].Action != services.AuditWebhookCreate ||
+		entries[0].Resource != webhookID || entries[1].Resource != webhookID {
+		t.Fatalf("audit entries = %+v, want the webhook's creation and deletion", entries)
+	}
+}
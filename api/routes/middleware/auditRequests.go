@@ -0,0 +1,67 @@
+// middleware/auditRequests.go
+package handler
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// auditFailureWindow is how often a client IP's failed authentications are audited
+	auditFailureWindow = time.Minute
+	// maxAuditedFailureIPs bounds the IPs tracked at once; failures from further IPs are not
+	// audited until older windows end
+	maxAuditedFailureIPs = 10000
+)
+
+// failureThrottle lets one failed authentication per IP and window into the audit log, and
+// counts the rest so the next entry can report them
+type failureThrottle struct {
+	mu      sync.Mutex
+	window  time.Duration
+	maxIPs  int
+	windows map[string]*failureWindow
+}
+
+type failureWindow struct {
+	start      time.Time
+	suppressed int
+}
+
+func newFailureThrottle(window time.Duration, maxIPs int) *failureThrottle {
+	return &failureThrottle{
+		window:  window,
+		maxIPs:  maxIPs,
+		windows: make(map[string]*failureWindow),
+	}
+}
+
+// allow reports whether a failure from ip is recorded, and how many failures from it were
+// left out since its last recorded one
+func (f *failureThrottle) allow(ip string, now time.Time) (bool, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if w, ok := f.windows[ip]; ok {
+		if now.Sub(w.start) < f.window {
+			w.suppressed++
+			return false, 0
+		}
+		suppressed := w.suppressed
+		*w = failureWindow{start: now}
+		return true, suppressed
+	}
+
+	if len(f.windows) >= f.maxIPs {
+		for key, w := range f.windows {
+			if now.Sub(w.start) >= f.window {
+				delete(f.windows, key)
+			}
+		}
+		if len(f.windows) >= f.maxIPs {
+			return false, 0
+		}
+	}
+	f.windows[ip] = &failureWindow{start: now}
+	return true, 0
+}
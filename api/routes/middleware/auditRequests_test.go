@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"grammarhive-backend/core/database"
+)
+
+// auditLog is an AuditRecorder kept in memory
+type auditLog struct {
+	mu      sync.Mutex
+	entries []database.AuditEntry
+}
+
+func (l *auditLog) Record(_ context.Context, entry database.AuditEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+func TestRecordFailureSkipsMissingCredentials(t *testing.T) {
+	audit := &auditLog{}
+	auth := NewMultiIssuer()
+	auth.Audit = audit
+
+	_, authErr := auth.Authenticate(context.Background(), "", "")
+	auth.RecordFailure(context.Background(), "203.0.113.9", "curl", "GET /api/me", authErr.Msg)
+	if len(audit.entries) != 0 {
+		t.Fatalf("recorded %+v for a request without credentials", audit.entries)
+	}
+
+	auth.RecordFailure(context.Background(), "203.0.113.9", "curl", "GET /api/me", "unauthorized")
+	if len(audit.entries) != 1 {
+		t.Fatalf("recorded %d entries for a bad token, want 1", len(audit.entries))
+	}
+}
+
+func TestRecordFailureIsThrottledPerIP(t *testing.T) {
+	audit := &auditLog{}
+	auth := NewMultiIssuer()
+	auth.Audit = audit
+
+	for i := 0; i < 50; i++ {
+		auth.RecordFailure(context.Background(), "203.0.113.9", "curl", "GET /api/me", "unauthorized")
+	}
+	auth.RecordFailure(context.Background(), "198.51.100.1", "curl", "GET /api/me", "unauthorized")
+
+	if len(audit.entries) != 2 {
+		t.Fatalf("recorded %d entries, want one per IP", len(audit.entries))
+	}
+	if audit.entries[0].IP != "203.0.113.9" || audit.entries[1].IP != "198.51.100.1" {
+		t.Fatalf("recorded %+v", audit.entries)
+	}
+}
+
+func TestFailureThrottle(t *testing.T) {
+	throttle := newFailureThrottle(time.Minute, 2)
+	start := time.Now()
+
+	if record, _ := throttle.allow("a", start); !record {
+		t.Fatal("first failure was not recorded")
+	}
+	for i := 0; i < 3; i++ {
+		if record, _ := throttle.allow("a", start.Add(time.Second)); record {
+			t.Fatal("failure within the window was recorded")
+		}
+	}
+	record, suppressed := throttle.allow("a", start.Add(time.Minute))
+	if !record || suppressed != 3 {
+		t.Fatalf("next window: record %v with %d suppressed, want true with 3", record, suppressed)
+	}
+
+	// Full: a new IP waits for a window to end
+	throttle.allow("b", start.Add(time.Minute))
+	if record, _ := throttle.allow("c", start.Add(time.Minute+time.Second)); record {
+		t.Fatal("recorded a failure beyond the tracked IPs")
+	}
+	if record, _ := throttle.allow("c", start.Add(2*time.Minute)); !record {
+		t.Fatal("expired windows were not pruned")
+	}
+}
+
+func TestFailureDetailCountsSuppressedFailures(t *testing.T) {
+	audit := &auditLog{}
+	auth := NewMultiIssuer()
+	auth.Audit = audit
+	auth.failures = newFailureThrottle(time.Millisecond, maxAuditedFailureIPs)
+
+	auth.RecordFailure(context.Background(), "203.0.113.9", "curl", "GET /api/me", "unauthorized")
+	auth.failures.windows["203.0.113.9"].suppressed = 7
+	time.Sleep(2 * time.Millisecond)
+	auth.RecordFailure(context.Background(), "203.0.113.9", "curl", "GET /api/me", "unauthorized")
+
+	if len(audit.entries) != 2 || !strings.Contains(audit.entries[1].Detail, "and 7 earlier failures") {
+		t.Fatalf("recorded %+v", audit.entries)
+	}
+}
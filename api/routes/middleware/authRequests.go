@@ -1,91 +1,399 @@
-// core/auth/auth0.go
+// middleware/authRequests.go
 package handler
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/config"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/devissuer"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/services"
+
 	"github.com/MicahParks/keyfunc"
 	"github.com/golang-jwt/jwt/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
+// msgMissingToken rejects requests that carry no credentials at all
+const msgMissingToken = "missing authorization token"
+
+// APIKeyResolver verifies API keys and returns the identity they act as
+type APIKeyResolver interface {
+	ResolveAPIKey(ctx context.Context, key string) (*identity.Identity, error)
+}
+
+// AuditRecorder appends entries to the audit log
+type AuditRecorder interface {
+	Record(ctx context.Context, entry database.AuditEntry)
+}
+
+// Authenticator accepts tokens from any of its trusted issuers. TenantClaim and
+// UsernameClaim are the defaults for issuers that do not map their own claims, and
+// a non-empty TenantClaim makes a tenant mandatory for every caller.
 type Authenticator struct {
-	Domain    string
-	Audience  string
-	jwks     *keyfunc.JWKS
+	Domain        string
+	Issuer        string
+	Audience      string
+	TenantClaim   string
+	UsernameClaim string
+	APIKeys       APIKeyResolver
+	Audit         AuditRecorder
+	issuers       map[string]*TrustedIssuer
+	failures      *failureThrottle
 }
 
 func NewAuth0(domain, audience string) (*Authenticator, error) {
 
 	jwksURL := fmt.Sprintf("https://%s/.well-known/jwks.json", domain)
 
+	issuer := fmt.Sprintf("https://%s/", domain)
 	options := keyfunc.Options{
 		RefreshInterval: time.Hour * 24,
-		RefreshTimeout: time.Second * 10,
+		RefreshTimeout:  time.Second * 10,
 	}
 
-	jwks, err := keyfunc.Get(jwksURL, options)
+	refresh := &keyRefresh{}
+	jwks, err := keyfunc.Get(jwksURL, refresh.track(issuer, options))
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize JWKS: %w", err)
 	}
 
+	authenticator := NewWithJWKS(jwks, issuer, audience)
+	authenticator.Domain = domain
+	authenticator.issuers[issuer].refresh = refresh
+	return authenticator, nil
+}
+
+// NewFromJWKSFile validates tokens against a key set read from a local file instead of Auth0
+func NewFromJWKSFile(path, issuer, audience string) (*Authenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+
+	jwks, err := keyfunc.NewJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file: %w", err)
+	}
+
+	return NewWithJWKS(jwks, issuer, audience), nil
+}
+
+// NewWithJWKS validates tokens signed by any key in jwks for the given issuer and audience.
+// As the one issuer configured by AUTH_MODE, its subjects are used as they are and it may
+// grant the admin scope
+func NewWithJWKS(jwks *keyfunc.JWKS, issuer, audience string) *Authenticator {
+	auth := NewMultiIssuer()
+	auth.Issuer = issuer
+	auth.Audience = audience
+	auth.AddIssuer(&TrustedIssuer{
+		Issuer:     issuer,
+		Audiences:  []string{audience},
+		AllowAdmin: true,
+		jwks:       jwks,
+	})
+	return auth
+}
+
+// NewMultiIssuer returns an authenticator that trusts no issuers until they are added
+func NewMultiIssuer() *Authenticator {
 	return &Authenticator{
-		Domain:    domain,
-		Audience:  audience,
-		jwks:      jwks,
-	}, nil
+		issuers:  make(map[string]*TrustedIssuer),
+		failures: newFailureThrottle(auditFailureWindow, maxAuditedFailureIPs),
+	}
+}
+
+// AddIssuer trusts tokens from the issuer, replacing any issuer with the same `iss`
+func (auth *Authenticator) AddIssuer(issuer *TrustedIssuer) {
+	auth.issuers[issuer.Issuer] = issuer
+}
+
+// NewFromConfig picks the token key source configured by AUTH_MODE, adds any extra
+// issuers listed in AUTH_ISSUERS_FILE and applies the default tenant and username claims
+func NewFromConfig(cfg config.Config) (*Authenticator, error) {
+	var authenticator *Authenticator
+	switch cfg.AuthMode {
+	case config.AuthModeAuth0:
+		a, err := NewAuth0(cfg.Auth0Domain, cfg.Auth0Audience)
+		if err != nil {
+			return nil, err
+		}
+		authenticator = a
+	case config.AuthModeJWKSFile:
+		a, err := NewFromJWKSFile(cfg.AuthJWKSFile, cfg.AuthIssuer, cfg.Auth0Audience)
+		if err != nil {
+			return nil, err
+		}
+		authenticator = a
+	case config.AuthModeDev:
+		issuer, err := devissuer.New(cfg.DevSigningKeyFile, cfg.AuthIssuer, cfg.Auth0Audience)
+		if err != nil {
+			return nil, err
+		}
+		slog.Warn("AUTH_MODE=dev: accepting tokens signed by the local development key")
+		authenticator = NewWithJWKS(issuer.JWKS(), issuer.Issuer, issuer.Audience)
+	case config.AuthModeOIDC:
+		if cfg.AuthIssuersFile == "" {
+			return nil, fmt.Errorf("AUTH_MODE=oidc requires AUTH_ISSUERS_FILE")
+		}
+		authenticator = NewMultiIssuer()
+	default:
+		return nil, fmt.Errorf("unknown auth mode: %q", cfg.AuthMode)
+	}
+
+	if cfg.AuthIssuersFile != "" {
+		issuers, err := LoadIssuerConfigs(cfg.AuthIssuersFile)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		for _, issuerCfg := range issuers {
+			issuer, err := DiscoverIssuer(ctx, issuerCfg)
+			if err != nil {
+				return nil, err
+			}
+			authenticator.AddIssuer(issuer)
+		}
+	}
+
+	authenticator.TenantClaim = cfg.TenantClaim
+	authenticator.UsernameClaim = cfg.UsernameClaim
+	return authenticator, nil
+}
+
+// AuthError explains why a caller was rejected; Status is the HTTP status describing it
+type AuthError struct {
+	Status int
+	Msg    string
+}
+
+func (e *AuthError) Error() string {
+	return e.Msg
 }
 
 func (auth *Authenticator) Middleware(next http.HandlerFunc) http.HandlerFunc {
-	issuer := fmt.Sprintf("https://%s/", auth.Domain)
 	return func(w http.ResponseWriter, r *http.Request) {
-		tokenStr := extractToken(r)
-		if tokenStr == "" {
-			http.Error(w, "missing authorization token", http.StatusUnauthorized)
+		ctx, err := auth.Authenticate(r.Context(), r.Header.Get("Authorization"), r.Header.Get("X-API-Key"))
+		if err != nil {
+			auth.reject(w, r.WithContext(ctx), err.Status, err.Msg)
 			return
 		}
+		next(w, r.WithContext(ctx))
+	}
+}
 
-		token, err := jwt.Parse(tokenStr, auth.jwks.Keyfunc, jwt.WithValidMethods([]string{"RS256"}))
-		if err != nil || !token.Valid {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
+// Authenticate resolves the caller from an Authorization value (`Bearer <token>` or
+// `ApiKey <key>`) or an X-API-Key value, and returns ctx scoped to the caller and its
+// tenant. HTTP passes the request headers and gRPC the call's metadata. On failure the
+// returned context still carries any identity that was resolved, for auditing
+func (auth *Authenticator) Authenticate(ctx context.Context, authorization, apiKey string) (context.Context, *AuthError) {
+	caller, status, msg := auth.authenticate(ctx, authorization, apiKey)
+	if caller == nil {
+		return ctx, &AuthError{Status: status, Msg: msg}
+	}
+
+	ctx = identity.WithIdentity(ctx, caller)
+	ctx = logging.Annotate(ctx, "subject", caller.Subject)
+	if caller.APIKeyID != "" {
+		ctx = logging.Annotate(ctx, "api_key_id", caller.APIKeyID)
+	}
+
+	if caller.Tenant == "" && auth.TenantClaim != "" {
+		return ctx, &AuthError{Status: http.StatusForbidden, Msg: "missing tenant claim"}
+	}
+	if caller.Tenant != "" {
+		if err := database.ValidateTenantID(caller.Tenant); err != nil {
+			return ctx, &AuthError{Status: http.StatusForbidden, Msg: "invalid tenant claim"}
 		}
+		ctx = database.WithTenant(ctx, caller.Tenant)
+	}
+	return ctx, nil
+}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			http.Error(w, "invalid token claims", http.StatusUnauthorized)
-			return
+// authenticate resolves the caller from an API key or bearer token, returning the status
+// and message to reply with on failure
+func (auth *Authenticator) authenticate(ctx context.Context, authorization, apiKeyHeader string) (*identity.Identity, int, string) {
+	ctx, span := tracer.Start(ctx, "auth.authenticate")
+	defer span.End()
+
+	var (
+		caller *identity.Identity
+		status int
+		msg    string
+	)
+	if apiKey := extractAPIKey(authorization, apiKeyHeader); apiKey != "" && auth.APIKeys != nil {
+		span.SetAttributes(attribute.String("auth.method", "api_key"))
+		id, err := auth.APIKeys.ResolveAPIKey(ctx, apiKey)
+		if err != nil {
+			caller, status, msg = nil, http.StatusUnauthorized, "invalid api key"
+		} else {
+			caller = id
 		}
+	} else {
+		span.SetAttributes(attribute.String("auth.method", "jwt"))
+		caller, status, msg = auth.verifyToken(extractToken(authorization))
+	}
+
+	if caller == nil {
+		span.SetStatus(codes.Error, msg)
+		return nil, status, msg
+	}
+	span.SetAttributes(attribute.String("enduser.id", caller.Subject))
+	return caller, 0, ""
+}
 
-		if !claims.VerifyAudience(auth.Audience, true) {
-			http.Error(w, "invalid audience", http.StatusUnauthorized)
+// RecordFailure writes a rejected authentication to the audit log; target names what was
+// called, such as the request method and path. Requests without credentials are not
+// recorded, and each IP gets at most one entry per auditFailureWindow, which counts the
+// failures left out since its previous entry
+func (auth *Authenticator) RecordFailure(ctx context.Context, ip, userAgent, target, msg string) {
+	if auth.Audit == nil || msg == msgMissingToken {
+		return
+	}
+	detail := fmt.Sprintf("%s: %s", target, msg)
+	if auth.failures != nil {
+		record, suppressed := auth.failures.allow(ip, time.Now())
+		if !record {
 			return
 		}
+		if suppressed > 0 {
+			detail += fmt.Sprintf(" (and %d earlier failures from this IP)", suppressed)
+		}
+	}
 
+	auth.Audit.Record(ctx, database.AuditEntry{
+		Action:    services.AuditAuthFailure,
+		IP:        ip,
+		UserAgent: userAgent,
+		Result:    services.AuditDenied,
+		Detail:    detail,
+	})
+}
 
-		if !claims.VerifyIssuer(issuer, true) {
-			http.Error(w, "invalid issuer", http.StatusUnauthorized)
-			return
+// reject records the failed authentication in the audit log and replies with status
+func (auth *Authenticator) reject(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	auth.RecordFailure(r.Context(), ClientIP(r), r.UserAgent(), r.Method+" "+r.URL.Path, msg)
+
+	code := problem.CodeForbidden
+	if status == http.StatusUnauthorized {
+		code = problem.CodeUnauthorized
+		w.Header().Set("WWW-Authenticate", `Bearer realm="grammarhive"`)
+	}
+	problem.Respond(w, r, status, code, msg)
+}
+
+// verifyToken validates a bearer JWT, returning the status and message to reply with on failure
+func (auth *Authenticator) verifyToken(tokenStr string) (*identity.Identity, int, string) {
+	if tokenStr == "" {
+		return nil, http.StatusUnauthorized, msgMissingToken
+	}
+
+	// Route the token to its issuer's keys before verifying the signature
+	unverified := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(tokenStr, unverified); err != nil {
+		return nil, http.StatusUnauthorized, "unauthorized"
+	}
+	iss, _ := unverified["iss"].(string)
+	issuer, ok := auth.issuers[iss]
+	if !ok {
+		return nil, http.StatusUnauthorized, "invalid issuer"
+	}
+
+	token, err := jwt.Parse(tokenStr, issuer.jwks.Keyfunc, jwt.WithValidMethods([]string{"RS256"}))
+	if err != nil || !token.Valid {
+		return nil, http.StatusUnauthorized, "unauthorized"
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, http.StatusUnauthorized, "invalid token claims"
+	}
+
+	if !verifyAnyAudience(claims, issuer.Audiences) {
+		return nil, http.StatusUnauthorized, "invalid audience"
+	}
+
+	if !claims.VerifyIssuer(issuer.Issuer, true) {
+		return nil, http.StatusUnauthorized, "invalid issuer"
+	}
+
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, http.StatusUnauthorized, "token expired"
+	}
+
+	usernameClaim := firstNonEmpty(issuer.UsernameClaim, auth.UsernameClaim)
+	tenantClaim := firstNonEmpty(issuer.TenantClaim, auth.TenantClaim)
+
+	id := identity.FromClaims(claims, usernameClaim)
+	if id.Subject == "" {
+		return nil, http.StatusUnauthorized, "invalid token claims"
+	}
+	id.Subject = issuer.SubjectPrefix + id.Subject
+	if !issuer.AllowAdmin {
+		id.Scopes = withoutScope(id.Scopes, identity.ScopeAdmin)
+	}
+	if tenantClaim != "" {
+		id.Tenant, _ = claims[tenantClaim].(string)
+	}
+	return id, 0, ""
+}
+
+func verifyAnyAudience(claims jwt.MapClaims, audiences []string) bool {
+	for _, audience := range audiences {
+		if claims.VerifyAudience(audience, true) {
+			return true
 		}
+	}
+	return false
+}
 
-		if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
-			http.Error(w, "token expired", http.StatusUnauthorized)
-			return
+func withoutScope(scopes []string, scope string) []string {
+	kept := scopes[:0]
+	for _, s := range scopes {
+		if s != scope {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
 		}
-	
-		next(w, r)
 	}
+	return ""
+}
+
+// extractToken reads a bearer token from an Authorization value
+func extractToken(authorization string) string {
+	if len(authorization) > 7 && authorization[:7] == "Bearer " {
+		return authorization[7:]
+	}
+	return ""
 }
 
-func extractToken(r *http.Request) string {
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		return ""
+// extractAPIKey reads a key from an X-API-Key value or an `Authorization: ApiKey ...` value
+func extractAPIKey(authorization, apiKeyHeader string) string {
+	if key := strings.TrimSpace(apiKeyHeader); key != "" {
+		return key
 	}
 
-	if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-		return authHeader[7:]
+	if len(authorization) > 7 && authorization[:7] == "ApiKey " {
+		return strings.TrimSpace(authorization[7:])
 	}
 	return ""
 }
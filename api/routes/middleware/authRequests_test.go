@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"grammarhive-backend/core/devissuer"
+	"grammarhive-backend/core/identity"
+)
+
+// serveDevIssuer starts a JWKS endpoint for a new development issuer and returns an
+// authenticator trusting it, fetched from the endpoint as a remote issuer's keys would be
+func serveDevIssuer(t *testing.T) (*devissuer.Issuer, *Authenticator) {
+	return serveDevIssuerWith(t, IssuerConfig{})
+}
+
+// serveDevIssuerWith is serveDevIssuer with the issuer's configuration based on cfg
+func serveDevIssuerWith(t *testing.T, cfg IssuerConfig) (*devissuer.Issuer, *Authenticator) {
+	t.Helper()
+	issuer, err := devissuer.New(filepath.Join(t.TempDir(), "dev.pem"), "", "grammarhive-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := issuer.JWKSJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/jwks.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jwks)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg.Issuer = issuer.Issuer
+	cfg.Audiences = []string{issuer.Audience}
+	cfg.JWKSURI = server.URL + "/.well-known/jwks.json"
+	cfg.UsernameClaim = "https://grammarhive.local/username"
+	trusted, err := DiscoverIssuer(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := NewMultiIssuer()
+	auth.AddIssuer(trusted)
+	return issuer, auth
+}
+
+func TestDevIssuerTokensVerifyAgainstServedJWKS(t *testing.T) {
+	issuer, auth := serveDevIssuer(t)
+
+	token, err := issuer.Mint(devissuer.TokenOptions{
+		Subject:       "dev|ada",
+		Username:      "ada",
+		UsernameClaim: "https://grammarhive.local/username",
+		Scopes:        []string{identity.ScopeRead, identity.ScopeWrite},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, authErr := auth.Authenticate(context.Background(), "Bearer "+token, "")
+	if authErr != nil {
+		t.Fatalf("minted token was rejected: %d %s", authErr.Status, authErr.Msg)
+	}
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Username != "ada" || caller.Subject != issuer.Issuer+"|dev|ada" {
+		t.Fatalf("caller = %+v, want subject %s|dev|ada and username ada", caller, issuer.Issuer)
+	}
+	if !caller.HasScope(identity.ScopeRead) || !caller.HasScope(identity.ScopeWrite) || caller.HasScope(identity.ScopeGenerate) {
+		t.Fatalf("scopes = %v, want exactly the minted scopes", caller.Scopes)
+	}
+}
+
+func TestDevIssuerTokensAreRejectedWhenInvalid(t *testing.T) {
+	issuer, auth := serveDevIssuer(t)
+
+	// Signed with a different key under the same issuer and key ID
+	other, err := devissuer.New(filepath.Join(t.TempDir(), "other.pem"), issuer.Issuer, issuer.Audience)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.KeyID = issuer.KeyID
+	forged, err := other.Mint(devissuer.TokenOptions{Subject: "dev|ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrongAudience := *issuer
+	wrongAudience.Audience = "another-api"
+	misdirected, err := wrongAudience.Mint(devissuer.TokenOptions{Subject: "dev|ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unknownIssuer := *issuer
+	unknownIssuer.Issuer = "https://elsewhere.example/"
+	foreign, err := unknownIssuer.Mint(devissuer.TokenOptions{Subject: "dev|ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, authorization, msg string
+	}{
+		{"missing", "", "missing authorization token"},
+		{"forged", "Bearer " + forged, "unauthorized"},
+		{"wrong audience", "Bearer " + misdirected, "invalid audience"},
+		{"unknown issuer", "Bearer " + foreign, "invalid issuer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, authErr := auth.Authenticate(context.Background(), tt.authorization, "")
+			if authErr == nil {
+				t.Fatal("token was accepted")
+			}
+			if authErr.Status != http.StatusUnauthorized || authErr.Msg != tt.msg {
+				t.Fatalf("got %d %q, want 401 %q", authErr.Status, authErr.Msg, tt.msg)
+			}
+		})
+	}
+}
+
+func TestDevIssuerTokensExpire(t *testing.T) {
+	issuer, auth := serveDevIssuer(t)
+
+	token, err := issuer.Mint(devissuer.TokenOptions{Subject: "dev|ada", TTL: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, authErr := auth.Authenticate(context.Background(), "Bearer "+token, ""); authErr != nil {
+		t.Fatalf("fresh token was rejected: %s", authErr.Msg)
+	}
+
+	time.Sleep(2 * time.Second)
+	if _, authErr := auth.Authenticate(context.Background(), "Bearer "+token, ""); authErr == nil {
+		t.Fatal("expired token was accepted")
+	}
+}
+
+func TestDevIssuerTokensVerifyAgainstPrintedJWKS(t *testing.T) {
+	issuer, err := devissuer.New(filepath.Join(t.TempDir(), "dev.pem"), "", "grammarhive-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// What `auth jwks` prints, saved for AUTH_MODE=jwks-file
+	jwks, err := issuer.JWKSJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := NewFromJWKSFile(jwksFile, issuer.Issuer, issuer.Audience)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := issuer.Mint(devissuer.TokenOptions{Subject: "dev|ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, authErr := auth.Authenticate(context.Background(), "Bearer "+token, ""); authErr != nil {
+		t.Fatalf("minted token was rejected: %d %s", authErr.Status, authErr.Msg)
+	}
+}
@@ -0,0 +1,20 @@
+// middleware/chain.go
+package handler
+
+import (
+	"net/http"
+)
+
+// Middleware wraps a handler with behaviour that runs around it
+type Middleware func(http.Handler) http.Handler
+
+// Chain composes middlewares into one; the first one listed is the outermost and sees
+// the request first
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
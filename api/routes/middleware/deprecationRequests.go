@@ -0,0 +1,24 @@
+// middleware/deprecationRequests.go
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Deprecated marks every response of a route as deprecated since `since` (RFC 9745) and
+// links to the route that replaces it, so clients can find the successor before the
+// route is retired
+func Deprecated(since time.Time, successor string) Middleware {
+	deprecation := fmt.Sprintf("@%d", since.Unix())
+	link := fmt.Sprintf("<%s>; rel=\"successor-version\"", successor)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			w.Header().Add("Link", link)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -2,18 +2,186 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"grammarhive-backend/api/routes/problem"
 )
 
-// SetCORSHeaders sets the CORS headers for the HTTP response.
-func SetCORSHeaders(w http.ResponseWriter) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+// CORSPolicy describes which cross-origin callers may use the API and how. An allowed
+// origin is an exact origin such as https://app.example.com, a wildcard subdomain such
+// as https://*.example.com, or "*" for any origin
+type CORSPolicy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// originPattern is a parsed allowed origin; host has its leading "*." removed for wildcards
+type originPattern struct {
+	any      bool
+	scheme   string
+	host     string
+	wildcard bool
 }
 
-// HandleOptions handles OPTIONS request method
-func HandleOptions(w http.ResponseWriter, r *http.Request) {
-	SetCORSHeaders(w)
-	w.WriteHeader(http.StatusOK)
+// CORS applies the policy to every response: allowed origins get the CORS headers,
+// preflights are answered directly and disallowed preflights are rejected with 403.
+// Credentials can only be allowed for listed origins, never for "*"
+func CORS(policy CORSPolicy) (Middleware, error) {
+	patterns := make([]originPattern, 0, len(policy.AllowedOrigins))
+	for _, origin := range policy.AllowedOrigins {
+		pattern, err := parseOriginPattern(origin)
+		if err != nil {
+			return nil, err
+		}
+		// Echoing every origin with credentials would let any site act as its visitors
+		if pattern.any && policy.AllowCredentials {
+			return nil, fmt.Errorf("CORS origin %q cannot be combined with allowing credentials: list the origins instead", origin)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	allowedMethods := make(map[string]bool, len(policy.AllowedMethods))
+	for _, method := range policy.AllowedMethods {
+		allowedMethods[strings.ToUpper(method)] = true
+	}
+	allowedHeaders := make(map[string]bool, len(policy.AllowedHeaders))
+	for _, header := range policy.AllowedHeaders {
+		allowedHeaders[http.CanonicalHeaderKey(header)] = true
+	}
+
+	methods := strings.Join(policy.AllowedMethods, ", ")
+	headers := strings.Join(policy.AllowedHeaders, ", ")
+	exposed := strings.Join(policy.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(policy.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			w.Header().Add("Vary", "Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			anyOrigin, allowed := matchOrigin(patterns, origin)
+			if !allowed {
+				if preflight {
+					problem.Respond(w, r, http.StatusForbidden, problem.CodeForbidden, "origin "+origin+" is not allowed")
+					return
+				}
+				// Without CORS headers the browser withholds the response from the page
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if policy.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposed != "" {
+					w.Header().Set("Access-Control-Expose-Headers", exposed)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+			requestedMethod := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+			if !allowedMethods[requestedMethod] {
+				problem.Respond(w, r, http.StatusForbidden, problem.CodeForbidden, "method "+requestedMethod+" is not allowed")
+				return
+			}
+			for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+				header = strings.TrimSpace(header)
+				if header != "" && !allowedHeaders[http.CanonicalHeaderKey(header)] {
+					problem.Respond(w, r, http.StatusForbidden, problem.CodeForbidden, "header "+header+" is not allowed")
+					return
+				}
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+			if policy.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}, nil
+}
+
+// OriginChecker reports whether a browser origin is allowed by the same patterns CORS takes.
+// WebSocket handshakes are not subject to CORS, so their handlers must check the origin themselves
+func OriginChecker(origins []string) (func(origin string) bool, error) {
+	patterns := make([]originPattern, 0, len(origins))
+	for _, origin := range origins {
+		pattern, err := parseOriginPattern(origin)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	return func(origin string) bool {
+		_, allowed := matchOrigin(patterns, origin)
+		return allowed
+	}, nil
+}
+
+func parseOriginPattern(origin string) (originPattern, error) {
+	if origin == "*" {
+		return originPattern{any: true}, nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+		return originPattern{}, fmt.Errorf("invalid CORS origin %q: want scheme://host[:port]", origin)
+	}
+
+	pattern := originPattern{scheme: strings.ToLower(u.Scheme), host: strings.ToLower(u.Host)}
+	if strings.HasPrefix(pattern.host, "*.") {
+		pattern.wildcard = true
+		pattern.host = pattern.host[1:] // keep the dot so only subdomains match
+	}
+	return pattern, nil
+}
+
+// matchOrigin reports whether origin is allowed, and whether it was allowed by "*"
+func matchOrigin(patterns []originPattern, origin string) (anyOrigin, allowed bool) {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false, false
+	}
+	scheme, host := strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+
+	for _, pattern := range patterns {
+		switch {
+		case pattern.any:
+			return true, true
+		case pattern.scheme != scheme:
+		case pattern.wildcard && strings.HasSuffix(host, pattern.host):
+			return false, true
+		case !pattern.wildcard && pattern.host == host:
+			return false, true
+		}
+	}
+	return false, false
 }
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSRejectsAnyOriginWithCredentials(t *testing.T) {
+	_, err := CORS(CORSPolicy{AllowedOrigins: []string{"https://app.example.com", "*"}, AllowCredentials: true})
+	if err == nil {
+		t.Fatal(`"*" was accepted together with credentials`)
+	}
+
+	if _, err := CORS(CORSPolicy{AllowedOrigins: []string{"*"}}); err != nil {
+		t.Fatalf(`"*" without credentials: %v`, err)
+	}
+	if _, err := CORS(CORSPolicy{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true}); err != nil {
+		t.Fatalf("wildcard subdomain with credentials: %v", err)
+	}
+}
+
+func TestCORSHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      CORSPolicy
+		origin      string
+		allowOrigin string
+		credentials string
+	}{
+		{"any origin", CORSPolicy{AllowedOrigins: []string{"*"}}, "https://site.test", "*", ""},
+		{"listed origin with credentials", CORSPolicy{AllowedOrigins: []string{"https://app.test"}, AllowCredentials: true}, "https://app.test", "https://app.test", "true"},
+		{"wildcard subdomain", CORSPolicy{AllowedOrigins: []string{"https://*.app.test"}}, "https://eu.app.test", "https://eu.app.test", ""},
+		{"unlisted origin", CORSPolicy{AllowedOrigins: []string{"https://app.test"}, AllowCredentials: true}, "https://evil.test", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cors, err := CORS(tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+			req.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			cors(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(w, req)
+
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.allowOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.credentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.credentials)
+			}
+		})
+	}
+}
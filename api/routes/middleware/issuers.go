@@ -0,0 +1,230 @@
+// middleware/issuers.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MicahParks/keyfunc"
+)
+
+// IssuerConfig describes a trusted token issuer and how to read its claims. SubjectPrefix
+// defaults to the issuer followed by "|", and AllowAdmin lets the issuer grant the admin scope
+type IssuerConfig struct {
+	Issuer          string   `json:"issuer"`
+	Audiences       []string `json:"audiences"`
+	JWKSURI         string   `json:"jwksUri,omitempty"`
+	UsernameClaim   string   `json:"usernameClaim,omitempty"`
+	TenantClaim     string   `json:"tenantClaim,omitempty"`
+	RefreshInterval string   `json:"refreshInterval,omitempty"`
+	SubjectPrefix   string   `json:"subjectPrefix,omitempty"`
+	AllowAdmin      bool     `json:"allowAdmin,omitempty"`
+}
+
+// TrustedIssuer is an issuer whose signing keys have been resolved. SubjectPrefix is put
+// before the `sub` of its tokens, so that two issuers cannot name the same account, and
+// the admin scope is dropped from its tokens unless AllowAdmin is set
+type TrustedIssuer struct {
+	Issuer        string
+	Audiences     []string
+	UsernameClaim string
+	TenantClaim   string
+	SubjectPrefix string
+	AllowAdmin    bool
+	jwks          *keyfunc.JWKS
+	refresh       *keyRefresh
+}
+
+// KeyStatus reports how fresh an issuer's signing keys are. Keys loaded from a file or
+// generated locally are never refreshed and are always fresh
+type KeyStatus struct {
+	Issuer      string     `json:"issuer"`
+	Remote      bool       `json:"remote"`
+	RefreshedAt *time.Time `json:"refreshedAt,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	Stale       bool       `json:"stale"`
+}
+
+// Status reports the freshness of the issuer's keys; they go stale once two refresh
+// intervals pass without a successful fetch
+func (t *TrustedIssuer) Status() KeyStatus {
+	status := KeyStatus{Issuer: t.Issuer}
+	if t.refresh == nil {
+		return status
+	}
+
+	t.refresh.mu.Lock()
+	defer t.refresh.mu.Unlock()
+	refreshedAt := t.refresh.refreshedAt
+	status.Remote = true
+	status.RefreshedAt = &refreshedAt
+	status.Stale = time.Since(refreshedAt) > 2*t.refresh.interval
+	if t.refresh.lastErr != nil {
+		status.LastError = t.refresh.lastErr.Error()
+	}
+	return status
+}
+
+// KeyStatus reports the key freshness of every trusted issuer, ordered by issuer
+func (auth *Authenticator) KeyStatus() []KeyStatus {
+	statuses := make([]KeyStatus, 0, len(auth.issuers))
+	for _, issuer := range auth.issuers {
+		statuses = append(statuses, issuer.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Issuer < statuses[j].Issuer })
+	return statuses
+}
+
+// keyRefresh records when a remote JWKS was last fetched and why its last refresh failed
+type keyRefresh struct {
+	mu          sync.Mutex
+	interval    time.Duration
+	refreshedAt time.Time
+	lastErr     error
+}
+
+// track hooks the refresh bookkeeping into opts; issuer labels logged refresh errors
+func (k *keyRefresh) track(issuer string, opts keyfunc.Options) keyfunc.Options {
+	k.interval = opts.RefreshInterval
+	opts.ResponseExtractor = func(ctx context.Context, resp *http.Response) (json.RawMessage, error) {
+		raw, err := keyfunc.ResponseExtractorStatusOK(ctx, resp)
+		if err == nil {
+			k.mu.Lock()
+			k.refreshedAt = time.Now()
+			k.lastErr = nil
+			k.mu.Unlock()
+		}
+		return raw, err
+	}
+	opts.RefreshErrorHandler = func(err error) {
+		k.mu.Lock()
+		k.lastErr = err
+		k.mu.Unlock()
+		slog.Warn("failed to refresh JWKS", "issuer", issuer, "error", err)
+	}
+	return opts
+}
+
+// LoadIssuerConfigs reads a JSON array of issuer configurations, giving every issuer a
+// subject prefix of its own
+func LoadIssuerConfigs(path string) ([]IssuerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read issuers file: %w", err)
+	}
+
+	var configs []IssuerConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse issuers file: %w", err)
+	}
+	for i, cfg := range configs {
+		if cfg.Issuer == "" || len(cfg.Audiences) == 0 {
+			return nil, fmt.Errorf("issuer entries require an issuer and at least one audience")
+		}
+		configs[i].SubjectPrefix = cfg.subjectPrefix()
+	}
+	// A prefix that starts another would let its issuer mint the other issuer's subjects
+	for _, a := range configs {
+		for _, b := range configs {
+			if a.Issuer != b.Issuer && strings.HasPrefix(b.SubjectPrefix, a.SubjectPrefix) {
+				return nil, fmt.Errorf("subject prefix of %s overlaps that of %s", a.Issuer, b.Issuer)
+			}
+		}
+	}
+	return configs, nil
+}
+
+func (cfg IssuerConfig) subjectPrefix() string {
+	if cfg.SubjectPrefix == "" {
+		return cfg.Issuer + "|"
+	}
+	return cfg.SubjectPrefix
+}
+
+// DiscoverIssuer resolves the issuer's JWKS through OpenID Connect discovery, unless
+// jwksUri is configured, and keeps its keys refreshed in the background
+func DiscoverIssuer(ctx context.Context, cfg IssuerConfig) (*TrustedIssuer, error) {
+	jwksURI := cfg.JWKSURI
+	if jwksURI == "" {
+		discovered, err := discoverJWKSURI(ctx, cfg.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		jwksURI = discovered
+	}
+
+	refreshInterval := time.Hour
+	if cfg.RefreshInterval != "" {
+		interval, err := time.ParseDuration(cfg.RefreshInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid refresh interval for %s: %w", cfg.Issuer, err)
+		}
+		refreshInterval = interval
+	}
+
+	refresh := &keyRefresh{}
+	jwks, err := keyfunc.Get(jwksURI, refresh.track(cfg.Issuer, keyfunc.Options{
+		RefreshInterval:   refreshInterval,
+		RefreshTimeout:    time.Second * 10,
+		RefreshRateLimit:  time.Minute * 5,
+		RefreshUnknownKID: true,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize JWKS for %s: %w", cfg.Issuer, err)
+	}
+
+	return &TrustedIssuer{
+		Issuer:        cfg.Issuer,
+		Audiences:     cfg.Audiences,
+		UsernameClaim: cfg.UsernameClaim,
+		TenantClaim:   cfg.TenantClaim,
+		SubjectPrefix: cfg.subjectPrefix(),
+		AllowAdmin:    cfg.AllowAdmin,
+		jwks:          jwks,
+		refresh:       refresh,
+	}, nil
+}
+
+func discoverJWKSURI(ctx context.Context, issuer string) (string, error) {
+	discoveryURL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return "", err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch discovery document for %s: %w", issuer, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("discovery for %s returned status %d", issuer, resp.StatusCode)
+	}
+
+	var doc struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return "", fmt.Errorf("failed to parse discovery document for %s: %w", issuer, err)
+	}
+
+	// OpenID Connect Discovery 1.0 section 4.3: the document must name the issuer it was fetched for
+	if doc.Issuer != issuer {
+		return "", fmt.Errorf("discovery document issuer %q does not match %q", doc.Issuer, issuer)
+	}
+	if doc.JWKSURI == "" {
+		return "", fmt.Errorf("discovery document for %s has no jwks_uri", issuer)
+	}
+	return doc.JWKSURI, nil
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"grammarhive-backend/core/devissuer"
+	"grammarhive-backend/core/identity"
+)
+
+func TestIssuerSubjectsArePrefixed(t *testing.T) {
+	issuer, auth := serveDevIssuerWith(t, IssuerConfig{SubjectPrefix: "keycloak:"})
+
+	token, err := issuer.Mint(devissuer.TokenOptions{Subject: "ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, authErr := auth.Authenticate(context.Background(), "Bearer "+token, "")
+	if authErr != nil {
+		t.Fatal(authErr.Msg)
+	}
+	if subject := identity.FromContext(ctx).Subject; subject != "keycloak:ada" {
+		t.Fatalf("subject = %q, want keycloak:ada", subject)
+	}
+}
+
+func TestOnlyAllowedIssuersGrantAdmin(t *testing.T) {
+	for _, allowAdmin := range []bool{false, true} {
+		issuer, auth := serveDevIssuerWith(t, IssuerConfig{AllowAdmin: allowAdmin})
+
+		token, err := issuer.Mint(devissuer.TokenOptions{
+			Subject: "ada",
+			Scopes:  []string{identity.ScopeAdmin, identity.ScopeRead},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, authErr := auth.Authenticate(context.Background(), "Bearer "+token, "")
+		if authErr != nil {
+			t.Fatal(authErr.Msg)
+		}
+
+		caller := identity.FromContext(ctx)
+		if caller.HasScope(identity.ScopeAdmin) != allowAdmin || !caller.HasScope(identity.ScopeRead) {
+			t.Errorf("allowAdmin %v: scopes = %v", allowAdmin, caller.Scopes)
+		}
+	}
+}
+
+func TestLoadIssuerConfigs(t *testing.T) {
+	load := func(t *testing.T, contents string) ([]IssuerConfig, error) {
+		path := filepath.Join(t.TempDir(), "issuers.json")
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return LoadIssuerConfigs(path)
+	}
+
+	t.Run("default prefixes", func(t *testing.T) {
+		configs, err := load(t, `[
+			{"issuer": "https://a.example/", "audiences": ["api"]},
+			{"issuer": "https://b.example/", "audiences": ["api"], "subjectPrefix": "b:"}
+		]`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if configs[0].SubjectPrefix != "https://a.example/|" || configs[1].SubjectPrefix != "b:" {
+			t.Fatalf("prefixes = %q, %q", configs[0].SubjectPrefix, configs[1].SubjectPrefix)
+		}
+	})
+
+	t.Run("overlapping prefixes", func(t *testing.T) {
+		_, err := load(t, `[
+			{"issuer": "https://a.example/", "audiences": ["api"], "subjectPrefix": "a"},
+			{"issuer": "https://b.example/", "audiences": ["api"], "subjectPrefix": "ab"}
+		]`)
+		if err == nil || !strings.Contains(err.Error(), "overlaps") {
+			t.Fatalf("err = %v, want an overlapping prefix error", err)
+		}
+	})
+
+	t.Run("missing audience", func(t *testing.T) {
+		if _, err := load(t, `[{"issuer": "https://a.example/"}]`); err == nil {
+			t.Fatal("loaded an issuer without audiences")
+		}
+	})
+}
@@ -0,0 +1,142 @@
+// middleware/logRequests.go
+package handler
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+
+	"grammarhive-backend/core/logging"
+
+	"github.com/gorilla/mux"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// statusRecorder captures the status code and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += n
+	return n, err
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection to a WebSocket handler, recording the switch of protocols
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(s.ResponseWriter).Hijack()
+	if err == nil && s.status == 0 {
+		s.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// RequestID assigns or propagates X-Request-ID and puts a logger carrying it in the context
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		ctx := logging.WithRequestID(r.Context(), requestID)
+		ctx = logging.WithLogger(ctx, slog.Default().With("request_id", requestID))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestLogging writes one structured access log line per request, including any fields
+// handlers annotate the context with; register it inside RequestID
+func RequestLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		fields := &logging.Fields{}
+		logger := logging.FromContext(r.Context())
+		ctx := logging.WithFields(r.Context(), fields)
+
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case rec.status >= 500:
+			level = slog.LevelError
+		case rec.status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := append([]any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"proto", r.Proto,
+			"status", rec.status,
+			"bytes", rec.bytes,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"ip", ClientIP(r),
+			"user_agent", r.UserAgent(),
+		}, fields.Attrs()...)
+		logger.Log(ctx, level, "request", attrs...)
+	})
+}
+
+// AnnotateRoute records the matched route template in the access log and names the
+// request's span after it; register it with router.Use
+func AnnotateRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				r = r.WithContext(logging.Annotate(r.Context(), "route", template))
+				span := trace.SpanFromContext(r.Context())
+				span.SetName(r.Method + " " + template)
+				span.SetAttributes(semconv.HTTPRoute(template))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,59 @@
+// middleware/metricsRequests.go
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/metrics"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// RecordMetrics counts requests and observes their latency by route template; register it with router.Use
+func RecordMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		metrics.HTTPRequests.WithLabelValues(route, r.Method, strconv.Itoa(rec.status)).Inc()
+		metrics.HTTPDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// MetricsHandler serves the metrics registry in the Prometheus text format. It replies
+// 404 when disabled and, when token is set, requires it as a bearer token
+func MetricsHandler(enabled bool, token string) http.HandlerFunc {
+	exporter := promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !enabled {
+			problem.Respond(w, r, http.StatusNotFound, problem.CodeNotFound, "no route matches "+r.URL.Path)
+			return
+		}
+		if token != "" {
+			expected := "Bearer " + token
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				problem.Respond(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "a valid metrics token is required")
+				return
+			}
+		}
+		exporter.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,102 @@
+// middleware/proxyRequests.go
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type clientIPKey struct{}
+
+// TrustedProxies lists the proxies whose X-Forwarded-For hops are believed
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies parses TRUSTED_PROXIES entries, each a CIDR prefix or a single address
+func ParseTrustedProxies(entries []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(entries))
+	for _, entry := range entries {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: expected an address or CIDR prefix", entry)
+		}
+		proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return proxies, nil
+}
+
+// trusts reports whether addr, an address without a port, is one of the proxies
+func (t TrustedProxies) trusts(addr string) bool {
+	ip, err := netip.ParseAddr(strings.TrimSpace(addr))
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range t {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientAddress returns the client's address given the peer address of the connection and
+// the X-Forwarded-For values. Each proxy appends the address it was called from, so hops are
+// read from the right and the first one no trusted proxy could have added is the client;
+// anything left of it may have been written by the client itself
+func (t TrustedProxies) ClientAddress(remoteAddr string, forwardedFor []string) string {
+	client := hostOnly(remoteAddr)
+	if !t.trusts(client) {
+		return client
+	}
+
+	var hops []string
+	for _, value := range forwardedFor {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		client = hop
+		if !t.trusts(hop) {
+			break
+		}
+	}
+	return client
+}
+
+// RealIP resolves each request's client address with the trusted proxies for ClientIP
+func RealIP(proxies TrustedProxies) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := proxies.ClientAddress(r.RemoteAddr, r.Header.Values("X-Forwarded-For"))
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip)))
+		})
+	}
+}
+
+// ClientIP returns the caller's address as resolved by RealIP, or the peer address of the
+// connection for requests that did not pass through it
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return hostOnly(r.RemoteAddr)
+}
+
+func hostOnly(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
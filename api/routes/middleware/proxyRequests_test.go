@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"grammarhive-backend/core/ratelimit"
+)
+
+func TestClientAddress(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.7", "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		want         string
+	}{
+		{"direct", "203.0.113.9:4000", nil, "203.0.113.9"},
+		{"untrusted peer's header ignored", "203.0.113.9:4000", []string{"198.51.100.1"}, "203.0.113.9"},
+		{"one proxy", "10.1.2.3:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed leftmost hop", "10.1.2.3:4000", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"proxy chain", "10.1.2.3:4000", []string{"1.2.3.4, 198.51.100.1, 192.0.2.7, 10.9.9.9"}, "198.51.100.1"},
+		{"repeated headers", "10.1.2.3:4000", []string{"1.2.3.4", "198.51.100.1, 10.9.9.9"}, "198.51.100.1"},
+		{"only proxies", "10.1.2.3:4000", []string{"10.4.4.4, 192.0.2.7"}, "10.4.4.4"},
+		{"no header from proxy", "10.1.2.3:4000", nil, "10.1.2.3"},
+		{"ipv6 proxy", "[2001:db8::1]:4000", []string{"2001:db8::2, 198.51.100.1, 2001:db8::3"}, "198.51.100.1"},
+		{"ipv4-mapped proxy", "[::ffff:10.1.2.3]:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := proxies.ClientAddress(tt.remoteAddr, tt.forwardedFor); got != tt.want {
+				t.Fatalf("ClientAddress(%q, %q) = %q, want %q", tt.remoteAddr, tt.forwardedFor, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxiesRejectsInvalidEntries(t *testing.T) {
+	for _, entry := range []string{"10.0.0.0/33", "proxy.internal", "10.0.0"} {
+		if _, err := ParseTrustedProxies([]string{entry}); err == nil {
+			t.Errorf("%q was accepted", entry)
+		}
+	}
+}
+
+func TestRealIPWithoutTrustedProxiesIgnoresForwardedFor(t *testing.T) {
+	var got string
+	handler := RealIP(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = ClientIP(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.9:4000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got != "203.0.113.9" {
+		t.Fatalf("ClientIP = %q, want the peer address", got)
+	}
+}
+
+func TestRateLimitByIPIgnoresCredentials(t *testing.T) {
+	limits, err := ratelimit.ParseLimits(PreAuthRoute + "=2/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), limits)
+	handler := RateLimitByIP(limiter, PreAuthRoute, func(w http.ResponseWriter, r *http.Request) {})
+
+	status := func(remoteAddr, authorization string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Authorization", authorization)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Code
+	}
+
+	for i, authorization := range []string{"Bearer a", "Bearer b"} {
+		if code := status("203.0.113.9:4000", authorization); code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i+1, code)
+		}
+	}
+	if code := status("203.0.113.9:4000", "Bearer c"); code != http.StatusTooManyRequests {
+		t.Fatalf("third request: status %d, want 429", code)
+	}
+	if code := status("198.51.100.1:4000", "Bearer c"); code != http.StatusOK {
+		t.Fatalf("other client: status %d", code)
+	}
+}
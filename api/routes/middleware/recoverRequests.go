@@ -0,0 +1,43 @@
+// middleware/recoverRequests.go
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/logging"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Recover turns a panic in a handler into a logged stack trace and a 500 problem
+// response, instead of a dropped connection
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			// net/http uses ErrAbortHandler to abort a response on purpose
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			logging.FromContext(r.Context()).Error("panic serving request",
+				"panic", fmt.Sprint(v),
+				"stack", string(debug.Stack()),
+			)
+			trace.SpanFromContext(r.Context()).SetStatus(codes.Error, fmt.Sprint("panic: ", v))
+			if rec.status == 0 {
+				problem.Respond(rec, r, http.StatusInternalServerError, problem.CodeInternal, "internal server error")
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
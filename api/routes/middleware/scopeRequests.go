@@ -0,0 +1,28 @@
+// middleware/scopeRequests.go
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/identity"
+)
+
+// RequireScopes rejects requests whose identity lacks any of the scopes with 403
+func RequireScopes(scopes []string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		caller := identity.FromContext(r.Context())
+		if caller == nil {
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "authentication required")
+			return
+		}
+
+		if scope, missing := caller.MissingScope(scopes); missing {
+			problem.Respond(w, r, http.StatusForbidden, problem.CodeInsufficientScope, fmt.Sprintf("insufficient scope: missing %s", scope))
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,27 @@
+// middleware/userRequests.go
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/logging"
+)
+
+// UserTracker records that an authenticated identity made a request
+type UserTracker interface {
+	Touch(ctx context.Context, caller *identity.Identity) error
+}
+
+// TrackUsers upserts the caller's user record; failures are logged and never block the request
+func TrackUsers(tracker UserTracker, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if caller := identity.FromContext(r.Context()); caller != nil {
+			if err := tracker.Touch(r.Context(), caller); err != nil {
+				logging.FromContext(r.Context()).Warn("failed to record user", "subject", caller.Subject, "error", err)
+			}
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,215 @@
+// api/routes/openapi/openapi.go
+package openapi
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/logging"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// document is the API description every route is validated against; keep it in step with
+// the routes registered in api/index.go
+//
+//go:embed openapi.yaml
+var document []byte
+
+// Spec is the loaded API description
+type Spec struct {
+	doc    *openapi3.T
+	router routers.Router
+	json   []byte
+}
+
+// Load parses and validates the embedded document
+func Load(ctx context.Context) (*Spec, error) {
+	loader := openapi3.NewLoader()
+	loader.Context = ctx
+
+	doc, err := loader.LoadFromData(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	if err := doc.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to route OpenAPI document: %w", err)
+	}
+
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+
+	return &Spec{doc: doc, router: router, json: encoded}, nil
+}
+
+// HandleDocument serves the document as JSON
+func (s *Spec) HandleDocument(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Write(s.json)
+}
+
+// Validate rejects requests whose parameters or body do not match the document with a
+// 400 problem, before authentication runs. Requests for paths the document does not
+// describe are passed through for the router to answer.
+//
+// With validateResponses set, responses are buffered and checked too, and mismatches are
+// logged; this is meant for tests and development, not production traffic
+func (s *Spec) Validate(validateResponses bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := s.router.FindRoute(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options: &openapi3filter.Options{
+					AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+					// Uploads are streamed to the handler, which sniffs the file's type itself
+					ExcludeRequestBody: isMultipart(r),
+				},
+			}
+			if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				problem.Write(w, r, requestProblem(r, err))
+				return
+			}
+
+			// Upgraded connections have no response to check, and need the connection itself
+			if !validateResponses || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			s.checkResponse(r, input, recorder)
+			recorder.flush()
+		})
+	}
+}
+
+// checkResponse logs how the recorded response departs from the document
+func (s *Spec) checkResponse(r *http.Request, input *openapi3filter.RequestValidationInput, recorder *responseRecorder) {
+	mediaType, _, _ := mime.ParseMediaType(recorder.Header().Get("Content-Type"))
+	err := openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 recorder.status,
+		Header:                 recorder.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(recorder.body.Bytes())),
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+			// Bodies without a decoder, such as JSON lines, can only have their status checked
+			ExcludeResponseBody: openapi3filter.RegisteredBodyDecoder(mediaType) == nil,
+		},
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("response does not match the OpenAPI document",
+			"operation", input.Route.Operation.OperationID,
+			"status", recorder.status,
+			"error", err,
+		)
+	}
+}
+
+// requestProblem describes a validation failure in terms of the offending parameter or body
+func requestProblem(r *http.Request, err error) *problem.Problem {
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		if p := problem.FromError(err); p != nil {
+			return p
+		}
+		return problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+	}
+
+	if p := problem.FromError(requestErr.Err); p != nil {
+		return p
+	}
+
+	if body := requestErr.RequestBody; body != nil && r.ContentLength != 0 {
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); body.GetMediaType(mediaType) == nil {
+			accepted := make([]string, 0, len(body.Content))
+			for mediaType := range body.Content {
+				accepted = append(accepted, mediaType)
+			}
+			sort.Strings(accepted)
+			return problem.New(http.StatusUnsupportedMediaType, problem.CodeUnsupportedMediaType,
+				fmt.Sprintf("request body must be %s", strings.Join(accepted, " or ")))
+		}
+	}
+
+	reason := requestErr.Reason
+	var schemaErr *openapi3.SchemaError
+	switch {
+	case errors.As(requestErr.Err, &schemaErr):
+		reason = schemaErr.Reason
+		if path := schemaErr.JSONPointer(); len(path) > 0 && requestErr.RequestBody != nil {
+			reason = strings.Join(path, ".") + ": " + reason
+		}
+	case requestErr.Err != nil && (reason == "" || errors.Is(requestErr.Err, openapi3filter.ErrInvalidRequired)):
+		reason = requestErr.Err.Error()
+	}
+
+	detail := reason
+	switch {
+	case requestErr.Parameter != nil:
+		detail = fmt.Sprintf("%s parameter %s: %s", requestErr.Parameter.In, requestErr.Parameter.Name, reason)
+	case requestErr.RequestBody != nil:
+		detail = "request body: " + reason
+	}
+	return problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, detail)
+}
+
+func isMultipart(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "multipart/form-data"
+}
+
+// responseRecorder holds the response back until it has been validated
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.body.Write(b)
+}
+
+func (rec *responseRecorder) flush() {
+	rec.ResponseWriter.WriteHeader(rec.status)
+	rec.ResponseWriter.Write(rec.body.Bytes())
+}
@@ -0,0 +1,96 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"grammarhive-backend/core/logging"
+
+	"github.com/gorilla/mux"
+)
+
+// validatingRouter serves handler as /api/health/live behind Validate with response
+// validation on, and returns the router with the log its mismatches are written to
+func validatingRouter(t *testing.T, handler http.HandlerFunc) (http.Handler, *bytes.Buffer) {
+	t.Helper()
+	spec, err := Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, nil))
+	router := mux.NewRouter()
+	router.Use(
+		func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(logging.WithLogger(r.Context(), logger)))
+			})
+		},
+		spec.Validate(true),
+	)
+	router.HandleFunc("/api/health/live", handler).Methods("GET")
+	router.HandleFunc("/api/v2/generate", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler ran for a request that does not match the document")
+	}).Methods("POST")
+	return router, logs
+}
+
+func TestValidateResponses(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		mismatch bool
+	}{
+		{"conforming", http.StatusOK, `{"status":"ok","build":{"version":"dev","goVersion":"go1.21"}}`, false},
+		{"missing property", http.StatusOK, `{"status":"ok"}`, true},
+		{"wrong enum value", http.StatusOK, `{"status":"up","build":{"version":"dev","goVersion":"go1.21"}}`, true},
+		{"undocumented status", http.StatusTeapot, `{"status":"ok","build":{"version":"dev","goVersion":"go1.21"}}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, logs := validatingRouter(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/health/live", nil))
+
+			// The response is passed on as written either way
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Fatalf("got %d %s, want %d %s", w.Code, w.Body.String(), tt.status, tt.body)
+			}
+			logged := strings.Contains(logs.String(), "response does not match the OpenAPI document")
+			if logged != tt.mismatch {
+				t.Fatalf("mismatch logged = %v, want %v: %s", logged, tt.mismatch, logs.String())
+			}
+			if tt.mismatch && !strings.Contains(logs.String(), `"operation":"healthLive"`) {
+				t.Fatalf("mismatch does not name the operation: %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestValidateRejectsRequestsBeforeTheHandler(t *testing.T) {
+	router, _ := validatingRouter(t, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/generate", strings.NewReader(`{"count":"three"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("Content-Type = %q, want application/problem+json", ct)
+	}
+}
@@ -0,0 +1,179 @@
+// api/routes/playground/handler.go
+package playground
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/config"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/ratelimit"
+	"grammarhive-backend/core/services"
+
+	"github.com/gorilla/websocket"
+)
+
+// Protocol is the WebSocket subprotocol the playground speaks; clients should offer it
+const Protocol = "grammarhive.playground.v1"
+
+// Browsers cannot set headers on WebSocket handshakes, so they may offer their credentials
+// as a subprotocol instead: "bearer.<token>" or "apikey.<key>"
+const (
+	bearerProtocolPrefix = "bearer."
+	apiKeyProtocolPrefix = "apikey."
+)
+
+// Buckets in Limits.Rates
+const (
+	rateMessages    = "messages"
+	rateGenerations = "generations"
+)
+
+// Limits bound what one session may use
+type Limits struct {
+	// MaxContentBytes caps the grammar content, and with it the size of a message
+	MaxContentBytes int
+	// MaxSamples caps the texts one generate message may ask for
+	MaxSamples int
+	// MaxSessions caps the sessions one caller may have open at once
+	MaxSessions int
+	// IdleTimeout closes sessions that send nothing for this long
+	IdleTimeout time.Duration
+	// MaxDuration closes sessions this long after they open
+	MaxDuration time.Duration
+	// Rates are token buckets per session for every message and for every generation
+	Rates *ratelimit.Limiter
+}
+
+// LimitsFromConfig builds the session limits from the configuration
+func LimitsFromConfig(cfg config.PlaygroundConfig) (Limits, error) {
+	rates, err := ratelimit.ParseLimits(cfg.Limits)
+	if err != nil {
+		return Limits{}, err
+	}
+	for _, bucket := range []string{rateMessages, rateGenerations} {
+		if _, ok := rates[bucket]; !ok {
+			return Limits{}, fmt.Errorf("playground limits must include %s", bucket)
+		}
+	}
+
+	return Limits{
+		MaxContentBytes: cfg.MaxContentBytes,
+		MaxSamples:      cfg.MaxSamples,
+		MaxSessions:     cfg.MaxSessions,
+		IdleTimeout:     time.Duration(cfg.IdleTimeout) * time.Second,
+		MaxDuration:     time.Duration(cfg.MaxDuration) * time.Second,
+		Rates:           ratelimit.NewLimiter(ratelimit.NewMemoryStore(), rates),
+	}, nil
+}
+
+// Handler serves live playground sessions over WebSocket
+type Handler struct {
+	upgrader     websocket.Upgrader
+	usageService *services.UsageService
+	limits       Limits
+
+	mu       sync.Mutex
+	sessions map[string]int // open sessions per caller
+}
+
+// NewHandler accepts handshakes without an Origin header, from non-browser clients, and
+// from the origins allowOrigin accepts
+func NewHandler(usageService *services.UsageService, limits Limits, allowOrigin func(origin string) bool) *Handler {
+	return &Handler{
+		upgrader: websocket.Upgrader{
+			HandshakeTimeout: 10 * time.Second,
+			Subprotocols:     []string{Protocol},
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || allowOrigin(origin)
+			},
+			Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+				problem.Respond(w, r, status, problem.CodeInvalidRequest, reason.Error())
+			},
+		},
+		usageService: usageService,
+		limits:       limits,
+		sessions:     make(map[string]int),
+	}
+}
+
+// Credentials moves credentials offered as a subprotocol into the headers authentication
+// reads; register it in front of authentication on the playground route
+func Credentials(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" && r.Header.Get("X-API-Key") == "" {
+			for _, protocol := range websocket.Subprotocols(r) {
+				if token, ok := strings.CutPrefix(protocol, bearerProtocolPrefix); ok {
+					r.Header.Set("Authorization", "Bearer "+token)
+					break
+				}
+				if key, ok := strings.CutPrefix(protocol, apiKeyProtocolPrefix); ok {
+					r.Header.Set("X-API-Key", key)
+					break
+				}
+			}
+		}
+		next(w, r)
+	}
+}
+
+// HandlePlayground upgrades the request and runs a session until the client leaves or a
+// limit closes it
+func (h *Handler) HandlePlayground(w http.ResponseWriter, r *http.Request) {
+	caller := callerKey(identity.FromContext(r.Context()))
+	if !h.open(caller) {
+		problem.Respond(w, r, http.StatusTooManyRequests, problem.CodeRateLimited,
+			fmt.Sprintf("at most %d playground sessions may be open at once", h.limits.MaxSessions))
+		return
+	}
+	defer h.close(caller)
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has replied
+		return
+	}
+	defer conn.Close()
+
+	ctx := logging.Annotate(r.Context(), "playground_session", logging.RequestID(r.Context()))
+	s := newSession(ctx, conn, h.usageService, h.limits)
+	s.run()
+}
+
+// open counts a new session for caller, unless caller is at the limit
+func (h *Handler) open(caller string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.limits.MaxSessions > 0 && h.sessions[caller] >= h.limits.MaxSessions {
+		return false
+	}
+	h.sessions[caller]++
+	return true
+}
+
+func (h *Handler) close(caller string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.sessions[caller]--; h.sessions[caller] <= 0 {
+		delete(h.sessions, caller)
+	}
+}
+
+// callerKey identifies who a session belongs to, as the rate limiter does
+func callerKey(caller *identity.Identity) string {
+	if caller == nil {
+		return ""
+	}
+	if caller.APIKeyID != "" {
+		return "key:" + caller.APIKeyID
+	}
+	return "sub:" + caller.Subject
+}
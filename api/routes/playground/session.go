@@ -0,0 +1,405 @@
+// api/routes/playground/session.go
+package playground
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/grammar"
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/services"
+
+	"github.com/gorilla/websocket"
+)
+
+// Close codes, from the range RFC 6455 leaves to applications
+const (
+	closeIdleTimeout    = 4000
+	closeSessionExpired = 4001
+)
+
+const (
+	pingInterval    = 30 * time.Second
+	writeTimeout    = 10 * time.Second
+	generateTimeout = 10 * time.Second
+)
+
+// maxEdits caps the edits in one message; each one rewrites the content
+const maxEdits = 256
+
+// sessionCount numbers sessions for their rate limit buckets; request IDs may come from clients
+var sessionCount atomic.Int64
+
+// maxDrawnSeed keeps drawn seeds exactly representable as JavaScript numbers, matching /api/v2/generate
+const maxDrawnSeed = 1 << 53
+
+// clientMessage is any message a client sends; Type selects the fields that apply:
+//   - "set" replaces the content with Content
+//   - "edit" applies Edits to the content
+//   - "generate" generates Count texts; with Live set the same request is re-run, with the
+//     same seed, after every change that leaves the grammar valid
+//   - "stop" ends live generation
+type clientMessage struct {
+	Type        string            `json:"type"`
+	ID          string            `json:"id"`
+	Content     string            `json:"content"`
+	Edits       []edit            `json:"edits"`
+	Count       int               `json:"count"`
+	Seed        *int64            `json:"seed"`
+	StartSymbol string            `json:"startSymbol"`
+	Variables   map[string]string `json:"variables"`
+	Live        bool              `json:"live"`
+}
+
+// edit replaces the characters from Start up to End with Text. Offsets count Unicode code
+// points, and the edits of a message apply in order, each to the result of the one before
+type edit struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+}
+
+type readyMessage struct {
+	Type    string        `json:"type"`
+	Session string        `json:"session"`
+	Limits  limitsMessage `json:"limits"`
+}
+
+type limitsMessage struct {
+	MaxContentBytes    int   `json:"maxContentBytes"`
+	MaxSamples         int   `json:"maxSamples"`
+	IdleTimeoutSeconds int64 `json:"idleTimeoutSeconds"`
+}
+
+// diagnosticsMessage is sent after every change to the content
+type diagnosticsMessage struct {
+	Type        string   `json:"type"`
+	ID          string   `json:"id,omitempty"`
+	Revision    int      `json:"revision"`
+	Valid       bool     `json:"valid"`
+	Error       string   `json:"error,omitempty"`
+	StartSymbol string   `json:"startSymbol"`
+	Rules       []string `json:"rules"`
+	Undefined   []string `json:"undefined"`
+	Unreachable []string `json:"unreachable"`
+}
+
+type samplesMessage struct {
+	Type     string   `json:"type"`
+	ID       string   `json:"id,omitempty"`
+	Revision int      `json:"revision"`
+	Seed     int64    `json:"seed"`
+	Texts    []string `json:"texts"`
+}
+
+// errorMessage reports a message that could not be handled; the session stays open
+type errorMessage struct {
+	Type       string  `json:"type"`
+	ID         string  `json:"id,omitempty"`
+	Code       string  `json:"code"`
+	Detail     string  `json:"detail"`
+	RetryAfter float64 `json:"retryAfter,omitempty"` // seconds
+}
+
+// session is one client's playground: its document, and the live generation request
+// re-run as the document changes. Only run's goroutine reads and writes messages
+type session struct {
+	ctx          context.Context
+	id           string
+	bucket       string
+	conn         *websocket.Conn
+	usageService *services.UsageService
+	limits       Limits
+
+	doc      *grammar.Document
+	revision int
+	live     *clientMessage
+}
+
+func newSession(ctx context.Context, conn *websocket.Conn, usageService *services.UsageService, limits Limits) *session {
+	return &session{
+		ctx:          ctx,
+		id:           logging.RequestID(ctx),
+		bucket:       strconv.FormatInt(sessionCount.Add(1), 10),
+		conn:         conn,
+		usageService: usageService,
+		limits:       limits,
+		doc:          grammar.NewDocument(),
+	}
+}
+
+// run reads and handles messages until the client leaves, a write fails, or the session
+// idles or outlives its limits
+func (s *session) run() {
+	logger := logging.FromContext(s.ctx)
+	// JSON may escape each byte of content into six
+	s.conn.SetReadLimit(int64(s.limits.MaxContentBytes)*6 + 4096)
+
+	done := make(chan struct{})
+	defer close(done)
+	go s.ping(done)
+
+	expires := time.Now().Add(s.limits.MaxDuration)
+	err := s.send(readyMessage{
+		Type:    "ready",
+		Session: s.id,
+		Limits: limitsMessage{
+			MaxContentBytes:    s.limits.MaxContentBytes,
+			MaxSamples:         s.limits.MaxSamples,
+			IdleTimeoutSeconds: int64(s.limits.IdleTimeout.Seconds()),
+		},
+	})
+
+	for err == nil {
+		idle := time.Now().Add(s.limits.IdleTimeout)
+		s.conn.SetReadDeadline(earliest(idle, expires))
+
+		var data []byte
+		if _, data, err = s.conn.ReadMessage(); err != nil {
+			var netErr net.Error
+			switch {
+			case errors.As(err, &netErr) && netErr.Timeout() && idle.Before(expires):
+				s.closeWith(closeIdleTimeout, "idle timeout")
+			case errors.As(err, &netErr) && netErr.Timeout():
+				s.closeWith(closeSessionExpired, "session expired")
+			case websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway):
+				logger.Info("playground session ended", "error", err)
+			}
+			return
+		}
+
+		var msg clientMessage
+		if jsonErr := json.Unmarshal(data, &msg); jsonErr != nil {
+			err = s.sendError("", problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "messages must be JSON objects"))
+			continue
+		}
+		if !s.allow(rateMessages, msg.ID, &err) {
+			continue
+		}
+		err = s.handle(msg)
+	}
+	if err != nil && !errors.Is(err, websocket.ErrCloseSent) {
+		logger.Info("playground session ended", "error", err)
+	}
+}
+
+// handle acts on one message; the error is only for failures to reply
+func (s *session) handle(msg clientMessage) error {
+	switch msg.Type {
+	case "set":
+		return s.update(msg.ID, msg.Content)
+	case "edit":
+		content, err := applyEdits(s.doc.Content(), msg.Edits)
+		if err != nil {
+			return s.sendError(msg.ID, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, err.Error()))
+		}
+		return s.update(msg.ID, content)
+	case "generate":
+		if msg.Live {
+			// Pin the seed so only changes to the grammar change the samples
+			if msg.Seed == nil {
+				seed := rand.Int63n(maxDrawnSeed)
+				msg.Seed = &seed
+			}
+			live := msg
+			s.live = &live
+		}
+		return s.generate(msg)
+	case "stop":
+		s.live = nil
+		return nil
+	default:
+		return s.sendError(msg.ID, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest,
+			fmt.Sprintf("unknown message type %q", msg.Type)))
+	}
+}
+
+// update re-parses the document with content, reports its diagnostics and re-runs live generation
+func (s *session) update(id, content string) error {
+	if len(content) > s.limits.MaxContentBytes {
+		return s.sendError(id, problem.New(http.StatusRequestEntityTooLarge, problem.CodePayloadTooLarge,
+			fmt.Sprintf("grammar content exceeds %d bytes", s.limits.MaxContentBytes)))
+	}
+
+	s.doc.Set(content)
+	s.revision++
+
+	analysis := s.doc.Analyze()
+	diagnostics := diagnosticsMessage{
+		Type:        "diagnostics",
+		ID:          id,
+		Revision:    s.revision,
+		Valid:       analysis.Err == nil,
+		StartSymbol: analysis.StartSymbol,
+		Rules:       make([]string, len(analysis.Rules)),
+		Undefined:   analysis.Undefined,
+		Unreachable: analysis.Unreachable,
+	}
+	if analysis.Err != nil {
+		diagnostics.Error = analysis.Err.Error()
+	}
+	for i, rule := range analysis.Rules {
+		diagnostics.Rules[i] = rule.Name
+	}
+	if err := s.send(diagnostics); err != nil {
+		return err
+	}
+
+	if s.live != nil && analysis.Err == nil {
+		return s.generate(*s.live)
+	}
+	return nil
+}
+
+// generate reserves the caller's quota, generates from the current content, and meters what
+// was generated, as /api/v2/generate does for inline content
+func (s *session) generate(req clientMessage) error {
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.Count < 1 || req.Count > s.limits.MaxSamples {
+		return s.sendError(req.ID, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest,
+			fmt.Sprintf("count must be between 1 and %d", s.limits.MaxSamples)))
+	}
+	var err error
+	if !s.allow(rateGenerations, req.ID, &err) {
+		return err
+	}
+
+	generator, err := s.doc.Generator()
+	if err != nil {
+		return s.sendError(req.ID, err)
+	}
+	seed := rand.Int63n(maxDrawnSeed)
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, generateTimeout)
+	defer cancel()
+
+	quota, err := s.usageService.ReserveQuota(ctx, req.Count)
+	if err != nil {
+		return s.sendError(req.ID, err)
+	}
+
+	start := time.Now()
+	texts := make([]string, 0, req.Count)
+	err = generator.RunEach(ctx, req.Count, seed, grammar.Options{
+		StartSymbol: req.StartSymbol,
+		Variables:   req.Variables,
+	}, func(_ int, text string) error {
+		texts = append(texts, text)
+		return nil
+	})
+	if err != nil {
+		s.usageService.ReleaseQuota(ctx, quota, req.Count)
+		return s.sendError(req.ID, err)
+	}
+	s.usageService.RecordGeneration(ctx, &services.Generation{
+		GrammarID: services.InlineGrammarID,
+		Messages:  texts,
+	}, time.Since(start))
+	s.usageService.ReleaseQuota(ctx, quota, req.Count-len(texts))
+
+	return s.send(samplesMessage{
+		Type:     "samples",
+		ID:       req.ID,
+		Revision: s.revision,
+		Seed:     seed,
+		Texts:    texts,
+	})
+}
+
+// allow takes a token from the session's bucket, replying with rate_limited when it is
+// empty; a failed reply is left in err. The limiter's store is in memory and cannot fail
+func (s *session) allow(bucket, id string, err *error) bool {
+	res, _, _ := s.limits.Rates.Allow(s.ctx, bucket, s.bucket)
+	if res.Allowed {
+		return true
+	}
+
+	*err = s.send(errorMessage{
+		Type:       "error",
+		ID:         id,
+		Code:       problem.CodeRateLimited,
+		Detail:     "too many " + bucket + "; slow down",
+		RetryAfter: res.RetryAfter.Seconds(),
+	})
+	return false
+}
+
+// sendError reports the problem err maps to; unknown errors are logged and reported as internal
+func (s *session) sendError(id string, err error) error {
+	p := problem.FromError(err)
+	if p == nil || p.Status >= http.StatusInternalServerError {
+		logging.FromContext(s.ctx).Error("playground message failed", "error", err)
+	}
+	if p == nil {
+		p = problem.New(http.StatusInternalServerError, problem.CodeInternal, "internal server error")
+	}
+
+	return s.send(errorMessage{
+		Type:   "error",
+		ID:     id,
+		Code:   p.Code,
+		Detail: p.Error(),
+	})
+}
+
+func (s *session) send(msg interface{}) error {
+	s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return s.conn.WriteJSON(msg)
+}
+
+func (s *session) closeWith(code int, reason string) {
+	s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeTimeout))
+}
+
+// ping keeps proxies from dropping a quiet connection; it does not count as client activity
+func (s *session) ping(done <-chan struct{}) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// applyEdits applies edits to content in order
+func applyEdits(content string, edits []edit) (string, error) {
+	if len(edits) > maxEdits {
+		return "", fmt.Errorf("at most %d edits may be sent in one message", maxEdits)
+	}
+	for _, e := range edits {
+		runes := []rune(content)
+		if e.Start < 0 || e.End < e.Start || e.End > len(runes) {
+			return "", fmt.Errorf("edit %d-%d is outside the content's %d characters", e.Start, e.End, len(runes))
+		}
+		content = string(runes[:e.Start]) + e.Text + string(runes[e.End:])
+	}
+	return content, nil
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,161 @@
+// api/routes/problem/problem.go
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/grammar"
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/services"
+)
+
+// ContentType is the media type of RFC 7807 problem details
+const ContentType = "application/problem+json"
+
+// Stable error codes; clients may switch on these, so never change an existing one
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeMissingFile          = "missing_file"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeUnauthorized         = "unauthorized"
+	CodeInvalidAPIKey        = "invalid_api_key"
+	CodeForbidden            = "forbidden"
+	CodeInsufficientScope    = "insufficient_scope"
+	CodeNotOwner             = "not_owner"
+	CodeScopeNotGranted      = "scope_not_granted"
+	CodeNotFound             = "not_found"
+	CodeGrammarNotFound      = "grammar_not_found"
+	CodeUserNotFound         = "user_not_found"
+	CodeAPIKeyNotFound       = "api_key_not_found"
+	CodeWebhookNotFound      = "webhook_not_found"
+	CodeDeliveryNotFound     = "delivery_not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeVersionConflict      = "version_conflict"
+	CodeInvalidGrammar       = "invalid_grammar"
+	CodeDepthExceeded        = "depth_exceeded"
+	CodeInvalidProfile       = "invalid_profile"
+	CodeQuotaExceeded        = "quota_exceeded"
+	CodeRateLimited          = "rate_limited"
+	CodeInternal             = "internal_error"
+	CodeUpstream             = "upstream_error"
+	CodeTimeout              = "timeout"
+	CodeUnavailable          = "unavailable"
+	CodeURITooLong           = "uri_too_long"
+	CodeHeadersTooLarge      = "headers_too_large"
+	CodeQueryTooComplex      = "query_too_complex"
+)
+
+// Problem is an RFC 7807 problem details object extended with a stable error code and
+// the request ID to quote when reporting the problem
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// New returns a problem with the given status, code and human-readable detail
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "urn:grammarhive:problem:" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// mappings translates core errors into problems; the first match wins
+var mappings = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{database.ErrGrammarNotFound, http.StatusNotFound, CodeGrammarNotFound},
+	{database.ErrUserNotFound, http.StatusNotFound, CodeUserNotFound},
+	{database.ErrAPIKeyNotFound, http.StatusNotFound, CodeAPIKeyNotFound},
+	{database.ErrWebhookNotFound, http.StatusNotFound, CodeWebhookNotFound},
+	{database.ErrDeliveryNotFound, http.StatusNotFound, CodeDeliveryNotFound},
+	{database.ErrVersionConflict, http.StatusConflict, CodeVersionConflict},
+	{grammar.ErrInvalidGrammar, http.StatusUnprocessableEntity, CodeInvalidGrammar},
+	{grammar.ErrDepthExceeded, http.StatusUnprocessableEntity, CodeDepthExceeded},
+	{grammar.ErrUnknownSymbol, http.StatusBadRequest, CodeInvalidRequest},
+	{services.ErrInvalidProfile, http.StatusUnprocessableEntity, CodeInvalidProfile},
+	{services.ErrNotOwner, http.StatusForbidden, CodeNotOwner},
+	{services.ErrNoUsername, http.StatusForbidden, CodeForbidden},
+	{services.ErrInvalidName, http.StatusBadRequest, CodeInvalidRequest},
+	{services.ErrInvalidUsername, http.StatusBadRequest, CodeInvalidRequest},
+	{services.ErrScopeNotGranted, http.StatusForbidden, CodeScopeNotGranted},
+	{services.ErrKeyCreatesKey, http.StatusForbidden, CodeForbidden},
+	{services.ErrInvalidAPIKey, http.StatusUnauthorized, CodeInvalidAPIKey},
+	{services.ErrInvalidWebhook, http.StatusBadRequest, CodeInvalidRequest},
+	{services.ErrQuotaExceeded, http.StatusTooManyRequests, CodeQuotaExceeded},
+}
+
+// FromError maps err to a problem, returning nil when err is not a known client-facing error
+func FromError(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+	for _, m := range mappings {
+		if errors.Is(err, m.err) {
+			return New(m.status, m.code, err.Error())
+		}
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return New(http.StatusRequestEntityTooLarge, CodePayloadTooLarge,
+			fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+	}
+	if database.IsTimeout(err) {
+		return New(http.StatusGatewayTimeout, CodeTimeout, "the request timed out")
+	}
+	return nil
+}
+
+// Write replies with a problem, filling in the request path and ID
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	body := *p
+	body.Instance = r.URL.Path
+	body.RequestID = logging.RequestID(r.Context())
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(body.Status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// Respond replies with a new problem built from status, code and detail
+func Respond(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	Write(w, r, New(status, code, detail))
+}
+
+// Error replies with the problem err maps to. Unknown errors are logged with msg and
+// reported as a generic 500 so internal details never reach the client
+func Error(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	if p := FromError(err); p != nil {
+		if p.Status >= http.StatusInternalServerError {
+			logging.FromContext(r.Context()).Error(msg, "error", err)
+		}
+		Write(w, r, p)
+		return
+	}
+	logging.FromContext(r.Context()).Error(msg, "error", err)
+	Respond(w, r, http.StatusInternalServerError, CodeInternal, msg)
+}
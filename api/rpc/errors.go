@@ -0,0 +1,84 @@
+// api/rpc/errors.go
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/logging"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain identifies the service in the ErrorInfo attached to every error
+const errorDomain = "grammarhive.org"
+
+// statusCodes translates the HTTP status of a problem into the closest gRPC code
+var statusCodes = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusConflict:              codes.Aborted,
+	http.StatusRequestEntityTooLarge: codes.InvalidArgument,
+	http.StatusUnprocessableEntity:   codes.InvalidArgument,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+	http.StatusBadGateway:            codes.Unavailable,
+	http.StatusServiceUnavailable:    codes.Unavailable,
+	http.StatusGatewayTimeout:        codes.DeadlineExceeded,
+}
+
+// statusError returns the gRPC status err maps to, with the problem code as the reason of
+// its ErrorInfo. Unknown errors are logged with msg and reported as Internal so internal
+// details never reach the client
+func statusError(ctx context.Context, msg string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "the request was canceled")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "the request timed out")
+	}
+
+	p := problem.FromError(err)
+	if p == nil {
+		logging.FromContext(ctx).Error(msg, "error", err)
+		p = problem.New(http.StatusInternalServerError, problem.CodeInternal, "internal server error")
+	} else if p.Status >= http.StatusInternalServerError {
+		logging.FromContext(ctx).Error(msg, "error", err)
+	}
+	return problemStatus(ctx, p)
+}
+
+// newError returns a status built from an HTTP status, problem code and detail
+func newError(ctx context.Context, httpStatus int, code, detail string) error {
+	return problemStatus(ctx, problem.New(httpStatus, code, detail))
+}
+
+func problemStatus(ctx context.Context, p *problem.Problem) error {
+	code, ok := statusCodes[p.Status]
+	if !ok {
+		code = codes.Internal
+	}
+
+	metadata := map[string]string{}
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		metadata["requestId"] = requestID
+	}
+
+	st, err := status.New(code, p.Error()).WithDetails(&errdetails.ErrorInfo{
+		Reason:   p.Code,
+		Domain:   errorDomain,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return status.Error(code, p.Error())
+	}
+	return st.Err()
+}
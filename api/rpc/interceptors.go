@@ -0,0 +1,281 @@
+// api/rpc/interceptors.go
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	middleware "grammarhive-backend/api/routes/middleware"
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/ratelimit"
+	"grammarhive-backend/core/tracing"
+	grammarhivev1 "grammarhive-backend/proto/grammarhive/v1"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey carries the request ID in both directions, like X-Request-ID over HTTP
+const requestIDKey = "x-request-id"
+
+var (
+	tracer           = tracing.Tracer("grammarhive-backend/api/rpc")
+	requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+)
+
+// methodScopes lists the scopes a caller must carry for each method of the grammar
+// service; every method requires authentication. Methods of other services, such as
+// health checks and reflection, are public
+var methodScopes = map[string][]string{
+	grammarhivev1.GrammarService_Generate_FullMethodName:       {identity.ScopeGenerate},
+	grammarhivev1.GrammarService_GenerateStream_FullMethodName: {identity.ScopeGenerate},
+	grammarhivev1.GrammarService_Parse_FullMethodName:          {},
+	grammarhivev1.GrammarService_Validate_FullMethodName:       {},
+	grammarhivev1.GrammarService_CreateGrammar_FullMethodName:  {identity.ScopeWrite},
+	grammarhivev1.GrammarService_GetGrammar_FullMethodName:     {identity.ScopeRead},
+	grammarhivev1.GrammarService_ListGrammars_FullMethodName:   {identity.ScopeRead},
+	grammarhivev1.GrammarService_UpdateGrammar_FullMethodName:  {identity.ScopeWrite},
+	grammarhivev1.GrammarService_DeleteGrammar_FullMethodName:  {identity.ScopeWrite},
+}
+
+// Guard applies the HTTP API's request handling to gRPC calls: request IDs, tracing,
+// access logs, panic recovery, authentication from metadata, scopes, user tracking and
+// rate limits, which are keyed by full method name
+type Guard struct {
+	Authenticator *middleware.Authenticator
+	Users         middleware.UserTracker
+	Limiter       *ratelimit.Limiter
+	Proxies       middleware.TrustedProxies
+}
+
+// Unary is the unary server interceptor
+func (g *Guard) Unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	err = g.serve(ctx, info.FullMethod, func(ctx context.Context) error {
+		resp, err = handler(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// Stream is the stream server interceptor
+func (g *Guard) Stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return g.serve(stream.Context(), info.FullMethod, func(ctx context.Context) error {
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	})
+}
+
+// serve runs call with the request scoped context, in the same order as the HTTP chain
+func (g *Guard) serve(ctx context.Context, method string, call func(ctx context.Context) error) (err error) {
+	start := time.Now()
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = g.withClientIP(ctx, md)
+
+	requestID := first(md, requestIDKey)
+	if !requestIDPattern.MatchString(requestID) {
+		requestID = newRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+	ctx = logging.WithRequestID(ctx, requestID)
+	ctx = logging.WithLogger(ctx, slog.Default().With("request_id", requestID))
+
+	fields := &logging.Fields{}
+	logger := logging.FromContext(ctx)
+	ctx = logging.WithFields(ctx, fields)
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	ctx, span := tracer.Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", method),
+			attribute.String("request.id", requestID),
+		),
+	)
+	if spanContext := span.SpanContext(); spanContext.IsValid() {
+		ctx = logging.Annotate(ctx, "trace_id", spanContext.TraceID().String())
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			logging.FromContext(ctx).Error("panic serving request",
+				"panic", fmt.Sprint(v),
+				"stack", string(debug.Stack()),
+			)
+			span.SetStatus(otelcodes.Error, fmt.Sprint("panic: ", v))
+			err = newError(ctx, http.StatusInternalServerError, problem.CodeInternal, "internal server error")
+		}
+
+		code := status.Code(err)
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+		if serverFault(code) {
+			span.SetStatus(otelcodes.Error, code.String())
+		}
+		span.End()
+
+		level := slog.LevelInfo
+		switch {
+		case serverFault(code):
+			level = slog.LevelError
+		case code != codes.OK:
+			level = slog.LevelWarn
+		}
+		attrs := append([]any{
+			"method", method,
+			"proto", "grpc",
+			"code", code.String(),
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"ip", clientIP(ctx),
+			"user_agent", userAgent(ctx),
+		}, fields.Attrs()...)
+		logger.Log(ctx, level, "request", attrs...)
+	}()
+
+	scopes, secured := methodScopes[method]
+	if !secured {
+		return call(ctx)
+	}
+
+	if err := g.allow(ctx, middleware.PreAuthRoute, "ip:"+clientIP(ctx)); err != nil {
+		return err
+	}
+	ctx, authErr := g.Authenticator.Authenticate(ctx, first(md, "authorization"), first(md, "x-api-key"))
+	if authErr != nil {
+		g.Authenticator.RecordFailure(ctx, clientIP(ctx), userAgent(ctx), method, authErr.Msg)
+		code := problem.CodeForbidden
+		if authErr.Status == http.StatusUnauthorized {
+			code = problem.CodeUnauthorized
+		}
+		return newError(ctx, authErr.Status, code, authErr.Msg)
+	}
+
+	caller := identity.FromContext(ctx)
+	if err := g.Users.Touch(ctx, caller); err != nil {
+		logging.FromContext(ctx).Warn("failed to record user", "subject", caller.Subject, "error", err)
+	}
+
+	if scope, missing := caller.MissingScope(scopes); missing {
+		return newError(ctx, http.StatusForbidden, problem.CodeInsufficientScope, fmt.Sprintf("insufficient scope: missing %s", scope))
+	}
+
+	key := "sub:" + caller.Tenant + "/" + caller.Subject
+	if caller.APIKeyID != "" {
+		key = "key:" + caller.APIKeyID
+	}
+	if err := g.allow(ctx, method, key); err != nil {
+		return err
+	}
+	return call(ctx)
+}
+
+// allow takes a rate limit token for key on route, failing open when the store is
+// unavailable like the HTTP middleware
+func (g *Guard) allow(ctx context.Context, route, key string) error {
+	res, limited, err := g.Limiter.Allow(ctx, route, key)
+	if err != nil {
+		logging.FromContext(ctx).Warn("rate limit store error", "route", route, "error", err)
+		return nil
+	}
+	if !limited || res.Allowed {
+		return nil
+	}
+
+	retryAfter := int64(math.Ceil(res.RetryAfter.Seconds()))
+	grpc.SetTrailer(ctx, metadata.Pairs("retry-after", strconv.FormatInt(retryAfter, 10)))
+	return newError(ctx, http.StatusTooManyRequests, problem.CodeRateLimited, "rate limit exceeded")
+}
+
+// serverFault reports whether a code means the server, not the caller, is at fault
+func serverFault(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.DeadlineExceeded, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+type clientIPKey struct{}
+
+// withClientIP resolves the caller's address, believing the x-forwarded-for metadata only
+// as far as the trusted proxies like the HTTP middleware, and stores it for clientIP
+func (g *Guard) withClientIP(ctx context.Context, md metadata.MD) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, clientIPKey{}, g.Proxies.ClientAddress(p.Addr.String(), md.Get("x-forwarded-for")))
+}
+
+// clientIP returns the caller's address resolved by the guard
+func clientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+func userAgent(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return first(md, "user-agent")
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// contextStream replaces the context of a server stream with the request scoped one
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// metadataCarrier reads W3C trace context from incoming metadata
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	return first(metadata.MD(c), key)
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+var _ propagation.TextMapCarrier = metadataCarrier{}
@@ -0,0 +1,282 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	middleware "grammarhive-backend/api/routes/middleware"
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/devissuer"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/ratelimit"
+	grammarhivev1 "grammarhive-backend/proto/grammarhive/v1"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// echoServer answers generation calls with the caller's subject instead of generating, so
+// tests can see which identity the guard passed on
+type echoServer struct {
+	grammarhivev1.UnimplementedGrammarServiceServer
+}
+
+func (echoServer) Generate(ctx context.Context, req *grammarhivev1.GenerateRequest) (*grammarhivev1.GenerateResponse, error) {
+	return &grammarhivev1.GenerateResponse{Texts: []string{identity.FromContext(ctx).Subject}}, nil
+}
+
+func (echoServer) GenerateStream(req *grammarhivev1.GenerateRequest, stream grammarhivev1.GrammarService_GenerateStreamServer) error {
+	subject := identity.FromContext(stream.Context()).Subject
+	for i := 0; i < int(req.GetCount()); i++ {
+		if err := stream.Send(&grammarhivev1.GeneratedText{Index: int32(i), Text: subject}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seenUsers records the subjects the guard tracked
+type seenUsers struct {
+	mu       sync.Mutex
+	subjects []string
+}
+
+func (u *seenUsers) Touch(ctx context.Context, caller *identity.Identity) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.subjects = append(u.subjects, caller.Subject)
+	return nil
+}
+
+// testIssuer returns a development issuer and an authenticator trusting its keys
+func testIssuer(t *testing.T) (*devissuer.Issuer, *middleware.Authenticator) {
+	t.Helper()
+	issuer, err := devissuer.New(filepath.Join(t.TempDir(), "dev.pem"), "", "grammarhive-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := issuer.JWKSJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	auth, err := middleware.NewFromJWKSFile(jwksFile, issuer.Issuer, issuer.Audience)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return issuer, auth
+}
+
+// mint returns call metadata carrying a token for subject with scopes
+func mint(t *testing.T, issuer *devissuer.Issuer, subject string, scopes ...string) metadata.MD {
+	t.Helper()
+	token, err := issuer.Mint(devissuer.TokenOptions{Subject: subject, Scopes: scopes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return metadata.Pairs("authorization", "Bearer "+token)
+}
+
+// dialGuarded serves srv behind guard over an in-memory listener and returns a client for it
+func dialGuarded(t *testing.T, srv grammarhivev1.GrammarServiceServer, guard *Guard) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(srv, guard)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func newGuard(auth *middleware.Authenticator, limits map[string]ratelimit.Limit) (*Guard, *seenUsers) {
+	users := &seenUsers{}
+	return &Guard{
+		Authenticator: auth,
+		Users:         users,
+		Limiter:       ratelimit.NewLimiter(ratelimit.NewMemoryStore(), limits),
+	}, users
+}
+
+// reason returns the problem code attached to a status error
+func reason(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func TestGuardAuthenticatesFromMetadata(t *testing.T) {
+	issuer, auth := testIssuer(t)
+	guard, users := newGuard(auth, nil)
+	client := grammarhivev1.NewGrammarServiceClient(dialGuarded(t, echoServer{}, guard))
+
+	other, err := devissuer.New(filepath.Join(t.TempDir(), "other.pem"), issuer.Issuer, issuer.Audience)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		code codes.Code
+	}{
+		{"missing", metadata.MD{}, codes.Unauthenticated},
+		{"malformed", metadata.Pairs("authorization", "Bearer not-a-token"), codes.Unauthenticated},
+		{"other issuer's key", mint(t, other, "dev|mallory", identity.ScopeGenerate), codes.Unauthenticated},
+		{"valid", mint(t, issuer, "dev|ada", identity.ScopeGenerate), codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewOutgoingContext(context.Background(), tt.md)
+			resp, err := client.Generate(ctx, &grammarhivev1.GenerateRequest{})
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("code = %s, want %s: %v", code, tt.code, err)
+			}
+			if tt.code == codes.OK && resp.Texts[0] != "dev|ada" {
+				t.Fatalf("handler saw subject %q, want dev|ada", resp.Texts[0])
+			}
+		})
+	}
+
+	if len(users.subjects) != 1 || users.subjects[0] != "dev|ada" {
+		t.Fatalf("tracked users = %v, want only dev|ada", users.subjects)
+	}
+}
+
+func TestGuardChecksScopes(t *testing.T) {
+	issuer, auth := testIssuer(t)
+	guard, _ := newGuard(auth, nil)
+	conn := dialGuarded(t, echoServer{}, guard)
+	client := grammarhivev1.NewGrammarServiceClient(conn)
+
+	ctx := metadata.NewOutgoingContext(context.Background(), mint(t, issuer, "dev|ada", identity.ScopeRead))
+	_, err := client.Generate(ctx, &grammarhivev1.GenerateRequest{})
+	if status.Code(err) != codes.PermissionDenied || reason(err) != problem.CodeInsufficientScope {
+		t.Fatalf("Generate without %s: %v, want PermissionDenied insufficient_scope", identity.ScopeGenerate, err)
+	}
+
+	stream, err := client.GenerateStream(ctx, &grammarhivev1.GenerateRequest{Count: 1})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("GenerateStream without %s: %v, want PermissionDenied", identity.ScopeGenerate, err)
+	}
+
+	// Methods without scopes still need a caller; other services are public
+	if _, err := client.Parse(context.Background(), &grammarhivev1.ParseRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("anonymous Parse: %v, want Unauthenticated", err)
+	}
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("anonymous health check: %v", err)
+	}
+}
+
+func TestGuardRateLimitsPerCaller(t *testing.T) {
+	issuer, auth := testIssuer(t)
+	guard, _ := newGuard(auth, map[string]ratelimit.Limit{
+		grammarhivev1.GrammarService_Generate_FullMethodName: {Requests: 2, Per: time.Minute, Burst: 2},
+	})
+	client := grammarhivev1.NewGrammarServiceClient(dialGuarded(t, echoServer{}, guard))
+
+	ada := metadata.NewOutgoingContext(context.Background(), mint(t, issuer, "dev|ada", identity.ScopeGenerate))
+	for i := 0; i < 2; i++ {
+		if _, err := client.Generate(ada, &grammarhivev1.GenerateRequest{}); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+
+	var trailer metadata.MD
+	_, err := client.Generate(ada, &grammarhivev1.GenerateRequest{}, grpc.Trailer(&trailer))
+	if status.Code(err) != codes.ResourceExhausted || reason(err) != problem.CodeRateLimited {
+		t.Fatalf("third call: %v, want ResourceExhausted rate_limited", err)
+	}
+	if retryAfter := trailer.Get("retry-after"); len(retryAfter) != 1 || retryAfter[0] != "30" {
+		t.Fatalf("retry-after = %v, want [30]", retryAfter)
+	}
+
+	// Buckets are kept per caller
+	bob := metadata.NewOutgoingContext(context.Background(), mint(t, issuer, "dev|bob", identity.ScopeGenerate))
+	if _, err := client.Generate(bob, &grammarhivev1.GenerateRequest{}); err != nil {
+		t.Fatalf("another caller was limited: %v", err)
+	}
+}
+
+func TestGuardRateLimitsBeforeAuthentication(t *testing.T) {
+	_, auth := testIssuer(t)
+	guard, _ := newGuard(auth, map[string]ratelimit.Limit{
+		middleware.PreAuthRoute: {Requests: 1, Per: time.Minute, Burst: 1},
+	})
+	client := grammarhivev1.NewGrammarServiceClient(dialGuarded(t, echoServer{}, guard))
+
+	if _, err := client.Generate(context.Background(), &grammarhivev1.GenerateRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("first anonymous call: %v, want Unauthenticated", err)
+	}
+	if _, err := client.Generate(context.Background(), &grammarhivev1.GenerateRequest{}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("second anonymous call: %v, want ResourceExhausted before authenticating", err)
+	}
+}
+
+func TestGuardStreams(t *testing.T) {
+	issuer, auth := testIssuer(t)
+	guard, _ := newGuard(auth, nil)
+	client := grammarhivev1.NewGrammarServiceClient(dialGuarded(t, echoServer{}, guard))
+
+	ctx := metadata.NewOutgoingContext(context.Background(), mint(t, issuer, "dev|ada", identity.ScopeGenerate))
+	var header metadata.MD
+	stream, err := client.GenerateStream(ctx, &grammarhivev1.GenerateRequest{Count: 3}, grpc.Header(&header))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var texts []*grammarhivev1.GeneratedText
+	for {
+		text, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		texts = append(texts, text)
+	}
+	if len(texts) != 3 {
+		t.Fatalf("received %d texts, want 3", len(texts))
+	}
+	for i, text := range texts {
+		if text.Index != int32(i) || text.Text != "dev|ada" {
+			t.Fatalf("text %d = %+v, want index %d from dev|ada", i, text, i)
+		}
+	}
+	if len(header.Get(requestIDKey)) != 1 {
+		t.Fatalf("stream header has no %s: %v", requestIDKey, header)
+	}
+}
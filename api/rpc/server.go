@@ -0,0 +1,338 @@
+// api/rpc/server.go
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"grammarhive-backend/api/routes/problem"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/grammar"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/services"
+	grammarhivev1 "grammarhive-backend/proto/grammarhive/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Limits on the number of texts a single call may generate
+const (
+	maxGenerateCount       = 100
+	maxGenerateStreamCount = 1000
+)
+
+// maxDrawnSeed keeps seeds drawn for callers exactly representable as JavaScript numbers,
+// matching /api/v2/generate
+const maxDrawnSeed = 1 << 53
+
+// Server implements grammarhive.v1.GrammarService on top of the services package, so gRPC
+// callers see the same grammars, quotas and audit log as HTTP callers
+type Server struct {
+	grammarhivev1.UnimplementedGrammarServiceServer
+	grammarService *services.GrammarGenService
+	profileService *services.ProfileService
+	usageService   *services.UsageService
+	auditService   *services.AuditService
+}
+
+func NewServer(dbService *database.MongoDB, usageService *services.UsageService, auditService *services.AuditService, webhookService *services.WebhookService, cache *grammar.Cache) *Server {
+	grammarService := services.NewGrammarService(dbService)
+	grammarService.GrammarService.Cache = cache
+	profileService := services.NewProfileService(dbService)
+	profileService.Webhooks = webhookService
+
+	return &Server{
+		grammarService: grammarService,
+		profileService: profileService,
+		usageService:   usageService,
+		auditService:   auditService,
+	}
+}
+
+// Generate returns every text at once
+func (s *Server) Generate(ctx context.Context, req *grammarhivev1.GenerateRequest) (*grammarhivev1.GenerateResponse, error) {
+	genReq, err := generateRequest(ctx, req, maxGenerateCount)
+	if err != nil {
+		return nil, err
+	}
+	ctx = annotateGeneration(ctx, genReq)
+
+	generation, err := s.generate(ctx, genReq, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grammarhivev1.GenerateResponse{
+		GrammarId: generation.GrammarID,
+		Version:   int32(generation.Version),
+		Seed:      genReq.Seed,
+		Texts:     generation.Messages,
+	}, nil
+}
+
+// GenerateStream sends each text as soon as it is generated; the texts are the same as
+// Generate returns for the same request and seed
+func (s *Server) GenerateStream(req *grammarhivev1.GenerateRequest, stream grammarhivev1.GrammarService_GenerateStreamServer) error {
+	ctx := stream.Context()
+	genReq, err := generateRequest(ctx, req, maxGenerateStreamCount)
+	if err != nil {
+		return err
+	}
+	ctx = annotateGeneration(ctx, genReq)
+
+	grammarID := genReq.GrammarID
+	if grammarID == "" {
+		grammarID = services.InlineGrammarID
+	}
+	_, err = s.generate(ctx, genReq, func(index, version int, text string) error {
+		return stream.Send(&grammarhivev1.GeneratedText{
+			Index:     int32(index),
+			Text:      text,
+			GrammarId: grammarID,
+			Version:   int32(version),
+			Seed:      genReq.Seed,
+		})
+	})
+	return err
+}
+
+// generate reserves the caller's quota, generates, and meters what was generated, handing
+// back the rest of the reservation. Texts already sent on a stream that then broke are
+// metered too, even though the call's context has usually been cancelled by then
+func (s *Server) generate(ctx context.Context, req services.GenerateRequest, fn func(index, version int, text string) error) (*services.Generation, error) {
+	quota, err := s.usageService.ReserveQuota(ctx, req.Count)
+	if err != nil {
+		return nil, statusError(ctx, "Error checking usage quota", err)
+	}
+
+	start := time.Now()
+	generation, err := s.grammarService.GenerateEach(ctx, req, fn)
+	delivered := 0
+	if generation != nil && len(generation.Messages) > 0 {
+		delivered = len(generation.Messages)
+		s.usageService.RecordGeneration(context.WithoutCancel(ctx), generation, time.Since(start))
+	}
+	s.usageService.ReleaseQuota(context.WithoutCancel(ctx), quota, req.Count-delivered)
+	if err != nil {
+		return nil, statusError(ctx, "Generation failed", err)
+	}
+	return generation, nil
+}
+
+// generateRequest checks a request against the limits of the calling method and draws a
+// seed when none was sent
+func generateRequest(ctx context.Context, req *grammarhivev1.GenerateRequest, maxCount int) (services.GenerateRequest, error) {
+	if (req.GetGrammarId() == "") == (req.GetContent() == "") {
+		return services.GenerateRequest{}, newError(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "exactly one of grammar_id and content is required")
+	}
+
+	count := int(req.GetCount())
+	if count == 0 {
+		count = 1
+	}
+	if count < 1 || count > maxCount {
+		return services.GenerateRequest{}, newError(ctx, http.StatusBadRequest, problem.CodeInvalidRequest,
+			fmt.Sprintf("count must be between 1 and %d", maxCount))
+	}
+
+	seed := req.GetSeed()
+	if req.Seed == nil {
+		seed = rand.Int63n(maxDrawnSeed)
+	}
+
+	return services.GenerateRequest{
+		GrammarID: req.GetGrammarId(),
+		Content:   req.GetContent(),
+		Count:     count,
+		Seed:      seed,
+		Options: grammar.Options{
+			StartSymbol: req.GetStartSymbol(),
+			Variables:   req.GetVariables(),
+		},
+	}, nil
+}
+
+func annotateGeneration(ctx context.Context, req services.GenerateRequest) context.Context {
+	grammarID := req.GrammarID
+	if grammarID == "" {
+		grammarID = services.InlineGrammarID
+	}
+	return logging.Annotate(ctx, "grammar_id", grammarID, "count", req.Count, "seed", req.Seed)
+}
+
+// Parse returns the rules read from grammar content, sorted by name
+func (s *Server) Parse(ctx context.Context, req *grammarhivev1.ParseRequest) (*grammarhivev1.ParseResponse, error) {
+	analysis := grammar.Analyze(req.GetContent())
+
+	rules := make([]*grammarhivev1.Rule, 0, len(analysis.Rules))
+	for _, rule := range analysis.Rules {
+		rules = append(rules, &grammarhivev1.Rule{Name: rule.Name, Productions: rule.Productions})
+	}
+	return &grammarhivev1.ParseResponse{StartSymbol: analysis.StartSymbol, Rules: rules}, nil
+}
+
+// Validate reports whether grammar content compiles; an invalid grammar is not an error
+func (s *Server) Validate(ctx context.Context, req *grammarhivev1.ValidateRequest) (*grammarhivev1.ValidateResponse, error) {
+	if _, err := grammar.NewRandomTextGenerator(req.GetContent()); err != nil {
+		return &grammarhivev1.ValidateResponse{Valid: false, Error: err.Error()}, nil
+	}
+	return &grammarhivev1.ValidateResponse{Valid: true}, nil
+}
+
+// CreateGrammar stores a new grammar owned by the caller
+func (s *Server) CreateGrammar(ctx context.Context, req *grammarhivev1.CreateGrammarRequest) (*grammarhivev1.Grammar, error) {
+	created, err := s.profileService.CreateGrammar(ctx, req.GetName(), req.GetContent(), req.GetPrivate())
+
+	entry := auditEntry(ctx, services.AuditGrammarCreate)
+	if created != nil {
+		entry.GrammarID, entry.Version, entry.Owner = created.GrammarID, created.Version, created.Owner
+	}
+	s.recordAudit(ctx, entry, err)
+
+	if err != nil {
+		return nil, statusError(ctx, "Error storing grammar", err)
+	}
+	return grammarMessage(created), nil
+}
+
+// GetGrammar returns the latest version of a grammar
+func (s *Server) GetGrammar(ctx context.Context, req *grammarhivev1.GetGrammarRequest) (*grammarhivev1.Grammar, error) {
+	g, err := s.profileService.GetGrammar(ctx, req.GetGrammarId())
+	if err != nil {
+		return nil, statusError(ctx, "Error fetching grammar", err)
+	}
+	return grammarMessage(g), nil
+}
+
+// ListGrammars lists a user's grammars, the caller's own when no username is given
+func (s *Server) ListGrammars(ctx context.Context, req *grammarhivev1.ListGrammarsRequest) (*grammarhivev1.ListGrammarsResponse, error) {
+	username := req.GetUsername()
+	if username == "" {
+		caller := identity.FromContext(ctx)
+		if caller == nil || caller.Username == "" {
+			return nil, statusError(ctx, "Error listing grammars", services.ErrNoUsername)
+		}
+		username = caller.Username
+	}
+
+	grammars, err := s.profileService.ListGrammars(ctx, username)
+	if err != nil {
+		return nil, statusError(ctx, "Error listing grammars", err)
+	}
+
+	resp := &grammarhivev1.ListGrammarsResponse{Grammars: make([]*grammarhivev1.Grammar, 0, len(grammars))}
+	for i := range grammars {
+		resp.Grammars = append(resp.Grammars, grammarMessage(&grammars[i]))
+	}
+	return resp, nil
+}
+
+// UpdateGrammar stores a new version of one of the caller's grammars
+func (s *Server) UpdateGrammar(ctx context.Context, req *grammarhivev1.UpdateGrammarRequest) (*grammarhivev1.Grammar, error) {
+	updated, err := s.profileService.UpdateGrammar(ctx, req.GetGrammarId(), services.GrammarUpdate{
+		Version: int(req.GetVersion()),
+		Name:    req.Name,
+		Content: req.Content,
+		Private: req.Private,
+	})
+
+	entry := auditEntry(ctx, services.AuditGrammarUpdate)
+	entry.GrammarID = req.GetGrammarId()
+	if updated != nil {
+		entry.Version, entry.Owner = updated.Version, updated.Owner
+	}
+	s.recordAudit(ctx, entry, err)
+
+	if err != nil {
+		return nil, statusError(ctx, "Error updating grammar", err)
+	}
+	return grammarMessage(updated), nil
+}
+
+// DeleteGrammar deletes every version of one of the caller's grammars
+func (s *Server) DeleteGrammar(ctx context.Context, req *grammarhivev1.DeleteGrammarRequest) (*grammarhivev1.DeleteGrammarResponse, error) {
+	err := s.profileService.DeleteGrammar(ctx, req.GetGrammarId())
+
+	entry := auditEntry(ctx, services.AuditGrammarDelete)
+	entry.GrammarID = req.GetGrammarId()
+	s.recordAudit(ctx, entry, err)
+
+	if err != nil {
+		return nil, statusError(ctx, "Error deleting grammar", err)
+	}
+	return &grammarhivev1.DeleteGrammarResponse{}, nil
+}
+
+// recordAudit records the outcome of a grammar change
+func (s *Server) recordAudit(ctx context.Context, entry database.AuditEntry, err error) {
+	switch {
+	case errors.Is(err, services.ErrNotOwner):
+		entry.Result, entry.Detail = services.AuditDenied, err.Error()
+	case err != nil:
+		entry.Result, entry.Detail = services.AuditFailure, err.Error()
+	}
+	s.auditService.Record(ctx, entry)
+}
+
+func auditEntry(ctx context.Context, action string) database.AuditEntry {
+	return database.AuditEntry{
+		Action:    action,
+		IP:        clientIP(ctx),
+		UserAgent: userAgent(ctx),
+		Result:    services.AuditSuccess,
+	}
+}
+
+func grammarMessage(g *database.Grammar) *grammarhivev1.Grammar {
+	return &grammarhivev1.Grammar{
+		GrammarId: g.GrammarID,
+		Version:   int32(g.Version),
+		Name:      g.Name,
+		Username:  g.Username,
+		Owner:     g.Owner,
+		Private:   g.Private,
+		Content:   g.Content,
+		CreatedAt: timestamppb.New(g.CreatedAt),
+		UpdatedAt: timestamppb.New(g.UpdatedAt),
+	}
+}
+
+// NewGRPCServer serves the grammar service behind guard, along with the standard health
+// and reflection services
+func NewGRPCServer(srv grammarhivev1.GrammarServiceServer, guard *Guard, opts ...grpc.ServerOption) *grpc.Server {
+	for _, method := range grammarhivev1.GrammarService_ServiceDesc.Methods {
+		mustHaveScopes(grammarhivev1.GrammarService_ServiceDesc.ServiceName, method.MethodName)
+	}
+	for _, stream := range grammarhivev1.GrammarService_ServiceDesc.Streams {
+		mustHaveScopes(grammarhivev1.GrammarService_ServiceDesc.ServiceName, stream.StreamName)
+	}
+
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(guard.Unary),
+		grpc.ChainStreamInterceptor(guard.Stream),
+	)
+	server := grpc.NewServer(opts...)
+	grammarhivev1.RegisterGrammarServiceServer(server, srv)
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(grammarhivev1.GrammarService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+	return server
+}
+
+func mustHaveScopes(service, method string) {
+	if _, ok := methodScopes["/"+service+"/"+method]; !ok {
+		panic(fmt.Sprintf("no scopes defined for method /%s/%s", service, method))
+	}
+}
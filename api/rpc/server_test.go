@@ -0,0 +1,180 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/database/dbtest"
+	"grammarhive-backend/core/grammar"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/ratelimit"
+	"grammarhive-backend/core/services"
+	grammarhivev1 "grammarhive-backend/proto/grammarhive/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// threeLetters is inline grammar content yielding three letters from x, y and z
+var threeLetters = &grammarhivev1.GenerateRequest_Content{
+	Content: "{\n<start>\n<letter> <letter> <letter> ;\n}\n{\n<letter>\nx ;\ny ;\nz ;\n}",
+}
+
+func newTestServer(t *testing.T) (*Server, *database.MongoDB) {
+	t.Helper()
+	db := dbtest.Connect(t)
+	return NewServer(db, services.NewUsageService(db, 0), services.NewAuditService(db), nil, grammar.NewCache(0)), db
+}
+
+// generations returns how many texts have been metered for subject today
+func generations(t *testing.T, db *database.MongoDB, subject string) int64 {
+	t.Helper()
+	now := time.Now()
+	count, err := db.CountGenerations(context.Background(), subject, now, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestGenerateStreamMatchesGenerate(t *testing.T) {
+	srv, db := newTestServer(t)
+	issuer, auth := testIssuer(t)
+	client := grammarhivev1.NewGrammarServiceClient(dialGuarded(t, srv, &Guard{
+		Authenticator: auth,
+		Users:         services.NewUserService(db),
+		Limiter:       ratelimit.NewLimiter(ratelimit.NewMemoryStore(), nil),
+	}))
+
+	ctx := metadata.NewOutgoingContext(context.Background(), mint(t, issuer, "dev|ada", identity.ScopeGenerate))
+	seed := int64(42)
+	req := &grammarhivev1.GenerateRequest{Source: threeLetters, Count: 5, Seed: &seed}
+
+	resp, err := client.Generate(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := client.GenerateStream(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var streamed []string
+	for {
+		text, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text.Seed != seed || text.GrammarId != services.InlineGrammarID {
+			t.Fatalf("text %+v does not carry the seed and inline grammar ID", text)
+		}
+		streamed = append(streamed, text.Text)
+	}
+
+	if len(streamed) != len(resp.Texts) {
+		t.Fatalf("streamed %d texts, Generate returned %d", len(streamed), len(resp.Texts))
+	}
+	for i := range streamed {
+		if streamed[i] != resp.Texts[i] {
+			t.Fatalf("streamed text %d = %q, Generate returned %q", i, streamed[i], resp.Texts[i])
+		}
+	}
+	if got := generations(t, db, "dev|ada"); got != 10 {
+		t.Fatalf("metered %d generations, want 10", got)
+	}
+}
+
+func TestGenerateStreamRejectsBadRequests(t *testing.T) {
+	srv, db := newTestServer(t)
+	issuer, auth := testIssuer(t)
+	client := grammarhivev1.NewGrammarServiceClient(dialGuarded(t, srv, &Guard{
+		Authenticator: auth,
+		Users:         services.NewUserService(db),
+		Limiter:       ratelimit.NewLimiter(ratelimit.NewMemoryStore(), nil),
+	}))
+	ctx := metadata.NewOutgoingContext(context.Background(), mint(t, issuer, "dev|ada", identity.ScopeGenerate))
+
+	for _, req := range []*grammarhivev1.GenerateRequest{
+		{},
+		{Source: threeLetters, Count: maxGenerateStreamCount + 1},
+	} {
+		stream, err := client.GenerateStream(ctx, req)
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("GenerateStream(%v): %v, want InvalidArgument", req, err)
+		}
+	}
+}
+
+// brokenStream accepts a number of texts and then fails as a stream whose client has gone,
+// cancelling the call's context as gRPC does
+type brokenStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	cancel  context.CancelFunc
+	accepts int
+	sent    []string
+}
+
+func (s *brokenStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *brokenStream) Send(text *grammarhivev1.GeneratedText) error {
+	if len(s.sent) == s.accepts {
+		s.cancel()
+		return status.Error(codes.Unavailable, "transport is closing")
+	}
+	s.sent = append(s.sent, text.Text)
+	return nil
+}
+
+func TestGenerateStreamMetersDeliveredTexts(t *testing.T) {
+	srv, db := newTestServer(t)
+
+	ctx, cancel := context.WithCancel(identity.WithIdentity(context.Background(), &identity.Identity{Subject: "dev|ada"}))
+	defer cancel()
+	stream := &brokenStream{ctx: ctx, cancel: cancel, accepts: 3}
+
+	err := srv.GenerateStream(&grammarhivev1.GenerateRequest{Source: threeLetters, Count: 10}, stream)
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("err = %v, want the stream's Unavailable", err)
+	}
+	if len(stream.sent) != 3 {
+		t.Fatalf("sent %d texts, want 3", len(stream.sent))
+	}
+	if got := generations(t, db, "dev|ada"); got != 3 {
+		t.Fatalf("metered %d generations, want the 3 delivered", got)
+	}
+}
+
+func TestGenerateStreamHandsBackUndeliveredQuota(t *testing.T) {
+	db := dbtest.Connect(t)
+	usage := services.NewUsageService(db, 10)
+	srv := NewServer(db, usage, services.NewAuditService(db), nil, grammar.NewCache(0))
+
+	ctx, cancel := context.WithCancel(identity.WithIdentity(context.Background(), &identity.Identity{Subject: "dev|ada"}))
+	defer cancel()
+	stream := &brokenStream{ctx: ctx, cancel: cancel, accepts: 3}
+	srv.GenerateStream(&grammarhivev1.GenerateRequest{Source: threeLetters, Count: 10}, stream)
+
+	// The 3 delivered texts stay reserved and the other 7 are handed back
+	ada := identity.WithIdentity(context.Background(), &identity.Identity{Subject: "dev|ada"})
+	if _, err := usage.ReserveQuota(ada, 7); err != nil {
+		t.Fatalf("reserving the undelivered generations: %v", err)
+	}
+	if _, err := usage.ReserveQuota(ada, 1); !errors.Is(err, services.ErrQuotaExceeded) {
+		t.Fatalf("reserving past the quota: %v, want ErrQuotaExceeded", err)
+	}
+}
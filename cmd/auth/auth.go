@@ -3,14 +3,47 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
+
+	"grammarhive-backend/core/config"
+	"grammarhive-backend/core/devissuer"
 )
 
+const usage = `usage: auth <command> [flags]
+
+commands:
+  token   request a client credentials token from Auth0 (default)
+  mint    sign a token with the local development key (AUTH_MODE=dev)
+  jwks    print the public key set of the local development key`
+
 func main() {
+	command := "token"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "token":
+		requestAuth0Token()
+	case "mint":
+		mintDevToken(args)
+	case "jwks":
+		printDevJWKS()
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func requestAuth0Token() {
 	// Load environment variables for Auth0 configuration
 	clientID := os.Getenv("AUTH0_CLIENT_ID")
 	clientSecret := os.Getenv("AUTH0_CLIENT_SECRET")
@@ -68,7 +101,62 @@ func main() {
 		log.Fatalf("Failed to parse token response: %v", err)
 	}
 
+	printCurl(tokenResponse.AccessToken, "https://backend.grammarhive.org")
+}
+
+func mintDevToken(args []string) {
+	cfg := config.Load()
+
+	flags := flag.NewFlagSet("mint", flag.ExitOnError)
+	subject := flags.String("sub", "dev|local-user", "token subject")
+	username := flags.String("username", "devuser", "value of the username claim")
+	tenant := flags.String("tenant", "", "value of the tenant claim, if TENANT_CLAIM is set")
+	scopes := flags.String("scopes", "grammar:generate grammar:read grammar:write", "space or comma separated scopes")
+	ttl := flags.Duration("ttl", time.Hour, "token lifetime")
+	raw := flags.Bool("raw", false, "print only the token")
+	flags.Parse(args)
+
+	issuer, err := devissuer.New(cfg.DevSigningKeyFile, cfg.AuthIssuer, cfg.Auth0Audience)
+	if err != nil {
+		log.Fatalf("Failed to load development issuer: %v", err)
+	}
+
+	token, err := issuer.Mint(devissuer.TokenOptions{
+		Subject:       *subject,
+		Username:      *username,
+		UsernameClaim: cfg.UsernameClaim,
+		Tenant:        *tenant,
+		TenantClaim:   cfg.TenantClaim,
+		Scopes:        strings.FieldsFunc(*scopes, func(r rune) bool { return r == ' ' || r == ',' }),
+		TTL:           *ttl,
+	})
+	if err != nil {
+		log.Fatalf("Failed to mint token: %v", err)
+	}
+
+	fmt.Println(token)
+	if !*raw {
+		printCurl(token, "http://localhost:8080")
+	}
+}
+
+func printDevJWKS() {
+	cfg := config.Load()
+
+	issuer, err := devissuer.New(cfg.DevSigningKeyFile, cfg.AuthIssuer, cfg.Auth0Audience)
+	if err != nil {
+		log.Fatalf("Failed to load development issuer: %v", err)
+	}
+
+	jwks, err := issuer.JWKSJSON()
+	if err != nil {
+		log.Fatalf("Failed to encode JWKS: %v", err)
+	}
+	fmt.Println(string(jwks))
+}
+
+func printCurl(token, baseURL string) {
 	fmt.Println("\nTest curl command:")
-	fmt.Printf("curl -H 'Authorization: Bearer %s' https://backend.grammarhive.org/api/grammar/generate?grammarId=21342\n", 
-		tokenResponse.AccessToken)
+	fmt.Printf("curl -H 'Authorization: Bearer %s' %s/api/grammar/generate?grammarId=21342\n",
+		token, baseURL)
 }
@@ -17,6 +17,10 @@ func main() {
 		port = "8080"
 	}
 
+	if err := handler.StartupError(); err != nil {
+		log.Fatalf("Startup failed: %s", err)
+	}
+
 	srv := &http.Server{
 		Addr:         ":" + port,
 		Handler:      http.HandlerFunc(handler.Handler),
@@ -45,6 +49,9 @@ func main() {
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
+	if err := handler.Shutdown(shutdownCtx); err != nil {
+		log.Println("Failed to flush and close connections:", err)
+	}
 
 	log.Println("Server exited gracefully")
 }
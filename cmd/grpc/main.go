@@ -0,0 +1,136 @@
+// cmd/grpc/main.go
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	middleware "grammarhive-backend/api/routes/middleware"
+	"grammarhive-backend/api/rpc"
+	"grammarhive-backend/core/config"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/grammar"
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/services"
+	"grammarhive-backend/core/tracing"
+
+	"google.golang.org/grpc"
+)
+
+func main() {
+	cfg := config.Load()
+	slog.SetDefault(logging.New(os.Stdout, cfg.LogLevel))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TracingExporter)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %s", err)
+	}
+
+	dbService, err := database.NewMongoDB(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %s", err)
+	}
+	if err := dbService.EnsureGrammarIndexes(ctx); err != nil {
+		log.Fatalf("Failed to prepare the grammars collection: %s", err)
+	}
+	if err := dbService.EnsureUserIndexes(ctx); err != nil {
+		log.Fatalf("Failed to prepare the users collection: %s", err)
+	}
+
+	authenticator, err := middleware.NewFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up authentication: %s", err)
+	}
+
+	limiter, err := middleware.NewLimiterFromConfig(ctx, cfg, dbService)
+	if err != nil {
+		log.Fatalf("Failed to set up rate limiting: %s", err)
+	}
+
+	proxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Failed to parse trusted proxies: %s", err)
+	}
+
+	usageService := services.NewUsageService(dbService, cfg.MonthlyQuota)
+	auditService := services.NewAuditService(dbService)
+	authenticator.APIKeys = services.NewAPIKeyService(dbService)
+	authenticator.Audit = auditService
+
+	webhookService := services.NewWebhookService(dbService)
+	var dispatcher *services.WebhookDispatcher
+	if cfg.Webhooks.Workers > 0 {
+		dispatcher = services.NewWebhookDispatcher(dbService, cfg.Webhooks.MaxAttempts,
+			time.Duration(cfg.Webhooks.Timeout)*time.Second, cfg.Webhooks.AllowPrivate)
+		dispatcher.Start(cfg.Webhooks.Workers)
+		webhookService.Dispatcher = dispatcher
+	}
+
+	server := rpc.NewGRPCServer(
+		rpc.NewServer(dbService, usageService, auditService, webhookService, grammar.NewCache(cfg.GrammarCacheSize)),
+		&rpc.Guard{
+			Authenticator: authenticator,
+			Users:         services.NewUserService(dbService),
+			Limiter:       limiter,
+			Proxies:       proxies,
+		},
+		grpc.MaxRecvMsgSize(int(cfg.MaxBodyBytes)),
+	)
+
+	listener, err := net.Listen("tcp", cfg.GRPCAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %s", cfg.GRPCAddr, err)
+	}
+
+	go func() {
+		log.Printf("Starting gRPC server on %s", cfg.GRPCAddr)
+		if err := server.Serve(listener); err != nil {
+			log.Fatalf("Server failed: %s", err)
+		}
+	}()
+
+	// Wait for interrupt signal to shutdown the server
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+
+	<-sigint
+	log.Println("Shutting down server...")
+
+	// Let in-flight calls finish, but not forever
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		server.Stop()
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+
+	if dispatcher != nil {
+		if err := dispatcher.Stop(shutdownCtx); err != nil {
+			log.Println("Failed to stop webhook delivery:", err)
+		}
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Println("Failed to flush spans:", err)
+	}
+	if err := dbService.Close(shutdownCtx); err != nil {
+		log.Println("Failed to close database connection:", err)
+	}
+
+	log.Println("Server exited gracefully")
+}
@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -24,7 +25,7 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	db, err := database.NewMongoDB(ctx, cfg.MongoURI)
+	db, err := database.NewMongoDB(ctx, cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
@@ -35,7 +36,12 @@ func main() {
 		log.Fatalf("Failed to fetch grammar: %v", err)
 	}
 
-	if err := db.StoreGrammar(ctx, "21342", "resume", "admin", grammarContent, 1); err != nil {
+	err = db.StoreGrammar(ctx, "21342", "resume", "admin", grammarContent, 0)
+	if errors.Is(err, database.ErrVersionConflict) {
+		log.Println("The grammar is already seeded")
+		return
+	}
+	if err != nil {
 		log.Fatalf("Failed to store grammar: %v", err)
 	}
 
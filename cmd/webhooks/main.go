@@ -0,0 +1,60 @@
+// cmd/webhooks/main.go
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"grammarhive-backend/core/config"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/services"
+)
+
+// Sends webhook deliveries for deployments whose API cannot run background work, such as
+// serverless functions; run it alongside them with WEBHOOK_WORKERS=0 set for the API
+func main() {
+	cfg := config.Load()
+	slog.SetDefault(logging.New(os.Stdout, cfg.LogLevel))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dbService, err := database.NewMongoDB(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %s", err)
+	}
+
+	workers := cfg.Webhooks.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	dispatcher := services.NewWebhookDispatcher(dbService, cfg.Webhooks.MaxAttempts,
+		time.Duration(cfg.Webhooks.Timeout)*time.Second, cfg.Webhooks.AllowPrivate)
+	dispatcher.Start(workers)
+	log.Printf("Delivering webhooks with %d workers", workers)
+
+	// Wait for interrupt signal to shutdown the dispatcher
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+
+	<-sigint
+	log.Println("Shutting down webhook delivery...")
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+
+	if err := dispatcher.Stop(shutdownCtx); err != nil {
+		log.Println("Failed to stop webhook delivery:", err)
+	}
+	if err := dbService.Close(shutdownCtx); err != nil {
+		log.Println("Failed to close database connection:", err)
+	}
+
+	log.Println("Webhook delivery exited gracefully")
+}
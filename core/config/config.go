@@ -3,24 +3,201 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+)
+
+// Sources of the keys used to verify access tokens
+const (
+	AuthModeAuth0    = "auth0"
+	AuthModeJWKSFile = "jwks-file"
+	AuthModeDev      = "dev"
+	AuthModeOIDC     = "oidc"
 )
 
 type Config struct {
-    MongoURI            string
-    ServerAddr          string
-    Auth0Domain         string
-    Auth0ClientID       string
-    Auth0ClientSecret   string
-    Auth0Audience       string
+	MongoURI           string
+	MongoDatabase      string
+	GrammarsCollection string
+	UsersCollection    string
+	TenantClaim        string
+	TenantMode         string
+	UsernameClaim      string
+	ServerAddr         string
+	GRPCAddr           string
+	Auth0Domain        string
+	Auth0ClientID      string
+	Auth0ClientSecret  string
+	Auth0Audience      string
+	AuthMode           string
+	AuthIssuer         string
+	AuthJWKSFile       string
+	AuthIssuersFile    string
+	DevSigningKeyFile  string
+	OAuthAuthorizeURL  string
+	OAuthTokenURL      string
+	OAuthRevokeURL     string
+	OAuthCallbackURL   string
+	OAuthScopes        string
+	LoginRedirectURLs  []string
+	RateLimits         string
+	RateLimitStore     string
+	MonthlyQuota       int64
+	LogLevel           string
+	GrammarCacheSize   int
+	MetricsEnabled     bool
+	MetricsToken       string
+	TracingExporter    string
+	MaxBodyBytes       int64
+	MaxUploadBytes     int64
+	MaxURIBytes        int
+	MaxHeaderBytes     int
+	HSTSMaxAge         int64
+	TrustedProxies     []string
+	ValidateResponses  bool
+	GraphQLMaxDepth    int
+	GraphQLMaxCost     int
+	CORS               CORSConfig
+	Playground         PlaygroundConfig
+	Webhooks           WebhookConfig
+}
+
+// CORSConfig is the cross-origin policy applied to every response
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int64 // seconds
+}
+
+// PlaygroundConfig bounds what each live playground session may use
+type PlaygroundConfig struct {
+	MaxContentBytes int
+	MaxSamples      int
+	MaxSessions     int   // per caller
+	IdleTimeout     int64 // seconds
+	MaxDuration     int64 // seconds
+	Limits          string
+}
+
+// WebhookConfig controls how webhook deliveries are sent
+type WebhookConfig struct {
+	Workers      int // 0 leaves deliveries to a separate dispatcher
+	MaxAttempts  int
+	Timeout      int64 // seconds
+	AllowPrivate bool  // deliver to loopback, private and reserved network addresses
 }
 
 func Load() Config {
-    return Config{
-        MongoURI:          os.Getenv("MONGO_URI"),
-        ServerAddr:        os.Getenv("SERVER_ADDR"),
-        Auth0Domain:       os.Getenv("AUTH0_DOMAIN"),
-        Auth0ClientID:     os.Getenv("AUTH0_CLIENT_ID"),
-        Auth0ClientSecret: os.Getenv("AUTH0_CLIENT_SECRET"),
-        Auth0Audience:     os.Getenv("AUTH0_AUDIENCE"),
-    }
+	domain := os.Getenv("AUTH0_DOMAIN")
+
+	return Config{
+		MongoURI:           os.Getenv("MONGO_URI"),
+		MongoDatabase:      getEnv("MONGO_DATABASE", "resumes-01"),
+		GrammarsCollection: getEnv("MONGO_GRAMMARS_COLLECTION", "grammars"),
+		UsersCollection:    getEnv("MONGO_USERS_COLLECTION", "users"),
+		TenantClaim:        os.Getenv("TENANT_CLAIM"),
+		TenantMode:         os.Getenv("TENANT_MODE"),
+		UsernameClaim:      getEnv("USERNAME_CLAIM", "https://grammarhive.org/username"),
+		ServerAddr:         os.Getenv("SERVER_ADDR"),
+		GRPCAddr:           getEnv("GRPC_ADDR", ":9090"),
+		Auth0Domain:        domain,
+		Auth0ClientID:      os.Getenv("AUTH0_CLIENT_ID"),
+		Auth0ClientSecret:  os.Getenv("AUTH0_CLIENT_SECRET"),
+		Auth0Audience:      os.Getenv("AUTH0_AUDIENCE"),
+		AuthMode:           getEnv("AUTH_MODE", AuthModeAuth0),
+		AuthIssuer:         os.Getenv("AUTH_ISSUER"),
+		AuthJWKSFile:       os.Getenv("AUTH_JWKS_FILE"),
+		AuthIssuersFile:    os.Getenv("AUTH_ISSUERS_FILE"),
+		DevSigningKeyFile:  getEnv("DEV_SIGNING_KEY_FILE", ".dev-signing-key.pem"),
+		OAuthAuthorizeURL:  getEnv("OAUTH_AUTHORIZE_URL", "https://"+domain+"/authorize"),
+		OAuthTokenURL:      getEnv("OAUTH_TOKEN_URL", "https://"+domain+"/oauth/token"),
+		OAuthRevokeURL:     getEnv("OAUTH_REVOKE_URL", "https://"+domain+"/oauth/revoke"),
+		OAuthCallbackURL:   os.Getenv("OAUTH_CALLBACK_URL"),
+		OAuthScopes:        getEnv("OAUTH_SCOPES", "openid profile email offline_access"),
+		LoginRedirectURLs:  getList("LOGIN_REDIRECT_URLS"),
+		RateLimits:         getEnv("RATE_LIMITS", "default=120/m,preauth=600/m:100,/api/grammar/generate=60/m:10,/api/grammar/generateList=20/m:5,/api/v2/generate=20/m:5,/api/graphql=60/m:10,/grammarhive.v1.GrammarService/Generate=20/m:5,/grammarhive.v1.GrammarService/GenerateStream=20/m:5"),
+		RateLimitStore:     getEnv("RATE_LIMIT_STORE", "memory"),
+		MonthlyQuota:       getInt("MONTHLY_GENERATION_QUOTA", 0),
+		LogLevel:           getEnv("LOG_LEVEL", "info"),
+		GrammarCacheSize:   int(getInt("GRAMMAR_CACHE_SIZE", 256)),
+		MetricsEnabled:     getBool("METRICS_ENABLED", false),
+		MetricsToken:       os.Getenv("METRICS_TOKEN"),
+		TracingExporter:    os.Getenv("TRACING_EXPORTER"),
+		MaxBodyBytes:       getInt("MAX_BODY_BYTES", 1<<20),
+		MaxUploadBytes:     getInt("MAX_UPLOAD_BYTES", 10<<20),
+		MaxURIBytes:        int(getInt("MAX_URI_BYTES", 8<<10)),
+		MaxHeaderBytes:     int(getInt("MAX_HEADER_BYTES", 16<<10)),
+		HSTSMaxAge:         getInt("HSTS_MAX_AGE", 63072000),
+		TrustedProxies:     getList("TRUSTED_PROXIES"),
+		ValidateResponses:  getBool("OPENAPI_VALIDATE_RESPONSES", false),
+		GraphQLMaxDepth:    int(getInt("GRAPHQL_MAX_DEPTH", 10)),
+		GraphQLMaxCost:     int(getInt("GRAPHQL_MAX_COMPLEXITY", 1000)),
+		CORS: CORSConfig{
+			AllowedOrigins:   getList("CORS_ALLOWED_ORIGINS"),
+			AllowedMethods:   splitList(getEnv("CORS_ALLOWED_METHODS", "GET,POST,PATCH,DELETE,OPTIONS")),
+			AllowedHeaders:   splitList(getEnv("CORS_ALLOWED_HEADERS", "Content-Type,Authorization,X-API-Key,X-Request-ID,traceparent,tracestate")),
+			ExposedHeaders:   splitList(getEnv("CORS_EXPOSED_HEADERS", "X-Request-ID,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After,Deprecation,Link,X-Generation-Seed")),
+			AllowCredentials: getBool("CORS_ALLOW_CREDENTIALS", false),
+			MaxAge:           getInt("CORS_MAX_AGE", 600),
+		},
+		Playground: PlaygroundConfig{
+			MaxContentBytes: int(getInt("PLAYGROUND_MAX_CONTENT_BYTES", 64<<10)),
+			MaxSamples:      int(getInt("PLAYGROUND_MAX_SAMPLES", 10)),
+			MaxSessions:     int(getInt("PLAYGROUND_MAX_SESSIONS", 5)),
+			IdleTimeout:     getInt("PLAYGROUND_IDLE_TIMEOUT", 300),
+			MaxDuration:     getInt("PLAYGROUND_MAX_DURATION", 3600),
+			Limits:          getEnv("PLAYGROUND_LIMITS", "messages=20/s:40,generations=2/s:5"),
+		},
+		Webhooks: WebhookConfig{
+			Workers:      int(getInt("WEBHOOK_WORKERS", 2)),
+			MaxAttempts:  int(getInt("WEBHOOK_MAX_ATTEMPTS", 8)),
+			Timeout:      getInt("WEBHOOK_TIMEOUT", 10),
+			AllowPrivate: getBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		},
+	}
+}
+
+// getEnv returns the value of the environment variable or the fallback if unset
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getInt parses an integer environment variable, returning fallback if unset or invalid
+func getInt(key string, fallback int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getBool parses a boolean environment variable, returning fallback if unset or invalid
+func getBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getList splits a comma separated environment variable, dropping empty entries
+func getList(key string) []string {
+	return splitList(os.Getenv(key))
+}
+
+// splitList splits a comma separated list, dropping empty entries
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
 }
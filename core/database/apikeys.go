@@ -0,0 +1,91 @@
+// core/database/apikeys.go
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrAPIKeyNotFound is returned when no API key matches the requested ID
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// API keys live in the shared database so they can be resolved before the tenant is known
+func (m *MongoDB) apiKeyCollection() *mongo.Collection {
+	return m.db.Collection("api_keys")
+}
+
+// CreateAPIKey stores a new key; key IDs are unique. The insert is not wrapped in utils.Retry,
+// as repeating it after a lost acknowledgement would fail on the key it had stored
+func (m *MongoDB) CreateAPIKey(ctx context.Context, key *APIKey) error {
+	keys := m.apiKeyCollection()
+	err := m.ensureIndexes(ctx, keys, mongo.IndexModel{
+		Keys:    bson.M{"keyID": 1},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = keys.InsertOne(ctx, key)
+	return err
+}
+
+// FindAPIKey returns the key with the public ID, including revoked and expired keys
+func (m *MongoDB) FindAPIKey(ctx context.Context, keyID string) (*APIKey, error) {
+	var key APIKey
+	err := m.apiKeyCollection().FindOne(ctx, bson.M{"keyID": keyID}).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListAPIKeys returns the keys of owner, newest first
+func (m *MongoDB) ListAPIKeys(ctx context.Context, owner string) ([]APIKey, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := m.apiKeyCollection().Find(ctx, bson.M{"owner": owner}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey marks the key of owner as revoked
+func (m *MongoDB) RevokeAPIKey(ctx context.Context, owner, keyID string) error {
+	res, err := m.apiKeyCollection().UpdateOne(
+		ctx,
+		bson.M{"keyID": keyID, "owner": owner, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey records that the key was just used
+func (m *MongoDB) TouchAPIKey(ctx context.Context, keyID string, at time.Time) error {
+	_, err := m.apiKeyCollection().UpdateOne(
+		ctx,
+		bson.M{"keyID": keyID},
+		bson.M{"$set": bson.M{"last_used_at": at}},
+	)
+	return err
+}
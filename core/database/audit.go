@@ -0,0 +1,102 @@
+// core/database/audit.go
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditFilter selects audit entries; empty fields match everything
+type AuditFilter struct {
+	Owner     string
+	Actor     string
+	Action    string
+	GrammarID string
+	Before    primitive.ObjectID // exclusive cursor, zero for the newest entries
+	Since     time.Time
+	Limit     int64 // zero for no limit
+}
+
+// AppendAudit inserts an audit entry; entries are never updated or deleted
+func (m *MongoDB) AppendAudit(ctx context.Context, entry *AuditEntry) error {
+	audit, err := m.collection(ctx, m.audit)
+	if err != nil {
+		return err
+	}
+
+	_, err = audit.InsertOne(ctx, entry)
+	return err
+}
+
+// FindAudit returns entries matching the filter, newest first
+func (m *MongoDB) FindAudit(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	cursor, err := m.auditCursor(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// EachAudit calls fn for every entry matching the filter, newest first, without loading them all
+func (m *MongoDB) EachAudit(ctx context.Context, filter AuditFilter, fn func(*AuditEntry) error) error {
+	cursor, err := m.auditCursor(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var entry AuditEntry
+		if err := cursor.Decode(&entry); err != nil {
+			return err
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (m *MongoDB) auditCursor(ctx context.Context, filter AuditFilter) (*mongo.Cursor, error) {
+	audit, err := m.collection(ctx, m.audit)
+	if err != nil {
+		return nil, err
+	}
+
+	query := bson.M{}
+	if filter.Owner != "" {
+		query["owner"] = filter.Owner
+	}
+	if filter.Actor != "" {
+		query["actor"] = filter.Actor
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.GrammarID != "" {
+		query["grammarID"] = filter.GrammarID
+	}
+	if !filter.Before.IsZero() {
+		query["_id"] = bson.M{"$lt": filter.Before}
+	}
+	if !filter.Since.IsZero() {
+		query["at"] = bson.M{"$gte": filter.Since}
+	}
+
+	opts := options.Find().SetSort(bson.M{"_id": -1})
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+	return audit.Find(ctx, query, opts)
+}
@@ -0,0 +1,118 @@
+// core/database/authsessions.go
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrSessionNotFound is returned when a login state or session is unknown or expired
+var ErrSessionNotFound = errors.New("session not found")
+
+// Login state and sessions are shared across tenants, as the tenant is only known once a token is issued
+func (m *MongoDB) loginStateCollection() *mongo.Collection {
+	return m.db.Collection("login_states")
+}
+
+func (m *MongoDB) authSessionCollection() *mongo.Collection {
+	return m.db.Collection("auth_sessions")
+}
+
+// SaveLoginState stores a pending login; abandoned logins are removed by a TTL index on expires_at
+func (m *MongoDB) SaveLoginState(ctx context.Context, state *LoginState) error {
+	states := m.loginStateCollection()
+	err := m.ensureIndexes(ctx, states,
+		mongo.IndexModel{Keys: bson.M{"state": 1}, Options: options.Index().SetUnique(true)},
+		mongo.IndexModel{Keys: bson.M{"expires_at": 1}, Options: options.Index().SetExpireAfterSeconds(0)},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = states.InsertOne(ctx, state)
+	return err
+}
+
+// ConsumeLoginState removes and returns the pending login, so each state is used at most once
+func (m *MongoDB) ConsumeLoginState(ctx context.Context, state string) (*LoginState, error) {
+	var result LoginState
+	err := m.loginStateCollection().FindOneAndDelete(ctx, bson.M{"state": state}).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(result.ExpiresAt) {
+		return nil, ErrSessionNotFound
+	}
+	return &result, nil
+}
+
+// SaveAuthSession stores a new session; expired sessions are removed by a TTL index on expires_at
+func (m *MongoDB) SaveAuthSession(ctx context.Context, session *AuthSession) error {
+	sessions := m.authSessionCollection()
+	err := m.ensureIndexes(ctx, sessions,
+		mongo.IndexModel{Keys: bson.M{"sessionHash": 1}, Options: options.Index().SetUnique(true)},
+		mongo.IndexModel{Keys: bson.M{"expires_at": 1}, Options: options.Index().SetExpireAfterSeconds(0)},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = sessions.InsertOne(ctx, session)
+	return err
+}
+
+func (m *MongoDB) GetAuthSession(ctx context.Context, sessionHash string) (*AuthSession, error) {
+	var result AuthSession
+	err := m.authSessionCollection().FindOne(ctx, bson.M{"sessionHash": sessionHash}).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(result.ExpiresAt) {
+		return nil, ErrSessionNotFound
+	}
+	// Sessions from before refresh tokens were sealed hold them in plaintext; they end here
+	if result.SealedRefreshToken == "" {
+		m.authSessionCollection().DeleteOne(ctx, bson.M{"sessionHash": sessionHash})
+		return nil, ErrSessionNotFound
+	}
+	return &result, nil
+}
+
+// UpdateAuthSession stores a rotated refresh token, sealed for the session
+func (m *MongoDB) UpdateAuthSession(ctx context.Context, sessionHash, sealedRefreshToken string) error {
+	res, err := m.authSessionCollection().UpdateOne(
+		ctx,
+		bson.M{"sessionHash": sessionHash},
+		bson.M{"$set": bson.M{"sealed_refresh_token": sealedRefreshToken, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (m *MongoDB) DeleteAuthSession(ctx context.Context, sessionHash string) (*AuthSession, error) {
+	var result AuthSession
+	err := m.authSessionCollection().FindOneAndDelete(ctx, bson.M{"sessionHash": sessionHash}).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
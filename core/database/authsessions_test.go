@@ -0,0 +1,71 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/database/dbtest"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestLoginStatesExpireAndAreUsedOnce(t *testing.T) {
+	db := dbtest.Connect(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	if err := db.SaveLoginState(ctx, &database.LoginState{State: "s1", CreatedAt: now, ExpiresAt: now.Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ConsumeLoginState(ctx, "s1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ConsumeLoginState(ctx, "s1"); !errors.Is(err, database.ErrSessionNotFound) {
+		t.Fatalf("second use: got %v, want ErrSessionNotFound", err)
+	}
+
+	cursor, err := db.Database().Collection("login_states").Indexes().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var indexes []bson.M
+	if err := cursor.All(ctx, &indexes); err != nil {
+		t.Fatal(err)
+	}
+	for _, index := range indexes {
+		if keys, _ := index["key"].(bson.M); keys["expires_at"] != nil && index["expireAfterSeconds"] != nil {
+			return
+		}
+	}
+	t.Fatalf("login_states has no TTL index on expires_at: %v", indexes)
+}
+
+func TestPlaintextSessionsAreEnded(t *testing.T) {
+	db := dbtest.Connect(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	if err := db.SaveAuthSession(ctx, &database.AuthSession{SessionHash: "h1", SealedRefreshToken: "sealed", ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	// As stored before refresh tokens were sealed
+	_, err := db.Database().Collection("auth_sessions").InsertOne(ctx, bson.M{
+		"sessionHash": "h2", "refresh_token": "plaintext", "expires_at": now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.GetAuthSession(ctx, "h1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.GetAuthSession(ctx, "h2"); !errors.Is(err, database.ErrSessionNotFound) {
+		t.Fatalf("plaintext session: got %v, want ErrSessionNotFound", err)
+	}
+	if n, _ := db.Database().Collection("auth_sessions").CountDocuments(ctx, bson.M{"sessionHash": "h2"}); n != 0 {
+		t.Fatal("plaintext session was not deleted")
+	}
+}
@@ -0,0 +1,70 @@
+// core/database/dbtest/dbtest.go
+
+// Package dbtest connects tests to the MongoDB named by MONGO_TEST_URI. Every test gets a
+// database of its own, dropped when it ends; tests are skipped when the variable is unset
+package dbtest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"testing"
+	"time"
+
+	"grammarhive-backend/core/config"
+	"grammarhive-backend/core/database"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// URIVariable names the MongoDB the tests run against
+const URIVariable = "MONGO_TEST_URI"
+
+// Connect returns a connection to a new, empty database
+func Connect(t testing.TB) *database.MongoDB {
+	t.Helper()
+	uri := os.Getenv(URIVariable)
+	if uri == "" {
+		t.Skipf("%s is not set", URIVariable)
+	}
+
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	name := "grammarhive-test-" + hex.EncodeToString(suffix)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	db, err := database.NewMongoDB(ctx, config.Config{
+		MongoURI:           uri,
+		MongoDatabase:      name,
+		GrammarsCollection: "grammars",
+		UsersCollection:    "users",
+	})
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", URIVariable, err)
+	}
+	if err := db.Ping(ctx); err != nil {
+		t.Fatalf("failed to reach %s: %v", URIVariable, err)
+	}
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		drop(ctx, uri, name)
+		db.Close(ctx)
+	})
+	return db
+}
+
+// drop removes the test database with a client of its own, as MongoDB does not expose its database
+func drop(ctx context.Context, uri, name string) {
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return
+	}
+	defer client.Disconnect(ctx)
+	client.Database(name).Drop(ctx)
+}
@@ -0,0 +1,8 @@
+package database
+
+import "go.mongodb.org/mongo-driver/mongo"
+
+// Database exposes the underlying database to tests that inspect documents and indexes directly
+func (m *MongoDB) Database() *mongo.Database {
+	return m.db
+}
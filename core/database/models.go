@@ -7,19 +7,158 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Grammar is one stored version of a grammar. It is served as JSON under its field names,
+// leaving out the storage ID and the owner's subject
 type Grammar struct {
-	ID        primitive.ObjectID  `bson:"_id,omitempty"`
-	Version   int                 `bson:"version"`
-	GrammarID string              `bson:"grammarID"`
-	Name      string              `bson:"name"`
-	Username  string              `bson:"name"`
-	Content   string              `bson:"content"`
-	CreatedAt time.Time           `bson:"created_at"`
-	UpdatedAt time.Time           `bson:"updated_at"`
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	Version   int                `bson:"version"`
+	GrammarID string             `bson:"grammarID"`
+	Name      string             `bson:"name"`
+	Username  string             `bson:"username"`
+	Owner     string             `bson:"owner" json:"-"`
+	Private   bool               `bson:"private"`
+	Content   string             `bson:"content"`
+	CreatedAt time.Time          `bson:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at"`
 }
 
 type User struct {
-	ID          primitive.ObjectID  `bson:"_id,omitempty"`
-	Username    string              `bson:"username"`
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	Subject     string             `bson:"subject" json:"subject"`
+	Username    string             `bson:"username" json:"username"`
+	Email       string             `bson:"email,omitempty" json:"email,omitempty"`
+	DisplayName string             `bson:"display_name,omitempty" json:"displayName,omitempty"`
+	Bio         string             `bson:"bio,omitempty" json:"bio,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"createdAt"`
+	LastSeenAt  time.Time          `bson:"last_seen_at" json:"lastSeenAt"`
 }
 
+// APIKey is a user-managed credential; only the SHA-256 hash of the secret is stored
+type APIKey struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	KeyID      string             `bson:"keyID" json:"keyId"`
+	Hash       string             `bson:"hash" json:"-"`
+	Name       string             `bson:"name" json:"name"`
+	Owner      string             `bson:"owner" json:"-"`
+	Username   string             `bson:"username" json:"username"`
+	Tenant     string             `bson:"tenant,omitempty" json:"-"`
+	Scopes     []string           `bson:"scopes" json:"scopes"`
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"lastUsedAt,omitempty"`
+	ExpiresAt  *time.Time         `bson:"expires_at,omitempty" json:"expiresAt,omitempty"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty" json:"revokedAt,omitempty"`
+}
+
+// LoginState is an authorization request awaiting its callback
+type LoginState struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	State        string             `bson:"state"`
+	CodeVerifier string             `bson:"code_verifier"`
+	RedirectURI  string             `bson:"redirect_uri,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at"`
+	ExpiresAt    time.Time          `bson:"expires_at"`
+}
+
+// AuthSession holds a user's refresh token server-side, keyed by a hash of the session cookie
+// and encrypted with a key derived from the cookie
+type AuthSession struct {
+	ID                 primitive.ObjectID `bson:"_id,omitempty"`
+	SessionHash        string             `bson:"sessionHash"`
+	SealedRefreshToken string             `bson:"sealed_refresh_token"`
+	CreatedAt          time.Time          `bson:"created_at"`
+	UpdatedAt          time.Time          `bson:"updated_at"`
+	ExpiresAt          time.Time          `bson:"expires_at"`
+}
+
+// UsageEvent is a single metered generation request
+type UsageEvent struct {
+	Subject   string
+	GrammarID string
+	Version   int
+	Count     int
+	Bytes     int
+	Latency   time.Duration
+	At        time.Time
+}
+
+// UsageCounter aggregates a subject's usage of one grammar version over a UTC day
+type UsageCounter struct {
+	Day         string `bson:"day" json:"day"`
+	GrammarID   string `bson:"grammarID" json:"grammarId"`
+	Version     int    `bson:"version" json:"version"`
+	Requests    int64  `bson:"requests" json:"requests"`
+	Generations int64  `bson:"generations" json:"generations"`
+	Bytes       int64  `bson:"bytes" json:"bytes"`
+	LatencyMS   int64  `bson:"latency_ms" json:"latencyMs"`
+}
+
+// AuditEntry records who did what to which grammar, API key or webhook, and whether it succeeded
+type AuditEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	At        time.Time          `bson:"at" json:"at"`
+	Actor     string             `bson:"actor,omitempty" json:"actor,omitempty"`
+	Username  string             `bson:"username,omitempty" json:"username,omitempty"`
+	APIKeyID  string             `bson:"apiKeyID,omitempty" json:"apiKeyId,omitempty"`
+	Action    string             `bson:"action" json:"action"`
+	GrammarID string             `bson:"grammarID,omitempty" json:"grammarId,omitempty"`
+	Version   int                `bson:"version,omitempty" json:"version,omitempty"`
+	Owner     string             `bson:"owner,omitempty" json:"owner,omitempty"`
+	Resource  string             `bson:"resource,omitempty" json:"resource,omitempty"` // API key or webhook acted on
+	IP        string             `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent string             `bson:"user_agent,omitempty" json:"userAgent,omitempty"`
+	Result    string             `bson:"result" json:"result"`
+	Detail    string             `bson:"detail,omitempty" json:"detail,omitempty"`
+}
+
+// Webhook is an endpoint notified of events on one grammar, or on every grammar of its
+// owner when GrammarID is empty
+type Webhook struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	WebhookID string             `bson:"webhookID" json:"webhookId"`
+	Owner     string             `bson:"owner" json:"-"`
+	Tenant    string             `bson:"tenant" json:"-"`
+	GrammarID string             `bson:"grammarID" json:"grammarId,omitempty"`
+	URL       string             `bson:"url" json:"url"`
+	Secret    string             `bson:"secret" json:"-"`
+	Events    []string           `bson:"events" json:"events"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// Delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event queued for a webhook, with every attempt to deliver it.
+// Its payload is fixed when it is queued, so retries and redeliveries send the same body
+type WebhookDelivery struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	DeliveryID    string             `bson:"deliveryID" json:"deliveryId"`
+	WebhookID     string             `bson:"webhookID" json:"webhookId"`
+	Owner         string             `bson:"owner" json:"-"`
+	Tenant        string             `bson:"tenant" json:"-"`
+	EventID       string             `bson:"eventID" json:"eventId"`
+	Event         string             `bson:"event" json:"event"`
+	GrammarID     string             `bson:"grammarID" json:"grammarId"`
+	Payload       string             `bson:"payload" json:"-"`
+	Status        string             `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"nextAttemptAt"`
+	LockedUntil   time.Time          `bson:"locked_until" json:"-"`
+	Log           []DeliveryAttempt  `bson:"log" json:"log"`
+	RedeliveryOf  string             `bson:"redeliveryOf,omitempty" json:"redeliveryOf,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"createdAt"`
+	DeliveredAt   *time.Time         `bson:"delivered_at,omitempty" json:"deliveredAt,omitempty"`
+	ExpireAt      time.Time          `bson:"expire_at" json:"-"`
+}
+
+// DeliveryAttempt is the outcome of one request to a webhook
+type DeliveryAttempt struct {
+	At         time.Time `bson:"at" json:"at"`
+	StatusCode int       `bson:"status_code,omitempty" json:"statusCode,omitempty"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	Response   string    `bson:"response,omitempty" json:"response,omitempty"` // the start of the response body
+	DurationMS int64     `bson:"duration_ms" json:"durationMs"`
+}
@@ -3,45 +3,81 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
-	"grammarhive-backend/core/utils"
+	"grammarhive-backend/core/config"
+	"grammarhive-backend/core/logging"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
+// ErrGrammarNotFound is returned when no grammar matches the requested ID
+var ErrGrammarNotFound = errors.New("grammar not found")
+
+// ErrVersionConflict is returned when a grammar was updated by another writer first
+var ErrVersionConflict = errors.New("version conflict, document has been updated by another process")
+
 type MongoDB struct {
-	client    *mongo.Client
-	db        *mongo.Database
-	grammars  *mongo.Collection
+	client     *mongo.Client
+	db         *mongo.Database
+	grammars   *mongo.Collection
+	versions   *mongo.Collection
+	users      *mongo.Collection
+	usage      *mongo.Collection
+	quotas     *mongo.Collection
+	audit      *mongo.Collection
+	tenantMode string
+	// indexed records the collections whose indexes exist, one per tenant for tenant collections
+	indexed sync.Map
 }
 
-func NewMongoDB(ctx context.Context, uri string) (*MongoDB, error) {
+func NewMongoDB(ctx context.Context, cfg config.Config) (*MongoDB, error) {
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
+	switch cfg.TenantMode {
+	case "", TenantModeDatabase, TenantModePrefix:
+	default:
+		return nil, fmt.Errorf("unknown tenant mode: %q", cfg.TenantMode)
+	}
+
 	opts := options.Client().
-		ApplyURI(uri).
+		ApplyURI(cfg.MongoURI).
 		SetMinPoolSize(5).
 		SetMaxPoolSize(20).
 		SetMaxConnIdleTime(30 * time.Second).
-		SetTimeout(5 * time.Second)
+		SetTimeout(5 * time.Second).
+		SetMonitor((&commandMonitor{}).monitor())
 
 	client, connErr := mongo.Connect(ctx, opts)
 	if connErr != nil {
 		return nil, fmt.Errorf("connection error: %w", connErr)
 	}
 
-	db := client.Database("resumes-01")
-	grammars := db.Collection("grammars")
+	db := client.Database(cfg.MongoDatabase)
+	grammars := db.Collection(cfg.GrammarsCollection)
+	versions := db.Collection("grammar_versions")
+	users := db.Collection(cfg.UsersCollection)
+	usage := db.Collection("usage_daily")
+	quotas := db.Collection("usage_quotas")
+	audit := db.Collection("audit_log")
 
 	return &MongoDB{
-		client:    client,
-		db:        db,
-		grammars:  grammars,
+		client:     client,
+		db:         db,
+		grammars:   grammars,
+		versions:   versions,
+		users:      users,
+		usage:      usage,
+		quotas:     quotas,
+		audit:      audit,
+		tenantMode: cfg.TenantMode,
 	}, nil
 }
 
@@ -49,54 +85,314 @@ func (m *MongoDB) Close(ctx context.Context) error {
 	return m.client.Disconnect(ctx)
 }
 
+// IsTimeout reports whether err was caused by a database operation or its context timing out
+func IsTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || mongo.IsTimeout(err)
+}
+
+// Ping checks that the primary is reachable
+func (m *MongoDB) Ping(ctx context.Context) error {
+	return m.client.Ping(ctx, readpref.Primary())
+}
+
+// collection resolves the base collection for the tenant carried by ctx
+func (m *MongoDB) collection(ctx context.Context, base *mongo.Collection) (*mongo.Collection, error) {
+	tenantID := TenantFromContext(ctx)
+	if m.tenantMode == "" || tenantID == "" {
+		return base, nil
+	}
+	if err := ValidateTenantID(tenantID); err != nil {
+		return nil, err
+	}
+
+	switch m.tenantMode {
+	case TenantModeDatabase:
+		return m.client.Database(m.db.Name() + "-" + tenantID).Collection(base.Name()), nil
+	default:
+		return m.db.Collection(tenantID + "_" + base.Name()), nil
+	}
+}
+
 func (m *MongoDB) StoreGrammar(ctx context.Context, grammarID, name, username, content string, version int) error {
-	return utils.Retry(ctx, 3, time.Second, func() error {
-		res, err := m.grammars.UpdateOne(
+	return m.StoreGrammarFor(ctx, &Grammar{
+		GrammarID: grammarID,
+		Name:      name,
+		Username:  username,
+		Content:   content,
+		Version:   version,
+	})
+}
+
+// StoreGrammarFor stores the grammar as the version after g.Version: a new grammar when
+// g.Version is 0, or the replacement of the stored version g.Version. It returns
+// ErrVersionConflict when that version is no longer the latest, or a new grammar's ID is taken.
+// The writes are not wrapped in utils.Retry: the driver already retries them once, safely, and
+// repeating a conditional write whose acknowledgement was lost would report a false conflict
+func (m *MongoDB) StoreGrammarFor(ctx context.Context, g *Grammar) error {
+	grammars, err := m.collection(ctx, m.grammars)
+	if err != nil {
+		return err
+	}
+	if err := m.ensureGrammarIndexes(ctx, grammars); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if g.Version == 0 {
+		_, err = grammars.InsertOne(ctx, bson.M{
+			"grammarID":  g.GrammarID,
+			"name":       g.Name,
+			"username":   g.Username,
+			"owner":      g.Owner,
+			"private":    g.Private,
+			"content":    g.Content,
+			"version":    1,
+			"created_at": now,
+			"updated_at": now,
+		})
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrVersionConflict
+		}
+	} else {
+		var res *mongo.UpdateResult
+		res, err = grammars.UpdateOne(
 			ctx,
-			bson.M{"grammarID": grammarID, "version": version},
+			bson.M{"grammarID": g.GrammarID, "version": g.Version},
 			bson.M{
 				"$set": bson.M{
-					"content":   content,
-					"name":      name,
-					"username":  username,
-					"updatedAt": time.Now(),
+					"content":    g.Content,
+					"name":       g.Name,
+					"username":   g.Username,
+					"owner":      g.Owner,
+					"private":    g.Private,
+					"updated_at": now,
 				},
 				"$inc": bson.M{
 					"version": 1,
 				},
 			},
-			options.Update().SetUpsert(true),
 		)
-
-		if res.MatchedCount == 0 {
-			return fmt.Errorf("version conflict, document has been updated by another process")
+		if err == nil && res.MatchedCount == 0 {
+			return ErrVersionConflict
 		}
-
+	}
+	if err != nil {
 		return err
+	}
+
+	// The history is best effort: the new version is already stored
+	if err := m.recordGrammarVersion(ctx, g, g.Version+1, now); err != nil {
+		logging.FromContext(ctx).Warn("failed to record grammar version", "grammar_id", g.GrammarID, "error", err)
+	}
+	return nil
+}
+
+// EnsureGrammarIndexes creates the grammar indexes of the shared database, so that a
+// collection the unique index cannot be built on stops startup instead of every upload.
+// Tenant collections get theirs on their first write
+func (m *MongoDB) EnsureGrammarIndexes(ctx context.Context) error {
+	return m.ensureGrammarIndexes(ctx, m.grammars)
+}
+
+// ensureGrammarIndexes makes grammar IDs unique in grammars; the collection only keeps the
+// latest version of each grammar. Documents duplicated by earlier releases, which upserted
+// stale versions as new documents, are merged first
+func (m *MongoDB) ensureGrammarIndexes(ctx context.Context, grammars *mongo.Collection) error {
+	if _, ok := m.indexed.Load(grammars.Database().Name() + "." + grammars.Name()); ok {
+		return nil
+	}
+	if err := m.mergeDuplicateGrammars(ctx, grammars); err != nil {
+		return fmt.Errorf("failed to merge duplicate grammars in %s: %w", grammars.Name(), err)
+	}
+	return m.ensureIndexes(ctx, grammars, mongo.IndexModel{
+		Keys:    bson.M{"grammarID": 1},
+		Options: options.Index().SetUnique(true),
 	})
 }
 
+// mergeDuplicateGrammars keeps the latest version of every grammar ID stored more than once,
+// moving the others to the version history unless a snapshot of their version exists
+func (m *MongoDB) mergeDuplicateGrammars(ctx context.Context, grammars *mongo.Collection) error {
+	cursor, err := grammars.Aggregate(ctx, bson.A{
+		bson.M{"$group": bson.M{"_id": "$grammarID", "count": bson.M{"$sum": 1}}},
+		bson.M{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	var duplicated []struct {
+		GrammarID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &duplicated); err != nil {
+		return err
+	}
+
+	versions, err := m.collection(ctx, m.versions)
+	if err != nil {
+		return err
+	}
+	for _, dup := range duplicated {
+		opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}, {Key: "_id", Value: -1}})
+		cursor, err := grammars.Find(ctx, bson.M{"grammarID": dup.GrammarID}, opts)
+		if err != nil {
+			return err
+		}
+		var stored []Grammar
+		if err := cursor.All(ctx, &stored); err != nil {
+			return err
+		}
+
+		for _, g := range stored[1:] {
+			_, err := versions.UpdateOne(
+				ctx,
+				bson.M{"grammarID": g.GrammarID, "version": g.Version},
+				bson.M{"$setOnInsert": GrammarVersion{
+					GrammarID: g.GrammarID,
+					Version:   g.Version,
+					Name:      g.Name,
+					Owner:     g.Owner,
+					Private:   g.Private,
+					Content:   g.Content,
+					CreatedAt: g.UpdatedAt,
+				}},
+				options.Update().SetUpsert(true),
+			)
+			if err != nil {
+				return err
+			}
+			if _, err := grammars.DeleteOne(ctx, bson.M{"_id": g.ID}); err != nil {
+				return err
+			}
+		}
+		logging.FromContext(ctx).Warn("merged duplicate grammar documents",
+			"collection", grammars.Name(), "grammar_id", dup.GrammarID, "removed", len(stored)-1)
+	}
+	return nil
+}
+
+// ensureIndexes creates indexes on coll once per collection and process
+func (m *MongoDB) ensureIndexes(ctx context.Context, coll *mongo.Collection, indexes ...mongo.IndexModel) error {
+	key := coll.Database().Name() + "." + coll.Name()
+	if _, ok := m.indexed.Load(key); ok {
+		return nil
+	}
+
+	if _, err := coll.Indexes().CreateMany(ctx, indexes); err != nil {
+		return fmt.Errorf("failed to create %s indexes: %w", coll.Name(), err)
+	}
+	m.indexed.Store(key, struct{}{})
+	return nil
+}
+
 func (m *MongoDB) GetGrammar(ctx context.Context, grammarID string) (string, error) {
+	grammars, err := m.collection(ctx, m.grammars)
+	if err != nil {
+		return "", err
+	}
+
 	var result struct {
 		Content string `bson:"content"`
 	}
-	err := m.grammars.FindOne(ctx, bson.M{"grammarID": grammarID}).Decode(&result)
+	err = grammars.FindOne(ctx, bson.M{"grammarID": grammarID}).Decode(&result)
 	return result.Content, err
 }
 
-func (m *MongoDB) GetGrammarsByUsername(username string) ([]Grammar, error) {
-	var results []Grammar
-	cursor, err := m.grammars.Find(context.Background(), bson.M{"username": username})
+// GetGrammarByID returns the latest stored grammar document, or ErrGrammarNotFound
+func (m *MongoDB) GetGrammarByID(ctx context.Context, grammarID string) (*Grammar, error) {
+	grammars, err := m.collection(ctx, m.grammars)
+	if err != nil {
+		return nil, err
+	}
+
+	var grammar Grammar
+	opts := options.FindOne().SetSort(bson.M{"version": -1})
+	err = grammars.FindOne(ctx, bson.M{"grammarID": grammarID}, opts).Decode(&grammar)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrGrammarNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &grammar, nil
+}
+
+// GetGrammarsByUsername lists the public grammars of username, plus private ones owned by viewer
+func (m *MongoDB) GetGrammarsByUsername(ctx context.Context, username, viewer string) ([]Grammar, error) {
+	grammars, err := m.collection(ctx, m.grammars)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []Grammar{}
+	cursor, err := grammars.Find(ctx, visibleTo(viewer, bson.M{"username": username}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var grammar Grammar
+		if err := cursor.Decode(&grammar); err != nil {
+			return nil, err
+		}
+		results = append(results, grammar)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// DeleteGrammar removes every stored version of a grammar, or returns ErrGrammarNotFound
+func (m *MongoDB) DeleteGrammar(ctx context.Context, grammarID string) error {
+	grammars, err := m.collection(ctx, m.grammars)
+	if err != nil {
+		return err
+	}
+
+	res, err := grammars.DeleteMany(ctx, bson.M{"grammarID": grammarID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrGrammarNotFound
+	}
+	return m.deleteGrammarVersions(ctx, grammarID)
+}
+
+// GetGrammarsByIDs returns the latest version of each grammar in grammarIDs that viewer may see
+func (m *MongoDB) GetGrammarsByIDs(ctx context.Context, grammarIDs []string, viewer string) ([]Grammar, error) {
+	return m.findGrammars(ctx, visibleTo(viewer, bson.M{"grammarID": bson.M{"$in": grammarIDs}}))
+}
+
+// GetGrammarsByUsernames lists the grammars of every user in usernames that viewer may see
+func (m *MongoDB) GetGrammarsByUsernames(ctx context.Context, usernames []string, viewer string) ([]Grammar, error) {
+	return m.findGrammars(ctx, visibleTo(viewer, bson.M{"username": bson.M{"$in": usernames}}))
+}
+
+// findGrammars returns the grammars matching filter, keeping only the latest version of each
+func (m *MongoDB) findGrammars(ctx context.Context, filter bson.M) ([]Grammar, error) {
+	grammars, err := m.collection(ctx, m.grammars)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
 
-	for cursor.Next(context.Background()) {
+	opts := options.Find().SetSort(bson.D{{Key: "grammarID", Value: 1}, {Key: "version", Value: -1}})
+	cursor, err := grammars.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []Grammar{}
+	for cursor.Next(ctx) {
 		var grammar Grammar
 		if err := cursor.Decode(&grammar); err != nil {
 			return nil, err
 		}
+		if n := len(results); n > 0 && results[n-1].GrammarID == grammar.GrammarID {
+			continue
+		}
 		results = append(results, grammar)
 	}
 	if err := cursor.Err(); err != nil {
@@ -104,3 +400,31 @@ func (m *MongoDB) GetGrammarsByUsername(username string) ([]Grammar, error) {
 	}
 	return results, nil
 }
+
+// CountGrammarsByOwner returns how many public and private grammars owner has stored
+func (m *MongoDB) CountGrammarsByOwner(ctx context.Context, owner string) (public, private int64, err error) {
+	grammars, err := m.collection(ctx, m.grammars)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	public, err = grammars.CountDocuments(ctx, bson.M{"owner": owner, "private": bson.M{"$ne": true}})
+	if err != nil {
+		return 0, 0, err
+	}
+	private, err = grammars.CountDocuments(ctx, bson.M{"owner": owner, "private": true})
+	if err != nil {
+		return 0, 0, err
+	}
+	return public, private, nil
+}
+
+// visibleTo restricts filter to public grammars and private grammars owned by viewer
+func visibleTo(viewer string, filter bson.M) bson.M {
+	visibility := bson.A{bson.M{"private": bson.M{"$ne": true}}}
+	if viewer != "" {
+		visibility = append(visibility, bson.M{"owner": viewer})
+	}
+	filter["$or"] = visibility
+	return filter
+}
@@ -0,0 +1,145 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/database/dbtest"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestStoreGrammarForVersions(t *testing.T) {
+	db := dbtest.Connect(t)
+	ctx := context.Background()
+
+	g := &database.Grammar{GrammarID: "g1", Name: "first", Username: "ada", Owner: "sub", Content: "{\n<start>\na ;\n}"}
+	if err := db.StoreGrammarFor(ctx, g); err != nil {
+		t.Fatalf("storing a new grammar: %v", err)
+	}
+	if err := db.StoreGrammarFor(ctx, g); !errors.Is(err, database.ErrVersionConflict) {
+		t.Fatalf("storing a new grammar under a taken ID: got %v, want ErrVersionConflict", err)
+	}
+
+	stored, err := db.GetGrammarByID(ctx, "g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Version != 1 {
+		t.Fatalf("version = %d, want 1", stored.Version)
+	}
+
+	stored.Name = "second"
+	if err := db.StoreGrammarFor(ctx, stored); err != nil {
+		t.Fatalf("updating the latest version: %v", err)
+	}
+	if err := db.StoreGrammarFor(ctx, stored); !errors.Is(err, database.ErrVersionConflict) {
+		t.Fatalf("updating a replaced version: got %v, want ErrVersionConflict", err)
+	}
+
+	latest, err := db.GetGrammarByID(ctx, "g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Version != 2 || latest.Name != "second" {
+		t.Fatalf("latest = version %d %q, want version 2 \"second\"", latest.Version, latest.Name)
+	}
+}
+
+func TestStoreGrammarForConcurrentUpdates(t *testing.T) {
+	db := dbtest.Connect(t)
+	ctx := context.Background()
+
+	if err := db.StoreGrammarFor(ctx, &database.Grammar{GrammarID: "g1", Owner: "sub"}); err != nil {
+		t.Fatal(err)
+	}
+
+	const writers = 8
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		stored    int
+		conflicts int
+	)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := db.StoreGrammarFor(ctx, &database.Grammar{GrammarID: "g1", Owner: "sub", Version: 1})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				stored++
+			case errors.Is(err, database.ErrVersionConflict):
+				conflicts++
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if stored != 1 || conflicts != writers-1 {
+		t.Fatalf("%d writes stored and %d conflicted, want 1 and %d", stored, conflicts, writers-1)
+	}
+	grammars, err := db.GetGrammarsByIDs(ctx, []string{"g1"}, "sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, private, err := db.CountGrammarsByOwner(ctx, "sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grammars) != 1 || grammars[0].Version != 2 || public+private != 1 {
+		t.Fatalf("stored %d documents, latest %+v; want one document at version 2", public+private, grammars)
+	}
+}
+
+func TestDuplicateGrammarsAreMergedBeforeIndexing(t *testing.T) {
+	db := dbtest.Connect(t)
+	ctx := context.Background()
+
+	// As stored by releases that upserted a stale version as a new document
+	grammars := db.Database().Collection("grammars")
+	for _, doc := range []bson.M{
+		{"grammarID": "g1", "version": 1, "name": "first", "owner": "sub", "content": "one"},
+		{"grammarID": "g1", "version": 3, "name": "third", "owner": "sub", "content": "three"},
+		{"grammarID": "g1", "version": 2, "name": "second", "owner": "sub", "content": "two"},
+		{"grammarID": "g2", "version": 1, "name": "other", "owner": "sub", "content": "other"},
+	} {
+		if _, err := grammars.InsertOne(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := db.EnsureGrammarIndexes(ctx); err != nil {
+		t.Fatalf("creating the indexes over duplicates: %v", err)
+	}
+
+	if count, err := grammars.CountDocuments(ctx, bson.M{"grammarID": "g1"}); err != nil || count != 1 {
+		t.Fatalf("%d documents for g1 (%v), want 1", count, err)
+	}
+	latest, err := db.GetGrammarByID(ctx, "g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Version != 3 || latest.Content != "three" {
+		t.Fatalf("kept version %d %q, want version 3", latest.Version, latest.Content)
+	}
+
+	versions, err := db.GetGrammarVersions(ctx, []string{"g1"}, "sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].Version != 2 || versions[1].Version != 1 || versions[1].Content != "one" {
+		t.Fatalf("history = %+v, want versions 2 and 1", versions)
+	}
+
+	if err := db.StoreGrammarFor(ctx, &database.Grammar{GrammarID: "g1", Owner: "sub"}); !errors.Is(err, database.ErrVersionConflict) {
+		t.Fatalf("storing a new grammar under g1: got %v, want ErrVersionConflict from the unique index", err)
+	}
+}
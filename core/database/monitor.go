@@ -0,0 +1,63 @@
+// core/database/monitor.go
+package database
+
+import (
+	"context"
+	"sync"
+
+	"grammarhive-backend/core/metrics"
+	"grammarhive-backend/core/tracing"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = tracing.Tracer("grammarhive-backend/core/database")
+
+// commandMonitor traces every command sent to MongoDB and observes its latency
+type commandMonitor struct {
+	spans sync.Map // request ID -> trace.Span
+}
+
+func (c *commandMonitor) monitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started:   c.started,
+		Succeeded: c.succeeded,
+		Failed:    c.failed,
+	}
+}
+
+func (c *commandMonitor) started(ctx context.Context, e *event.CommandStartedEvent) {
+	_, span := tracer.Start(ctx, "mongodb."+e.CommandName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemMongoDB,
+			semconv.DBNamespace(e.DatabaseName),
+			semconv.DBOperationName(e.CommandName),
+		),
+	)
+	// The first element of a command document names the collection it targets
+	if elem, err := e.Command.IndexErr(0); err == nil {
+		if collection, ok := elem.Value().StringValueOK(); ok {
+			span.SetAttributes(semconv.DBCollectionName(collection))
+		}
+	}
+	c.spans.Store(e.RequestID, span)
+}
+
+func (c *commandMonitor) succeeded(_ context.Context, e *event.CommandSucceededEvent) {
+	metrics.DBOperationDuration.WithLabelValues(e.CommandName, "success").Observe(e.Duration.Seconds())
+	if span, ok := c.spans.LoadAndDelete(e.RequestID); ok {
+		span.(trace.Span).End()
+	}
+}
+
+func (c *commandMonitor) failed(_ context.Context, e *event.CommandFailedEvent) {
+	metrics.DBOperationDuration.WithLabelValues(e.CommandName, "error").Observe(e.Duration.Seconds())
+	if span, ok := c.spans.LoadAndDelete(e.RequestID); ok {
+		span.(trace.Span).SetStatus(codes.Error, e.Failure)
+		span.(trace.Span).End()
+	}
+}
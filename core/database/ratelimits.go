@@ -0,0 +1,80 @@
+// core/database/ratelimits.go
+package database
+
+import (
+	"context"
+	"time"
+
+	"grammarhive-backend/core/ratelimit"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RateLimitStore keeps token buckets in Mongo so limits hold across instances
+type RateLimitStore struct {
+	buckets *mongo.Collection
+}
+
+// RateLimits returns a rate limit store in the shared database; expired buckets
+// are removed by a TTL index on expire_at
+func (m *MongoDB) RateLimits(ctx context.Context) (*RateLimitStore, error) {
+	buckets := m.db.Collection("rate_limits")
+	_, err := buckets.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expire_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RateLimitStore{buckets: buckets}, nil
+}
+
+// Take refills and decrements the bucket in a single atomic pipeline update
+func (s *RateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	burst := float64(limit.Burst)
+	ratePerMilli := limit.Rate() / 1000
+	fullAfter := time.Duration(burst / limit.Rate() * float64(time.Second))
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"tokens": bson.M{"$min": bson.A{
+				burst,
+				bson.M{"$add": bson.A{
+					bson.M{"$ifNull": bson.A{"$tokens", burst}},
+					bson.M{"$multiply": bson.A{
+						bson.M{"$subtract": bson.A{now, bson.M{"$ifNull": bson.A{"$updated_at", now}}}},
+						ratePerMilli,
+					}},
+				}},
+			}},
+			"updated_at": now,
+			"expire_at":  now.Add(fullAfter),
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"allowed": bson.M{"$gte": bson.A{"$tokens", 1}},
+			"tokens": bson.M{"$cond": bson.A{
+				bson.M{"$gte": bson.A{"$tokens", 1}},
+				bson.M{"$subtract": bson.A{"$tokens", 1}},
+				"$tokens",
+			}},
+		}}},
+	}
+
+	var state struct {
+		Tokens  float64 `bson:"tokens"`
+		Allowed bool    `bson:"allowed"`
+	}
+	err := s.buckets.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": key},
+		pipeline,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&state)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	return ratelimit.NewResult(limit, state.Tokens, state.Allowed), nil
+}
@@ -0,0 +1,38 @@
+// core/database/tenant.go
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+const (
+	// TenantModeDatabase stores each tenant in its own database
+	TenantModeDatabase = "database"
+	// TenantModePrefix stores each tenant in prefixed collections of the shared database
+	TenantModePrefix = "prefix"
+)
+
+type tenantKey struct{}
+
+var tenantPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,38}$`)
+
+// WithTenant returns a copy of ctx scoped to the given tenant
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant the context is scoped to, if any
+func TenantFromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantKey{}).(string)
+	return tenantID
+}
+
+// ValidateTenantID checks that a tenant ID is safe to use in database and collection names
+func ValidateTenantID(tenantID string) error {
+	if !tenantPattern.MatchString(tenantID) {
+		return fmt.Errorf("invalid tenant id: %q", tenantID)
+	}
+	return nil
+}
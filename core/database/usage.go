@@ -0,0 +1,173 @@
+// core/database/usage.go
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UsageDayFormat is the layout of the day key of daily usage counters
+const UsageDayFormat = "2006-01-02"
+
+// usageMonthFormat is the layout of the month key of quota reservations
+const usageMonthFormat = "2006-01"
+
+// RecordUsage adds a usage event to the caller's daily counter for the grammar
+func (m *MongoDB) RecordUsage(ctx context.Context, event *UsageEvent) error {
+	usage, err := m.collection(ctx, m.usage)
+	if err != nil {
+		return err
+	}
+
+	_, err = usage.UpdateOne(
+		ctx,
+		bson.M{
+			"subject":   event.Subject,
+			"day":       event.At.UTC().Format(UsageDayFormat),
+			"grammarID": event.GrammarID,
+			"version":   event.Version,
+		},
+		bson.M{
+			"$inc": bson.M{
+				"requests":    1,
+				"generations": event.Count,
+				"bytes":       event.Bytes,
+				"latency_ms":  event.Latency.Milliseconds(),
+			},
+			"$set": bson.M{"updated_at": event.At},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// GetUsage returns the subject's daily counters for days in [from, to], oldest first
+func (m *MongoDB) GetUsage(ctx context.Context, subject string, from, to time.Time) ([]UsageCounter, error) {
+	usage, err := m.collection(ctx, m.usage)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"subject": subject,
+		"day": bson.M{
+			"$gte": from.UTC().Format(UsageDayFormat),
+			"$lte": to.UTC().Format(UsageDayFormat),
+		},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "day", Value: 1}, {Key: "grammarID", Value: 1}})
+
+	cursor, err := usage.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counters := []UsageCounter{}
+	if err := cursor.All(ctx, &counters); err != nil {
+		return nil, err
+	}
+	return counters, nil
+}
+
+// CountGenerations sums the subject's generations for days in [from, to]
+func (m *MongoDB) CountGenerations(ctx context.Context, subject string, from, to time.Time) (int64, error) {
+	usage, err := m.collection(ctx, m.usage)
+	if err != nil {
+		return 0, err
+	}
+
+	cursor, err := usage.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{
+			"subject": subject,
+			"day": bson.M{
+				"$gte": from.UTC().Format(UsageDayFormat),
+				"$lte": to.UTC().Format(UsageDayFormat),
+			},
+		}},
+		bson.M{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": "$generations"}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var totals []struct {
+		Total int64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return 0, err
+	}
+	if len(totals) == 0 {
+		return 0, nil
+	}
+	return totals[0].Total, nil
+}
+
+// ReserveGenerations takes count generations from the subject's allowance of limit for the UTC
+// month starting at month, and reports false when they do not fit. The check and the update
+// are one conditional write, so concurrent reservations cannot overdraw the allowance
+func (m *MongoDB) ReserveGenerations(ctx context.Context, subject string, month time.Time, count, limit int64) (bool, error) {
+	quotas, err := m.collection(ctx, m.quotas)
+	if err != nil {
+		return false, err
+	}
+	err = m.ensureIndexes(ctx, quotas, mongo.IndexModel{
+		Keys:    bson.D{{Key: "subject", Value: 1}, {Key: "month", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	key := bson.M{"subject": subject, "month": month.UTC().Format(usageMonthFormat)}
+	reserve := func() (bool, error) {
+		res, err := quotas.UpdateOne(
+			ctx,
+			bson.M{"subject": key["subject"], "month": key["month"], "reserved": bson.M{"$lte": limit - count}},
+			bson.M{"$inc": bson.M{"reserved": count}},
+		)
+		if err != nil {
+			return false, err
+		}
+		return res.MatchedCount == 1, nil
+	}
+
+	if ok, err := reserve(); ok || err != nil {
+		return ok, err
+	}
+	if exists, err := quotas.CountDocuments(ctx, key); err != nil || exists > 0 {
+		return false, err
+	}
+
+	// The month's first reservation starts from the generations metered so far
+	used, err := m.CountGenerations(ctx, subject, month, month.AddDate(0, 1, -1))
+	if err != nil {
+		return false, err
+	}
+	_, err = quotas.InsertOne(ctx, bson.M{"subject": key["subject"], "month": key["month"], "reserved": used})
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return false, err
+	}
+	return reserve()
+}
+
+// ReleaseGenerations returns count reserved generations to the subject's allowance for the
+// UTC month starting at month
+func (m *MongoDB) ReleaseGenerations(ctx context.Context, subject string, month time.Time, count int64) error {
+	quotas, err := m.collection(ctx, m.quotas)
+	if err != nil {
+		return err
+	}
+
+	_, err = quotas.UpdateOne(
+		ctx,
+		bson.M{"subject": subject, "month": month.UTC().Format(usageMonthFormat)},
+		bson.M{"$inc": bson.M{"reserved": -count}},
+	)
+	return err
+}
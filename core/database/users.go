@@ -0,0 +1,217 @@
+// core/database/users.go
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"grammarhive-backend/core/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrUserNotFound is returned when no user record exists for the subject
+var ErrUserNotFound = errors.New("user not found")
+
+// UpsertUser creates the user record on first sight and refreshes its claims and last-seen time
+func (m *MongoDB) UpsertUser(ctx context.Context, subject, username, email string, seenAt time.Time) error {
+	users, err := m.collection(ctx, m.users)
+	if err != nil {
+		return err
+	}
+	if err := m.ensureUserIndexes(ctx, users); err != nil {
+		return err
+	}
+
+	set := bson.M{"last_seen_at": seenAt}
+	if username != "" {
+		set["username"] = username
+	}
+	if email != "" {
+		set["email"] = email
+	}
+
+	upsert := func() error {
+		_, err := users.UpdateOne(
+			ctx,
+			bson.M{"subject": subject},
+			bson.M{
+				"$set":         set,
+				"$setOnInsert": bson.M{"created_at": seenAt},
+			},
+			options.Update().SetUpsert(true),
+		)
+		return err
+	}
+	// A concurrent first sight of the same subject may insert between our match and insert;
+	// the record exists then, and updating it again cannot conflict
+	if err := upsert(); !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	return upsert()
+}
+
+// EnsureUserIndexes creates the user indexes of the shared database at startup
+func (m *MongoDB) EnsureUserIndexes(ctx context.Context) error {
+	return m.ensureUserIndexes(ctx, m.users)
+}
+
+// ensureUserIndexes makes subjects unique in users. Records duplicated by earlier releases,
+// whose concurrent first sights of a subject could both insert, are merged first
+func (m *MongoDB) ensureUserIndexes(ctx context.Context, users *mongo.Collection) error {
+	if _, ok := m.indexed.Load(users.Database().Name() + "." + users.Name()); ok {
+		return nil
+	}
+	if err := mergeDuplicateUsers(ctx, users); err != nil {
+		return fmt.Errorf("failed to merge duplicate users in %s: %w", users.Name(), err)
+	}
+	return m.ensureIndexes(ctx, users, mongo.IndexModel{
+		Keys:    bson.M{"subject": 1},
+		Options: options.Index().SetUnique(true),
+	})
+}
+
+// mergeDuplicateUsers keeps the first record of every subject stored more than once, taking
+// the latest last-seen time and any profile fields it lacks from the others
+func mergeDuplicateUsers(ctx context.Context, users *mongo.Collection) error {
+	cursor, err := users.Aggregate(ctx, bson.A{
+		bson.M{"$group": bson.M{"_id": "$subject", "count": bson.M{"$sum": 1}}},
+		bson.M{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	var duplicated []struct {
+		Subject string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &duplicated); err != nil {
+		return err
+	}
+
+	for _, dup := range duplicated {
+		opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+		cursor, err := users.Find(ctx, bson.M{"subject": dup.Subject}, opts)
+		if err != nil {
+			return err
+		}
+		var stored []User
+		if err := cursor.All(ctx, &stored); err != nil {
+			return err
+		}
+
+		kept := stored[0]
+		removed := make([]primitive.ObjectID, 0, len(stored)-1)
+		for _, u := range stored[1:] {
+			if u.LastSeenAt.After(kept.LastSeenAt) {
+				kept.LastSeenAt = u.LastSeenAt
+			}
+			if kept.Username == "" {
+				kept.Username = u.Username
+			}
+			if kept.Email == "" {
+				kept.Email = u.Email
+			}
+			if kept.DisplayName == "" {
+				kept.DisplayName = u.DisplayName
+			}
+			if kept.Bio == "" {
+				kept.Bio = u.Bio
+			}
+			removed = append(removed, u.ID)
+		}
+
+		if _, err := users.ReplaceOne(ctx, bson.M{"_id": kept.ID}, kept); err != nil {
+			return err
+		}
+		if _, err := users.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": removed}}); err != nil {
+			return err
+		}
+		logging.FromContext(ctx).Warn("merged duplicate user records",
+			"collection", users.Name(), "subject", dup.Subject, "removed", len(removed))
+	}
+	return nil
+}
+
+func (m *MongoDB) GetUser(ctx context.Context, subject string) (*User, error) {
+	users, err := m.collection(ctx, m.users)
+	if err != nil {
+		return nil, err
+	}
+
+	var user User
+	err = users.FindOne(ctx, bson.M{"subject": subject}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUserProfile sets the editable profile fields that are non-nil
+func (m *MongoDB) UpdateUserProfile(ctx context.Context, subject string, displayName, bio *string) (*User, error) {
+	users, err := m.collection(ctx, m.users)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := bson.M{}
+	if displayName != nil {
+		fields["display_name"] = *displayName
+	}
+	if bio != nil {
+		fields["bio"] = *bio
+	}
+	if len(fields) == 0 {
+		return m.GetUser(ctx, subject)
+	}
+
+	var user User
+	err = users.FindOneAndUpdate(
+		ctx,
+		bson.M{"subject": subject},
+		bson.M{"$set": fields},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUsersBySubjects returns the user records of every subject in subjects that has one
+func (m *MongoDB) GetUsersBySubjects(ctx context.Context, subjects []string) ([]User, error) {
+	return m.findUsers(ctx, bson.M{"subject": bson.M{"$in": subjects}})
+}
+
+// GetUsersByUsernames returns the user records of every username in usernames that has one
+func (m *MongoDB) GetUsersByUsernames(ctx context.Context, usernames []string) ([]User, error) {
+	return m.findUsers(ctx, bson.M{"username": bson.M{"$in": usernames}})
+}
+
+func (m *MongoDB) findUsers(ctx context.Context, filter bson.M) ([]User, error) {
+	users, err := m.collection(ctx, m.users)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := users.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []User{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,73 @@
+package database_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"grammarhive-backend/core/database/dbtest"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUpsertUserKeepsOneRecordPerSubject(t *testing.T) {
+	db := dbtest.Connect(t)
+	ctx := context.Background()
+	seen := time.Now().UTC().Truncate(time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := db.UpsertUser(ctx, "auth0|ada", "ada", "", seen); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	count, err := db.Database().Collection("users").CountDocuments(ctx, bson.M{"subject": "auth0|ada"})
+	if err != nil || count != 1 {
+		t.Fatalf("%d records for auth0|ada (%v), want 1", count, err)
+	}
+}
+
+func TestDuplicateUsersAreMergedBeforeIndexing(t *testing.T) {
+	db := dbtest.Connect(t)
+	ctx := context.Background()
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := first.Add(time.Hour)
+
+	// As stored by releases without a unique subject index
+	users := db.Database().Collection("users")
+	for _, doc := range []bson.M{
+		{"subject": "auth0|ada", "username": "ada", "display_name": "Ada", "created_at": first, "last_seen_at": first},
+		{"subject": "auth0|ada", "username": "ada", "bio": "Countess", "created_at": first.Add(time.Second), "last_seen_at": later},
+		{"subject": "auth0|bob", "username": "bob", "created_at": first, "last_seen_at": first},
+	} {
+		if _, err := users.InsertOne(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := db.EnsureUserIndexes(ctx); err != nil {
+		t.Fatalf("creating the indexes over duplicates: %v", err)
+	}
+
+	if count, err := users.CountDocuments(ctx, bson.M{"subject": "auth0|ada"}); err != nil || count != 1 {
+		t.Fatalf("%d records for auth0|ada (%v), want 1", count, err)
+	}
+	user, err := db.GetUser(ctx, "auth0|ada")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.CreatedAt.Equal(first) || !user.LastSeenAt.Equal(later) || user.DisplayName != "Ada" || user.Bio != "Countess" {
+		t.Fatalf("merged user = %+v, want the first record with the latest sighting and both profile fields", user)
+	}
+
+	if _, err := users.InsertOne(ctx, bson.M{"subject": "auth0|bob"}); err == nil {
+		t.Fatal("inserted a second record for auth0|bob, want the unique index to refuse it")
+	}
+}
@@ -0,0 +1,80 @@
+// core/database/versions.go
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GrammarVersion is a snapshot of a grammar as it was stored at one version; the grammars
+// collection only keeps the latest
+type GrammarVersion struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	GrammarID string             `bson:"grammarID"`
+	Version   int                `bson:"version"`
+	Name      string             `bson:"name"`
+	Owner     string             `bson:"owner"`
+	Private   bool               `bson:"private"`
+	Content   string             `bson:"content"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// recordGrammarVersion snapshots g as stored at version
+func (m *MongoDB) recordGrammarVersion(ctx context.Context, g *Grammar, version int, at time.Time) error {
+	versions, err := m.collection(ctx, m.versions)
+	if err != nil {
+		return err
+	}
+
+	_, err = versions.ReplaceOne(
+		ctx,
+		bson.M{"grammarID": g.GrammarID, "version": version},
+		GrammarVersion{
+			GrammarID: g.GrammarID,
+			Version:   version,
+			Name:      g.Name,
+			Owner:     g.Owner,
+			Private:   g.Private,
+			Content:   g.Content,
+			CreatedAt: at,
+		},
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+// GetGrammarVersions returns the stored versions of every grammar in grammarIDs, newest first.
+// Snapshots taken while a grammar was private are left out unless viewer owns it
+func (m *MongoDB) GetGrammarVersions(ctx context.Context, grammarIDs []string, viewer string) ([]GrammarVersion, error) {
+	versions, err := m.collection(ctx, m.versions)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "grammarID", Value: 1}, {Key: "version", Value: -1}})
+	cursor, err := versions.Find(ctx, visibleTo(viewer, bson.M{"grammarID": bson.M{"$in": grammarIDs}}), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []GrammarVersion{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// deleteGrammarVersions removes every snapshot of a grammar
+func (m *MongoDB) deleteGrammarVersions(ctx context.Context, grammarID string) error {
+	versions, err := m.collection(ctx, m.versions)
+	if err != nil {
+		return err
+	}
+
+	_, err = versions.DeleteMany(ctx, bson.M{"grammarID": grammarID})
+	return err
+}
@@ -0,0 +1,218 @@
+// core/database/webhooks.go
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrWebhookNotFound is returned when no webhook matches the requested ID
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// ErrDeliveryNotFound is returned when no webhook delivery matches the requested ID
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+// maxDeliveryLog caps the attempts kept on a delivery
+const maxDeliveryLog = 20
+
+// Webhooks and their deliveries live in the shared database, tagged with their tenant, so
+// the dispatcher can drain every tenant's queue without a request to scope it
+func (m *MongoDB) webhookCollection() *mongo.Collection {
+	return m.db.Collection("webhooks")
+}
+
+func (m *MongoDB) deliveryCollection() *mongo.Collection {
+	return m.db.Collection("webhook_deliveries")
+}
+
+// EnsureWebhookIndexes creates the indexes the dispatcher and the delivery log query by;
+// deliveries are removed by a TTL index on expire_at
+func (m *MongoDB) EnsureWebhookIndexes(ctx context.Context) error {
+	_, err := m.webhookCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"webhookID": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "tenant", Value: 1}, {Key: "owner", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = m.deliveryCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"deliveryID": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "webhookID", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.M{"expire_at": 1}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+func (m *MongoDB) CreateWebhook(ctx context.Context, webhook *Webhook) error {
+	_, err := m.webhookCollection().InsertOne(ctx, webhook)
+	return err
+}
+
+// GetWebhook returns a webhook by ID; the dispatcher reads it afresh for every attempt
+func (m *MongoDB) GetWebhook(ctx context.Context, webhookID string) (*Webhook, error) {
+	var webhook Webhook
+	err := m.webhookCollection().FindOne(ctx, bson.M{"webhookID": webhookID}).Decode(&webhook)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks returns the webhooks of owner in tenant, newest first
+func (m *MongoDB) ListWebhooks(ctx context.Context, tenant, owner string) ([]Webhook, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := m.webhookCollection().Find(ctx, bson.M{"tenant": tenant, "owner": owner}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// FindWebhooksFor returns the webhooks of owner in tenant subscribed to event on grammarID,
+// including those subscribed to every grammar
+func (m *MongoDB) FindWebhooksFor(ctx context.Context, tenant, owner, grammarID, event string) ([]Webhook, error) {
+	cursor, err := m.webhookCollection().Find(ctx, bson.M{
+		"tenant":    tenant,
+		"owner":     owner,
+		"grammarID": bson.M{"$in": bson.A{"", grammarID}},
+		"events":    event,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook removes a webhook of owner; deliveries still queued for it fail when they are attempted
+func (m *MongoDB) DeleteWebhook(ctx context.Context, tenant, owner, webhookID string) error {
+	res, err := m.webhookCollection().DeleteOne(ctx, bson.M{"tenant": tenant, "owner": owner, "webhookID": webhookID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// QueueDeliveries adds deliveries to the queue
+func (m *MongoDB) QueueDeliveries(ctx context.Context, deliveries []WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(deliveries))
+	for i := range deliveries {
+		docs[i] = deliveries[i]
+	}
+	_, err := m.deliveryCollection().InsertMany(ctx, docs)
+	return err
+}
+
+// ClaimDelivery leases the pending delivery that has been due longest until now+lease, so
+// no other dispatcher attempts it meanwhile; it returns nil when nothing is due. A
+// dispatcher that dies mid-attempt leaves the lease to expire and the delivery to be retried
+func (m *MongoDB) ClaimDelivery(ctx context.Context, now time.Time, lease time.Duration) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := m.deliveryCollection().FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"status":          DeliveryPending,
+			"next_attempt_at": bson.M{"$lte": now},
+			"locked_until":    bson.M{"$lte": now},
+		},
+		bson.M{"$set": bson.M{"locked_until": now.Add(lease)}},
+		options.FindOneAndUpdate().
+			SetSort(bson.M{"next_attempt_at": 1}).
+			SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// RecordDeliveryAttempt logs an attempt and releases the delivery's lease. status is
+// DeliveryPending with the time of the next attempt for a retry, or a final state
+func (m *MongoDB) RecordDeliveryAttempt(ctx context.Context, deliveryID string, attempt DeliveryAttempt, status string, next time.Time) error {
+	set := bson.M{
+		"status":          status,
+		"next_attempt_at": next,
+		"locked_until":    time.Time{},
+	}
+	if status == DeliverySucceeded {
+		set["delivered_at"] = attempt.At
+	}
+
+	_, err := m.deliveryCollection().UpdateOne(
+		ctx,
+		bson.M{"deliveryID": deliveryID},
+		bson.M{
+			"$set": set,
+			"$inc": bson.M{"attempts": 1},
+			"$push": bson.M{"log": bson.M{
+				"$each":  bson.A{attempt},
+				"$slice": -maxDeliveryLog,
+			}},
+		},
+	)
+	return err
+}
+
+// ListDeliveries returns up to limit deliveries of one of owner's webhooks, newest first
+func (m *MongoDB) ListDeliveries(ctx context.Context, tenant, owner, webhookID string, limit int64) ([]WebhookDelivery, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(limit)
+	cursor, err := m.deliveryCollection().Find(ctx, bson.M{"tenant": tenant, "owner": owner, "webhookID": webhookID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// GetDelivery returns one delivery of one of owner's webhooks
+func (m *MongoDB) GetDelivery(ctx context.Context, tenant, owner, webhookID, deliveryID string) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := m.deliveryCollection().FindOne(ctx, bson.M{
+		"tenant":     tenant,
+		"owner":      owner,
+		"webhookID":  webhookID,
+		"deliveryID": deliveryID,
+	}).Decode(&delivery)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
@@ -0,0 +1,58 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/database/dbtest"
+)
+
+func TestClaimedDeliveriesAreLeased(t *testing.T) {
+	db := dbtest.Connect(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	if err := db.QueueDeliveries(ctx, []database.WebhookDelivery{
+		{DeliveryID: "d1", WebhookID: "w1", Status: database.DeliveryPending, NextAttemptAt: now.Add(-time.Minute), Log: []database.DeliveryAttempt{}},
+		{DeliveryID: "d2", WebhookID: "w1", Status: database.DeliveryPending, NextAttemptAt: now.Add(time.Hour), Log: []database.DeliveryAttempt{}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	claimed, err := db.ClaimDelivery(ctx, now, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed == nil || claimed.DeliveryID != "d1" {
+		t.Fatalf("claimed %+v, want d1, the only delivery due", claimed)
+	}
+
+	// Nobody else gets it while the lease lasts, as the dispatcher holding it may still be sending
+	if again, err := db.ClaimDelivery(ctx, now.Add(30*time.Second), time.Minute); err != nil || again != nil {
+		t.Fatalf("claimed %+v, %v during the lease, want nothing", again, err)
+	}
+
+	// A dispatcher that died mid-attempt leaves the lease to expire
+	expired, err := db.ClaimDelivery(ctx, now.Add(2*time.Minute), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired == nil || expired.DeliveryID != "d1" || expired.Attempts != 0 {
+		t.Fatalf("claimed %+v after the lease expired, want d1 with no attempts recorded", expired)
+	}
+
+	// Recording the attempt releases the lease at once
+	attempt := database.DeliveryAttempt{At: now, StatusCode: 500}
+	if err := db.RecordDeliveryAttempt(ctx, "d1", attempt, database.DeliveryPending, now); err != nil {
+		t.Fatal(err)
+	}
+	retried, err := db.ClaimDelivery(ctx, now.Add(2*time.Minute), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retried == nil || retried.DeliveryID != "d1" || retried.Attempts != 1 || len(retried.Log) != 1 {
+		t.Fatalf("claimed %+v after a recorded attempt, want d1 with one attempt logged", retried)
+	}
+}
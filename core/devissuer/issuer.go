@@ -0,0 +1,155 @@
+// core/devissuer/issuer.go
+package devissuer
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MicahParks/keyfunc"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// DefaultIssuer is the `iss` of tokens minted by the development issuer
+const DefaultIssuer = "https://grammarhive.local/"
+
+// Issuer signs RS256 tokens with a local key so the API can run without Auth0
+type Issuer struct {
+	Issuer   string
+	Audience string
+	KeyID    string
+	key      *rsa.PrivateKey
+}
+
+// TokenOptions describes the token to mint
+type TokenOptions struct {
+	Subject       string
+	Username      string
+	UsernameClaim string
+	Tenant        string
+	TenantClaim   string
+	Scopes        []string
+	TTL           time.Duration
+}
+
+// New loads the signing key from keyFile, generating and saving one if the file does not exist
+func New(keyFile, issuer, audience string) (*Issuer, error) {
+	key, err := loadOrCreateKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if issuer == "" {
+		issuer = DefaultIssuer
+	}
+
+	return &Issuer{
+		Issuer:   issuer,
+		Audience: audience,
+		KeyID:    keyID(&key.PublicKey),
+		key:      key,
+	}, nil
+}
+
+// Mint signs a token for the given subject
+func (i *Issuer) Mint(opts TokenOptions) (string, error) {
+	if opts.Subject == "" {
+		return "", errors.New("subject is required")
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = time.Hour
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": i.Issuer,
+		"sub": opts.Subject,
+		"aud": i.Audience,
+		"iat": now.Unix(),
+		"exp": now.Add(opts.TTL).Unix(),
+	}
+	if len(opts.Scopes) > 0 {
+		claims["scope"] = strings.Join(opts.Scopes, " ")
+	}
+	if opts.Username != "" && opts.UsernameClaim != "" {
+		claims[opts.UsernameClaim] = opts.Username
+	}
+	if opts.Tenant != "" && opts.TenantClaim != "" {
+		claims[opts.TenantClaim] = opts.Tenant
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = i.KeyID
+	return token.SignedString(i.key)
+}
+
+// JWKS returns a key set holding the issuer's public key
+func (i *Issuer) JWKS() *keyfunc.JWKS {
+	return keyfunc.NewGiven(map[string]keyfunc.GivenKey{
+		i.KeyID: keyfunc.NewGivenRSACustomWithOptions(&i.key.PublicKey, keyfunc.GivenKeyOptions{Algorithm: "RS256"}),
+	})
+}
+
+// JWKSJSON returns the public key set in the format served at /.well-known/jwks.json
+func (i *Issuer) JWKSJSON() ([]byte, error) {
+	pub := &i.key.PublicKey
+	return json.MarshalIndent(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": i.KeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	}, "", "  ")
+}
+
+func loadOrCreateKey(keyFile string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(keyFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return createKey(keyFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM encoded", keyFile)
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	return key, nil
+}
+
+func createKey(keyFile string) (*rsa.PrivateKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyFile, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write signing key: %w", err)
+	}
+	return key, nil
+}
+
+// keyID derives a stable key ID from the public key
+func keyID(pub *rsa.PublicKey) string {
+	sum := sha256.Sum256(x509.MarshalPKCS1PublicKey(pub))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,36 @@
+package devissuer
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNewReusesTheSavedKey(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "dev.pem")
+
+	first, err := New(keyFile, "", "aud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := New(keyFile, "", "aud")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Issuer != DefaultIssuer {
+		t.Fatalf("issuer = %q, want %q", first.Issuer, DefaultIssuer)
+	}
+	if first.KeyID != second.KeyID {
+		t.Fatalf("key IDs %s and %s differ; the saved key was not reused", first.KeyID, second.KeyID)
+	}
+}
+
+func TestMintRequiresSubject(t *testing.T) {
+	issuer, err := New(filepath.Join(t.TempDir(), "dev.pem"), "", "aud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := issuer.Mint(TokenOptions{}); err == nil {
+		t.Fatal("minted a token without a subject")
+	}
+}
@@ -0,0 +1,87 @@
+// core/grammar/analysis.go
+package grammar
+
+import (
+	"sort"
+	"strings"
+)
+
+// Rule is a non-terminal and its productions
+type Rule struct {
+	Name        string
+	Productions []string
+}
+
+// Analysis describes grammar content without running it
+type Analysis struct {
+	StartSymbol string
+	// Rules are sorted by name
+	Rules []Rule
+	// Undefined lists the non-terminals productions refer to that have no rule
+	Undefined []string
+	// Unreachable lists the rules that cannot be reached from the start symbol
+	Unreachable []string
+	// Err is why the content does not compile, or nil when it does
+	Err error
+}
+
+// Analyze parses content and reports its rules, undefined and unreachable non-terminals,
+// and whether it compiles
+func Analyze(content string) *Analysis {
+	return analyze(Parse(content))
+}
+
+func analyze(rtg *RandomTextGenerator) *Analysis {
+	analysis := &Analysis{
+		StartSymbol: rtg.StartSymbol,
+		Rules:       make([]Rule, 0, len(rtg.GrammarRules)),
+		Undefined:   []string{},
+		Unreachable: []string{},
+		Err:         rtg.validateGrammar(),
+	}
+
+	undefined := make(map[string]bool)
+	for name, productions := range rtg.GrammarRules {
+		analysis.Rules = append(analysis.Rules, Rule{Name: name, Productions: productions})
+		for _, ref := range references(productions) {
+			if _, exists := rtg.GrammarRules[ref]; !exists && !undefined[ref] {
+				undefined[ref] = true
+				analysis.Undefined = append(analysis.Undefined, ref)
+			}
+		}
+	}
+	sort.Slice(analysis.Rules, func(i, j int) bool { return analysis.Rules[i].Name < analysis.Rules[j].Name })
+	sort.Strings(analysis.Undefined)
+
+	reachable := make(map[string]bool)
+	queue := []string{rtg.StartSymbol}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if reachable[name] {
+			continue
+		}
+		reachable[name] = true
+		queue = append(queue, references(rtg.GrammarRules[name])...)
+	}
+	for _, rule := range analysis.Rules {
+		if !reachable[rule.Name] {
+			analysis.Unreachable = append(analysis.Unreachable, rule.Name)
+		}
+	}
+
+	return analysis
+}
+
+// references returns the non-terminals the productions refer to, without their brackets
+func references(productions []string) []string {
+	var refs []string
+	for _, prod := range productions {
+		for _, sym := range strings.Fields(prod) {
+			if strings.HasPrefix(sym, "<") && strings.HasSuffix(sym, ">") {
+				refs = append(refs, strings.Trim(sym, "<>"))
+			}
+		}
+	}
+	return refs
+}
@@ -0,0 +1,86 @@
+// core/grammar/cache.go
+package grammar
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"sync"
+
+	"grammarhive-backend/core/metrics"
+	"grammarhive-backend/core/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Cache keeps the most recently used compiled generators, keyed by a hash of their grammar content
+type Cache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[[sha256.Size]byte]*list.Element
+}
+
+type cacheEntry struct {
+	key       [sha256.Size]byte
+	generator *RandomTextGenerator
+}
+
+// NewCache returns a cache holding up to size generators; a size of 0 or less disables caching
+func NewCache(size int) *Cache {
+	return &Cache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// Generator returns the compiled generator for the content, compiling and caching it on a miss
+func (c *Cache) Generator(ctx context.Context, grammarContent string) (*RandomTextGenerator, error) {
+	key := sha256.Sum256([]byte(grammarContent))
+
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		c.mu.Unlock()
+		metrics.CacheRequests.WithLabelValues("grammar", "hit").Inc()
+		trace.SpanFromContext(ctx).AddEvent("grammar.cache_hit")
+		return elem.Value.(*cacheEntry).generator, nil
+	}
+	c.mu.Unlock()
+	metrics.CacheRequests.WithLabelValues("grammar", "miss").Inc()
+
+	_, span := tracer.Start(ctx, "grammar.compile", trace.WithAttributes(
+		attribute.Int("grammar.content_bytes", len(grammarContent)),
+	))
+	generator, err := NewRandomTextGenerator(grammarContent)
+	tracing.End(span, err)
+	if err != nil || c.size <= 0 {
+		return generator, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		c.entries[key] = c.order.PushFront(&cacheEntry{key: key, generator: generator})
+		for c.order.Len() > c.size {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*cacheEntry).key)
+		}
+	}
+	return generator, nil
+}
+
+// Cap returns the number of generators the cache can hold
+func (c *Cache) Cap() int {
+	return c.size
+}
+
+// Len returns the number of cached generators
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
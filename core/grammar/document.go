@@ -0,0 +1,99 @@
+// core/grammar/document.go
+package grammar
+
+import "strings"
+
+// Document is grammar content that changes over time, such as in an editor. It keeps the
+// rules read from every block, from one "{" line to the next, and reuses them while the
+// block's text is unchanged, so an edit to a large grammar only re-reads the blocks it touched
+type Document struct {
+	content string
+	rtg     *RandomTextGenerator
+	blocks  map[string]parsedBlock
+	// Reparsed is the number of blocks the last Set had to read
+	Reparsed int
+}
+
+// parsedBlock is what one block defines, and the rule it leaves open for the next block
+type parsedBlock struct {
+	rules []Rule
+	open  string
+}
+
+// NewDocument returns an empty document
+func NewDocument() *Document {
+	d := &Document{}
+	d.Set("")
+	return d
+}
+
+// Content returns the document's current content
+func (d *Document) Content() string {
+	return d.content
+}
+
+// Set replaces the document's content; the rules read are the same Parse would read
+func (d *Document) Set(content string) {
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	rtg := &RandomTextGenerator{
+		GrammarRules: make(map[string][]string),
+		StartSymbol:  "start",
+	}
+	blocks := make(map[string]parsedBlock, len(d.blocks))
+	reparsed := 0
+
+	// Lines before the first block define nothing. A block's rules also depend on the rule
+	// an unclosed block before it left open, so that is part of its key
+	open := ""
+	for start := nextBlock(lines, 0); start < len(lines); {
+		end := nextBlock(lines, start+1)
+		key := open + "\n" + strings.Join(lines[start:end], "\n")
+
+		block, ok := blocks[key]
+		if !ok {
+			block, ok = d.blocks[key]
+		}
+		if !ok {
+			block.open = readRules(lines[start:end], open, func(name string, productions []string) {
+				block.rules = append(block.rules, Rule{Name: name, Productions: productions})
+			})
+			reparsed++
+		}
+		blocks[key] = block
+
+		for _, rule := range block.rules {
+			rtg.GrammarRules[rule.Name] = rule.Productions
+		}
+		open = block.open
+		start = end
+	}
+
+	d.content = content
+	d.rtg = rtg
+	d.blocks = blocks
+	d.Reparsed = reparsed
+}
+
+// Analyze describes the current content
+func (d *Document) Analyze() *Analysis {
+	return analyze(d.rtg)
+}
+
+// Generator returns a generator for the current content, or why it cannot be compiled.
+// The generator stays valid after later changes to the document
+func (d *Document) Generator() (*RandomTextGenerator, error) {
+	if err := d.rtg.validateGrammar(); err != nil {
+		return nil, err
+	}
+	return d.rtg, nil
+}
+
+// nextBlock returns the index of the first "{" line at or after from, or len(lines)
+func nextBlock(lines []string, from int) int {
+	for i := from; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "{" {
+			return i
+		}
+	}
+	return len(lines)
+}
@@ -3,12 +3,30 @@
 package grammar
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"strings"
 	"time"
+
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/metrics"
+	"grammarhive-backend/core/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// MaxExpansionDepth is the number of symbol expansions after which generation gives up
+const MaxExpansionDepth = 800
+
+// ErrDepthExceeded is returned when a generation needs more than MaxExpansionDepth expansions
+var ErrDepthExceeded = errors.New("maximum expansion depth exceeded")
+
+// ErrUnknownSymbol is returned when a run starts from a non-terminal the grammar does not define
+var ErrUnknownSymbol = errors.New("unknown start symbol")
+
 // RandomTextGenerator represents a context-free grammar based text generator
 type RandomTextGenerator struct {
 	GrammarRules map[string][]string
@@ -17,30 +35,45 @@ type RandomTextGenerator struct {
 
 // NewRandomTextGenerator creates and initializes a new RandomTextGenerator instance
 func NewRandomTextGenerator(grammarFileContent string) (*RandomTextGenerator, error) {
+	rtg := Parse(grammarFileContent)
+
+	// Validate grammar after reading rules
+	if err := rtg.validateGrammar(); err != nil {
+		return nil, err
+	}
+
+	return rtg, nil
+}
+
+// Parse reads the rules of grammar content without validating them; use
+// NewRandomTextGenerator to get a generator that is safe to run
+func Parse(grammarFileContent string) *RandomTextGenerator {
 	rtg := &RandomTextGenerator{
 		GrammarRules: make(map[string][]string),
 		StartSymbol:  "start", // looking at non-terminal without `<>`
 	}
 	lines := strings.Split(strings.TrimSpace(grammarFileContent), "\n")
 	rtg.readGrammarRules(lines)
-
-	// Validate grammar after reading rules
-    if err := rtg.validateGrammar(); err != nil {
-        return nil, err
-    }
-
-	return rtg, nil
+	return rtg
 }
 
 // readGrammarRules parses the grammar rules from the input lines
 func (rtg *RandomTextGenerator) readGrammarRules(lines []string) {
-	var currentNonTerminal string
+	readRules(lines, "", func(name string, productions []string) {
+		rtg.GrammarRules[name] = productions
+	})
+}
+
+// readRules parses lines, calling define for each rule as its block closes. currentNonTerminal
+// is the rule a previous unclosed block named, which a following "{" keeps reading into; the
+// rule being read when lines run out is returned for the same purpose
+func readRules(lines []string, currentNonTerminal string, define func(name string, productions []string)) string {
 	var productions []string
 	inRule := false
 
 	for i := 0; i < len(lines); i++ {
 		line := strings.TrimSpace(lines[i])
-		
+
 		if line == "" {
 			continue
 		}
@@ -51,7 +84,7 @@ func (rtg *RandomTextGenerator) readGrammarRules(lines []string) {
 			productions = make([]string, 0)
 		case line == "}":
 			if currentNonTerminal != "" && len(productions) > 0 {
-				rtg.GrammarRules[currentNonTerminal] = productions
+				define(currentNonTerminal, productions)
 			}
 			inRule = false
 			currentNonTerminal = ""
@@ -66,11 +99,28 @@ func (rtg *RandomTextGenerator) readGrammarRules(lines []string) {
 			}
 		}
 	}
+	return currentNonTerminal
+}
+
+// Options adjust a single run of a generator without changing its compiled rules
+type Options struct {
+	// StartSymbol is the non-terminal to expand instead of <start>
+	StartSymbol string
+	// Variables fix the text of the named non-terminals, overriding any rules for them
+	Variables map[string]string
+}
+
+// runState is the per-run state shared by every expansion of one generation
+type runState struct {
+	rng       *rand.Rand
+	variables map[string]string
+	depth     int
+	logger    *slog.Logger
 }
 
 // expandSymbol recursively expands a grammar symbol
-func (rtg *RandomTextGenerator) expandSymbol(symbol string, depth *int) string {
-	if *depth > 800 { // configurable max depth
+func (rtg *RandomTextGenerator) expandSymbol(symbol string, run *runState) string {
+	if run.depth > MaxExpansionDepth {
 		return "Error: Maximum recursion depth exceeded"
 	}
 
@@ -79,22 +129,24 @@ func (rtg *RandomTextGenerator) expandSymbol(symbol string, depth *int) string {
 	}
 
 	nonTerminal := strings.Trim(symbol, "<>")
+	if value, ok := run.variables[nonTerminal]; ok {
+		return value
+	}
 	productions, exists := rtg.GrammarRules[nonTerminal]
-	
+
 	if !exists {
-		fmt.Printf("Warning: No production rules found for non-terminal: %s\n", nonTerminal)
+		run.logger.Warn("no production rules found for non-terminal", "non_terminal", nonTerminal)
 		return symbol
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	production := productions[rand.Intn(len(productions))]
+	production := productions[run.rng.Intn(len(productions))]
 
 	symbols := strings.Fields(production)
 	var result []string
 
 	for _, sym := range symbols {
-		*depth++
-		result = append(result, rtg.expandSymbol(sym, depth))
+		run.depth++
+		result = append(result, rtg.expandSymbol(sym, run))
 	}
 
 	return strings.Join(result, " ")
@@ -102,12 +154,69 @@ func (rtg *RandomTextGenerator) expandSymbol(symbol string, depth *int) string {
 
 // Run generates random text by expanding the start symbol
 func (rtg *RandomTextGenerator) Run() string {
-	if len(rtg.GrammarRules) == 0 {
-		return "Error: Grammar rules not properly initialized"
+	text, err := rtg.RunContext(context.Background())
+	if err != nil {
+		return "Error: " + err.Error()
 	}
+	return text
+}
 
-	depthCount := 0
-	result := rtg.expandSymbol("<" + rtg.StartSymbol + ">", &depthCount)
-	return strings.TrimSpace(result)
+// RunContext generates random text, logging warnings with the request's logger
+func (rtg *RandomTextGenerator) RunContext(ctx context.Context) (string, error) {
+	return rtg.RunWith(ctx, rand.New(rand.NewSource(time.Now().UnixNano())), Options{})
 }
 
+// RunEach generates count texts one after another from a single source seeded with seed,
+// handing each to fn; an error from fn or a cancelled ctx stops generation
+func (rtg *RandomTextGenerator) RunEach(ctx context.Context, count int, seed int64, opts Options, fn func(index int, text string) error) error {
+	rng := rand.New(rand.NewSource(seed))
+	for i := 0; i < count; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		text, err := rtg.RunWith(ctx, rng, opts)
+		if err != nil {
+			return err
+		}
+		if text == "" {
+			return fmt.Errorf("generated text is empty at index %d", i)
+		}
+		if err := fn(i, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunWith generates random text drawing every choice from rng, so a seeded rng
+// reproduces the same text. rng must not be shared between goroutines
+func (rtg *RandomTextGenerator) RunWith(ctx context.Context, rng *rand.Rand, opts Options) (_ string, err error) {
+	if len(rtg.GrammarRules) == 0 {
+		return "", fmt.Errorf("%w: grammar rules not properly initialized", ErrInvalidGrammar)
+	}
+
+	startSymbol := rtg.StartSymbol
+	if opts.StartSymbol != "" {
+		startSymbol = strings.Trim(opts.StartSymbol, "<>")
+		_, isRule := rtg.GrammarRules[startSymbol]
+		_, isVariable := opts.Variables[startSymbol]
+		if !isRule && !isVariable {
+			return "", fmt.Errorf("%w: <%s>", ErrUnknownSymbol, startSymbol)
+		}
+	}
+
+	_, span := tracer.Start(ctx, "grammar.expand")
+	defer func() { tracing.End(span, err) }()
+
+	run := &runState{rng: rng, variables: opts.Variables, logger: logging.FromContext(ctx)}
+	result := rtg.expandSymbol("<"+startSymbol+">", run)
+	metrics.ExpansionDepth.Observe(float64(run.depth))
+	span.SetAttributes(
+		attribute.Int("grammar.expansions", run.depth),
+		attribute.Int("grammar.output_bytes", len(result)),
+	)
+	if run.depth > MaxExpansionDepth {
+		return "", ErrDepthExceeded
+	}
+	return strings.TrimSpace(result), nil
+}
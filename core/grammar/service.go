@@ -2,25 +2,38 @@
 package grammar
 
 import (
+	"context"
 	"fmt"
 	"sync"
+
+	"grammarhive-backend/core/tracing"
 )
 
+var tracer = tracing.Tracer("grammarhive-backend/core/grammar")
+
+// DefaultCacheSize is the number of compiled grammars kept by NewGrammarGenService
+const DefaultCacheSize = 256
+
 type Service struct {
-	generator *RandomTextGenerator
+	Cache *Cache
 }
 
 func NewGrammarGenService() *Service {
-	return &Service{}
+	return &Service{
+		Cache: NewCache(DefaultCacheSize),
+	}
 }
 
-func (s *Service) ExecuteGrammarGen(grammarContent string) (string, error) {
-	generator, err := NewRandomTextGenerator(grammarContent)
+func (s *Service) ExecuteGrammarGen(ctx context.Context, grammarContent string) (string, error) {
+	generator, err := s.Cache.Generator(ctx, grammarContent)
 	if err != nil {
 		return "", fmt.Errorf("failed to create generator: %w", err)
 	}
 
-	text := generator.Run()
+	text, err := generator.RunContext(ctx)
+	if err != nil {
+		return "", err
+	}
 	if text == "" {
 		return "", fmt.Errorf("generated text is empty")
 	}
@@ -29,38 +42,66 @@ func (s *Service) ExecuteGrammarGen(grammarContent string) (string, error) {
 }
 
 // GenerateMultiple generates n texts concurrently
-func (s *Service) GenerateMultiple(grammarContent string, count int) ([]string, error) {
-    generator, err := NewRandomTextGenerator(grammarContent)
-    if err != nil {
-        return nil, fmt.Errorf("failed to create generator: %w", err)
-    }
-
-    messages := make([]string, count)
-    var wg sync.WaitGroup
-    errChan := make(chan error, count)
-
-    // Generate texts concurrently
-    for i := 0; i < count; i++ {
-        wg.Add(1)
-        go func(index int) {
-            defer wg.Done()
-            text := generator.Run()
-            if text == "" {
-                errChan <- fmt.Errorf("generated text is empty at index %d", index)
-                return
-            }
-            messages[index] = text
-        }(i)
-    }
-
-    // Wait for all generations to complete
-    wg.Wait()
-    close(errChan)
-
-    // Check for any errors
-    if len(errChan) > 0 {
-        return nil, <-errChan // Return the first error encountered
-    }
-
-    return messages, nil
+func (s *Service) GenerateMultiple(ctx context.Context, grammarContent string, count int) ([]string, error) {
+	generator, err := s.Cache.Generator(ctx, grammarContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create generator: %w", err)
+	}
+
+	messages := make([]string, count)
+	var wg sync.WaitGroup
+	errChan := make(chan error, count)
+
+	// Generate texts concurrently
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			text, err := generator.RunContext(ctx)
+			if err != nil {
+				errChan <- err
+				return
+			}
+			if text == "" {
+				errChan <- fmt.Errorf("generated text is empty at index %d", index)
+				return
+			}
+			messages[index] = text
+		}(i)
+	}
+
+	// Wait for all generations to complete
+	wg.Wait()
+	close(errChan)
+
+	// Check for any errors
+	if len(errChan) > 0 {
+		return nil, <-errChan // Return the first error encountered
+	}
+
+	return messages, nil
+}
+
+// GenerateSeeded generates count texts one after another from a single source seeded with
+// seed, so the same seed, grammar and options always produce the same texts
+func (s *Service) GenerateSeeded(ctx context.Context, grammarContent string, count int, seed int64, opts Options) ([]string, error) {
+	messages := make([]string, 0, count)
+	err := s.GenerateEach(ctx, grammarContent, count, seed, opts, func(_ int, text string) error {
+		messages = append(messages, text)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// GenerateEach is GenerateSeeded handing each text to fn as soon as it is generated; an
+// error from fn or a cancelled ctx stops generation
+func (s *Service) GenerateEach(ctx context.Context, grammarContent string, count int, seed int64, opts Options, fn func(index int, text string) error) error {
+	generator, err := s.Cache.Generator(ctx, grammarContent)
+	if err != nil {
+		return fmt.Errorf("failed to create generator: %w", err)
+	}
+	return generator.RunEach(ctx, count, seed, opts, fn)
 }
@@ -3,12 +3,23 @@
 package grammar
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
 
-// validateGrammar checks for undefined non-terminals in the grammar rules
+// ErrInvalidGrammar is wrapped by every error describing why grammar content cannot be compiled
+var ErrInvalidGrammar = errors.New("invalid grammar")
+
+// validateGrammar checks that the start symbol is defined and that no production refers to an undefined non-terminal
 func (rtg *RandomTextGenerator) validateGrammar() error {
+	if len(rtg.GrammarRules) == 0 {
+		return fmt.Errorf("%w: no production rules found", ErrInvalidGrammar)
+	}
+	if _, exists := rtg.GrammarRules[rtg.StartSymbol]; !exists {
+		return fmt.Errorf("%w: start symbol <%s> is not defined", ErrInvalidGrammar, rtg.StartSymbol)
+	}
+
 	// Check for undefined non-terminals
 	for _, productions := range rtg.GrammarRules {
 		for _, prod := range productions {
@@ -17,7 +28,7 @@ func (rtg *RandomTextGenerator) validateGrammar() error {
 				if strings.HasPrefix(sym, "<") && strings.HasSuffix(sym, ">") {
 					nonTerm := strings.Trim(sym, "<>")
 					if _, exists := rtg.GrammarRules[nonTerm]; !exists {
-						return fmt.Errorf("undefined non-terminal: %s", nonTerm)
+						return fmt.Errorf("%w: undefined non-terminal: %s", ErrInvalidGrammar, nonTerm)
 					}
 				}
 			}
@@ -0,0 +1,111 @@
+// core/identity/identity.go
+package identity
+
+import (
+	"context"
+	"strings"
+)
+
+// ScopeAdmin grants access to every secured route and every scope
+const ScopeAdmin = "admin"
+
+// Scopes required by the grammar, API key and webhook routes, over HTTP and gRPC alike
+const (
+	ScopeGenerate = "grammar:generate"
+	ScopeRead     = "grammar:read"
+	ScopeWrite    = "grammar:write"
+	ScopeAPIKeys  = "apikeys:manage"
+	ScopeWebhooks = "webhooks:manage"
+)
+
+// Identity is the verified caller of a request, resolved from its credentials
+type Identity struct {
+	Subject  string
+	Username string
+	Tenant   string
+	Scopes   []string
+	Claims   map[string]interface{}
+	APIKeyID string
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the identity
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the identity carried by ctx, or nil for anonymous requests
+func FromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey{}).(*Identity)
+	return id
+}
+
+// FromClaims builds an identity from verified JWT claims
+func FromClaims(claims map[string]interface{}, usernameClaim string) *Identity {
+	id := &Identity{Claims: claims}
+	id.Subject, _ = claims["sub"].(string)
+	if usernameClaim != "" {
+		id.Username, _ = claims[usernameClaim].(string)
+	}
+	id.Scopes = ScopesFromClaims(claims)
+	return id
+}
+
+// ScopesFromClaims merges the space separated `scope` claim with the `permissions` array
+func ScopesFromClaims(claims map[string]interface{}) []string {
+	var scopes []string
+	seen := make(map[string]bool)
+	add := func(scope string) {
+		if scope != "" && !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if scope, ok := claims["scope"].(string); ok {
+		for _, s := range strings.Fields(scope) {
+			add(s)
+		}
+	}
+	if permissions, ok := claims["permissions"].([]interface{}); ok {
+		for _, p := range permissions {
+			if s, ok := p.(string); ok {
+				add(s)
+			}
+		}
+	}
+	return scopes
+}
+
+// HasScope reports whether the identity was granted the scope
+func (id *Identity) HasScope(scope string) bool {
+	if id == nil {
+		return false
+	}
+	for _, s := range id.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingScope returns the first of scopes the identity was not granted; admins are
+// granted every scope
+func (id *Identity) MissingScope(scopes []string) (string, bool) {
+	if id.HasScope(ScopeAdmin) {
+		return "", false
+	}
+	for _, scope := range scopes {
+		if !id.HasScope(scope) {
+			return scope, true
+		}
+	}
+	return "", false
+}
+
+// Owns reports whether the identity is the owner subject
+func (id *Identity) Owns(owner string) bool {
+	return id != nil && id.Subject != "" && id.Subject == owner
+}
@@ -0,0 +1,84 @@
+// core/logging/logging.go
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+type loggerKey struct{}
+type fieldsKey struct{}
+
+// Fields collects attributes discovered while a request is handled, such as its
+// route and subject, so they can be reported in the request's access log line
+type Fields struct {
+	mu    sync.Mutex
+	attrs []any
+}
+
+// New returns a JSON logger writing to w at the named level (debug, info, warn, error)
+func New(w io.Writer, level string) *slog.Logger {
+	var lvl slog.Level
+	switch strings.ToLower(level) {
+	case "debug":
+		lvl = slog.LevelDebug
+	case "warn":
+		lvl = slog.LevelWarn
+	case "error":
+		lvl = slog.LevelError
+	default:
+		lvl = slog.LevelInfo
+	}
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl}))
+}
+
+// WithLogger returns a copy of ctx carrying logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the request-scoped logger in ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// WithFields returns a copy of ctx that collects request fields into f
+func WithFields(ctx context.Context, f *Fields) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, f)
+}
+
+// Annotate adds key/value pairs to the request's logger and access log fields
+func Annotate(ctx context.Context, args ...any) context.Context {
+	if f, ok := ctx.Value(fieldsKey{}).(*Fields); ok {
+		f.mu.Lock()
+		f.attrs = append(f.attrs, args...)
+		f.mu.Unlock()
+	}
+	return WithLogger(ctx, FromContext(ctx).With(args...))
+}
+
+// Attrs returns the collected fields
+func (f *Fields) Attrs() []any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]any(nil), f.attrs...)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the ID of the request being handled, if any
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
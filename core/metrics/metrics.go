@@ -0,0 +1,96 @@
+// core/metrics/metrics.go
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "grammarhive"
+
+// Values of the source label of generation metrics. Grammar IDs are not used as labels, as
+// every stored grammar would add series
+const (
+	SourceStored = "stored"
+	SourceInline = "inline"
+)
+
+// Registry holds every collector exposed on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	GenerationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "generation_duration_seconds",
+		Help:      "Time to load a grammar and generate its output, by grammar source.",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"source"})
+
+	GenerationOutputBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "generation_output_bytes",
+		Help:      "Size of generated output per request, by grammar source.",
+		Buckets:   prometheus.ExponentialBuckets(64, 4, 8),
+	}, []string{"source"})
+
+	ExpansionDepth = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "expansion_depth",
+		Help:      "Number of symbol expansions needed to generate one text.",
+		Buckets:   []float64{10, 25, 50, 100, 200, 400, 800},
+	})
+
+	DBOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_operation_duration_seconds",
+		Help:      "MongoDB command latency by command and outcome.",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 5},
+	}, []string{"command", "outcome"})
+
+	Retries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "retries_total",
+		Help:      "Retried attempts of utils.Retry, and calls that exhausted their attempts.",
+	}, []string{"outcome"})
+
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+
+	WebhookAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_attempts_total",
+		Help:      "Webhook delivery attempts by outcome (succeeded, retried or failed).",
+	}, []string{"outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		GenerationDuration,
+		GenerationOutputBytes,
+		ExpansionDepth,
+		DBOperationDuration,
+		Retries,
+		CacheRequests,
+		WebhookAttempts,
+	)
+}
@@ -0,0 +1,152 @@
+// core/ratelimit/limiter.go
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRoute is the key in a limit table that applies to routes without their own entry
+const DefaultRoute = "default"
+
+// Limit is a token bucket refilled with Requests tokens every Per, holding at most Burst tokens
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// Rate returns the refill rate in tokens per second
+func (l Limit) Rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token, when not allowed
+}
+
+// Store holds bucket state; implementations must make Take atomic per key
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// Limiter applies per-route limits to callers
+type Limiter struct {
+	store  Store
+	limits map[string]Limit
+}
+
+func NewLimiter(store Store, limits map[string]Limit) *Limiter {
+	return &Limiter{
+		store:  store,
+		limits: limits,
+	}
+}
+
+// LimitFor returns the limit of route, falling back to the default limit
+func (l *Limiter) LimitFor(route string) (Limit, bool) {
+	if limit, ok := l.limits[route]; ok {
+		return limit, true
+	}
+	limit, ok := l.limits[DefaultRoute]
+	return limit, ok
+}
+
+// Allow takes a token for the caller on route; routes without a limit are always allowed
+func (l *Limiter) Allow(ctx context.Context, route, caller string) (Result, bool, error) {
+	limit, ok := l.LimitFor(route)
+	if !ok {
+		return Result{Allowed: true}, false, nil
+	}
+	res, err := l.store.Take(ctx, route+"|"+caller, limit, time.Now())
+	return res, true, err
+}
+
+// ParseLimits parses a comma separated table of `route=requests/unit[:burst]` entries,
+// e.g. "default=120/m,/api/grammar/generate=30/m:10", where unit is s, m or h
+func ParseLimits(spec string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q: expected route=requests/unit", entry)
+		}
+		limit, err := parseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit %q: %w", entry, err)
+		}
+		limits[strings.TrimSpace(route)] = limit
+	}
+	return limits, nil
+}
+
+func parseLimit(value string) (Limit, error) {
+	value, burstStr, hasBurst := strings.Cut(strings.TrimSpace(value), ":")
+	requestsStr, unit, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("missing unit")
+	}
+
+	requests, err := strconv.Atoi(requestsStr)
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("requests must be a positive integer")
+	}
+
+	var per time.Duration
+	switch unit {
+	case "s":
+		per = time.Second
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("unknown unit %q", unit)
+	}
+
+	burst := requests
+	if hasBurst {
+		burst, err = strconv.Atoi(burstStr)
+		if err != nil || burst <= 0 {
+			return Limit{}, fmt.Errorf("burst must be a positive integer")
+		}
+	}
+
+	return Limit{Requests: requests, Per: per, Burst: burst}, nil
+}
+
+// NewResult derives the reported state from the tokens left after a take
+func NewResult(limit Limit, tokens float64, allowed bool) Result {
+	rate := limit.Rate()
+	res := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Burst) - tokens) / rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	if s < 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
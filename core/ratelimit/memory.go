@@ -0,0 +1,75 @@
+// core/ratelimit/memory.go
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// maxBuckets bounds the buckets kept in memory; past it the least recently used are dropped
+// even when they have not refilled, which at worst lets an idle caller start over
+const maxBuckets = 100000
+
+type bucket struct {
+	key     string
+	tokens  float64
+	updated time.Time
+	full    time.Time // when the bucket will have refilled under its own limit
+}
+
+// MemoryStore keeps buckets in process; limits are per instance
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	recent  *list.List // of *bucket, most recently used first
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*list.Element),
+		recent:  list.New(),
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b *bucket
+	if elem, ok := s.buckets[key]; ok {
+		b = elem.Value.(*bucket)
+		s.recent.MoveToFront(elem)
+	} else {
+		b = &bucket{key: key, tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = s.recent.PushFront(b)
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate())
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.full = now.Add(time.Duration((float64(limit.Burst) - b.tokens) / limit.Rate() * float64(time.Second)))
+
+	s.prune(now)
+	return NewResult(limit, b.tokens, allowed), nil
+}
+
+// prune drops least recently used buckets that have refilled completely, as they are
+// equivalent to new ones, and any past maxBuckets. Each bucket is dropped at most once, so
+// the cost is constant per Take on average
+func (s *MemoryStore) prune(now time.Time) {
+	for elem := s.recent.Back(); elem != nil; elem = s.recent.Back() {
+		b := elem.Value.(*bucket)
+		if now.Before(b.full) && s.recent.Len() <= maxBuckets {
+			return
+		}
+		s.recent.Remove(elem)
+		delete(s.buckets, b.key)
+	}
+}
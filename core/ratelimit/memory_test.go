@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreKeepsStrictBucketsWhilePruning(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+	strict := Limit{Requests: 1, Per: time.Hour, Burst: 1}
+	fast := Limit{Requests: 10, Per: time.Second, Burst: 10}
+
+	if res, _ := s.Take(ctx, "strict|ada", strict, now); !res.Allowed {
+		t.Fatal("first strict request was limited")
+	}
+
+	// Fast buckets refill within a second; taking them a minute later prunes them but must not
+	// drop the strict bucket, which is far from full
+	for i := 0; i < 100; i++ {
+		s.Take(ctx, fmt.Sprintf("fast|%d", i), fast, now)
+	}
+	later := now.Add(time.Minute)
+	s.Take(ctx, "fast|new", fast, later)
+
+	if res, _ := s.Take(ctx, "strict|ada", strict, later); res.Allowed {
+		t.Fatal("second strict request within the hour was allowed")
+	}
+	if len(s.buckets) != 2 || s.recent.Len() != 2 {
+		t.Fatalf("kept %d buckets, want the strict one and the latest fast one", len(s.buckets))
+	}
+}
+
+func TestMemoryStoreIsBounded(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+	limit := Limit{Requests: 1, Per: time.Hour, Burst: 1}
+
+	for i := 0; i < maxBuckets+10; i++ {
+		s.Take(ctx, fmt.Sprintf("route|%d", i), limit, now)
+	}
+	if len(s.buckets) != maxBuckets || s.recent.Len() != maxBuckets {
+		t.Fatalf("kept %d buckets, want %d", len(s.buckets), maxBuckets)
+	}
+	if _, ok := s.buckets["route|0"]; ok {
+		t.Fatal("kept the least recently used bucket past the bound")
+	}
+	if res, _ := s.Take(ctx, fmt.Sprintf("route|%d", maxBuckets+9), limit, now); res.Allowed {
+		t.Fatal("the most recent bucket was dropped")
+	}
+}
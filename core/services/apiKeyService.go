@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/logging"
+	"log"
+	"strings"
+	"time"
+)
+
+const apiKeyPrefix = "gh_"
+
+// apiKeyIDLength is the length of the public ID of new keys; older keys have shorter IDs
+const apiKeyIDLength = 16
+
+var (
+	// ErrInvalidAPIKey is returned for malformed, unknown, revoked or expired keys
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrScopeNotGranted is returned when a key requests scopes its creator does not hold
+	ErrScopeNotGranted = errors.New("api key cannot be granted scopes the caller does not hold")
+	// ErrKeyCreatesKey is returned when a caller authenticated with an API key tries to create
+	// another, which could outlive the key that created it
+	ErrKeyCreatesKey = errors.New("api keys can only be created with a user token")
+)
+
+type APIKeyService struct {
+	DB *database.MongoDB
+}
+
+func NewAPIKeyService(db *database.MongoDB) *APIKeyService {
+	if db == nil {
+		log.Fatal("Database connection is nil")
+	}
+
+	return &APIKeyService{
+		DB: db,
+	}
+}
+
+// Create issues a new key for the caller and returns it with its plaintext secret, which is never stored
+func (s *APIKeyService) Create(ctx context.Context, name string, scopes []string, ttl time.Duration) (*database.APIKey, string, error) {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return nil, "", ErrNotOwner
+	}
+	if caller.APIKeyID != "" {
+		return nil, "", ErrKeyCreatesKey
+	}
+	if len(scopes) == 0 {
+		scopes = caller.Scopes
+	}
+	if !caller.HasScope(identity.ScopeAdmin) {
+		for _, scope := range scopes {
+			if !caller.HasScope(scope) {
+				return nil, "", fmt.Errorf("%w: %s", ErrScopeNotGranted, scope)
+			}
+		}
+	}
+
+	keyID, err := randomString(apiKeyIDLength)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomString(32)
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext := apiKeyPrefix + keyID + "_" + secret
+
+	key := &database.APIKey{
+		KeyID:     keyID,
+		Hash:      hashAPIKey(plaintext),
+		Name:      name,
+		Owner:     caller.Subject,
+		Username:  caller.Username,
+		Tenant:    caller.Tenant,
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+	if ttl > 0 {
+		expiresAt := key.CreatedAt.Add(ttl)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := s.DB.CreateAPIKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, plaintext, nil
+}
+
+// List returns the caller's keys without their hashes
+func (s *APIKeyService) List(ctx context.Context) ([]database.APIKey, error) {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return nil, ErrNotOwner
+	}
+	return s.DB.ListAPIKeys(ctx, caller.Subject)
+}
+
+// Revoke disables one of the caller's keys
+func (s *APIKeyService) Revoke(ctx context.Context, keyID string) error {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return ErrNotOwner
+	}
+	return s.DB.RevokeAPIKey(ctx, caller.Subject, keyID)
+}
+
+// ResolveAPIKey verifies a plaintext key and returns the identity it acts as
+func (s *APIKeyService) ResolveAPIKey(ctx context.Context, plaintext string) (*identity.Identity, error) {
+	keyID, ok := parseAPIKeyID(plaintext)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.DB.FindAPIKey(ctx, keyID)
+	if errors.Is(err, database.ErrAPIKeyNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashAPIKey(plaintext))) != 1 ||
+		key.RevokedAt != nil ||
+		(key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err := s.DB.TouchAPIKey(ctx, keyID, now); err != nil {
+		logging.FromContext(ctx).Warn("failed to record api key usage", "api_key_id", keyID, "error", err)
+	}
+
+	return &identity.Identity{
+		Subject:  key.Owner,
+		Username: key.Username,
+		Tenant:   key.Tenant,
+		Scopes:   key.Scopes,
+		APIKeyID: key.KeyID,
+	}, nil
+}
+
+func parseAPIKeyID(plaintext string) (string, bool) {
+	if !strings.HasPrefix(plaintext, apiKeyPrefix) {
+		return "", false
+	}
+	keyID, secret, ok := strings.Cut(strings.TrimPrefix(plaintext, apiKeyPrefix), "_")
+	return keyID, ok && keyID != "" && secret != ""
+}
+
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString returns n URL-safe characters without underscores, so keys split unambiguously
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(b)
+	return strings.ReplaceAll(encoded, "_", "-")[:n], nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"grammarhive-backend/core/database/dbtest"
+	"grammarhive-backend/core/identity"
+)
+
+func TestCreateAPIKeyRejectsAPIKeyCallers(t *testing.T) {
+	s := &APIKeyService{}
+	ctx := identity.WithIdentity(context.Background(), &identity.Identity{
+		Subject:  "sub",
+		Scopes:   []string{identity.ScopeAPIKeys},
+		APIKeyID: "parent",
+	})
+
+	if _, _, err := s.Create(ctx, "child", nil, 0); !errors.Is(err, ErrKeyCreatesKey) {
+		t.Fatalf("got %v, want ErrKeyCreatesKey", err)
+	}
+}
+
+func TestCreateAndResolveAPIKey(t *testing.T) {
+	s := NewAPIKeyService(dbtest.Connect(t))
+	ctx := identity.WithIdentity(context.Background(), &identity.Identity{
+		Subject:  "sub",
+		Username: "ada",
+		Scopes:   []string{identity.ScopeRead, identity.ScopeAPIKeys},
+	})
+
+	key, plaintext, err := s.Create(ctx, "ci", []string{identity.ScopeRead}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key.KeyID) != apiKeyIDLength || !strings.Contains(plaintext, key.KeyID) {
+		t.Fatalf("key ID %q in %q, want %d characters", key.KeyID, plaintext, apiKeyIDLength)
+	}
+
+	caller, err := s.ResolveAPIKey(context.Background(), plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caller.Subject != "sub" || caller.APIKeyID != key.KeyID || !caller.HasScope(identity.ScopeRead) || caller.HasScope(identity.ScopeAPIKeys) {
+		t.Fatalf("resolved %+v", caller)
+	}
+
+	if err := s.Revoke(ctx, key.KeyID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ResolveAPIKey(context.Background(), plaintext); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("resolving a revoked key: got %v, want ErrInvalidAPIKey", err)
+	}
+}
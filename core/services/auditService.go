@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/logging"
+	"log"
+	"time"
+)
+
+// Audited actions
+const (
+	AuditGrammarCreate = "grammar.create"
+	AuditGrammarUpdate = "grammar.update"
+	AuditGrammarDelete = "grammar.delete"
+	AuditAuthFailure   = "auth.failure"
+	AuditAPIKeyCreate  = "apikey.create"
+	AuditAPIKeyRevoke  = "apikey.revoke"
+	AuditWebhookCreate = "webhook.create"
+	AuditWebhookDelete = "webhook.delete"
+)
+
+// Audit results
+const (
+	AuditSuccess = "success"
+	AuditDenied  = "denied"
+	AuditFailure = "failure"
+)
+
+type AuditService struct {
+	DB *database.MongoDB
+}
+
+func NewAuditService(db *database.MongoDB) *AuditService {
+	if db == nil {
+		log.Fatal("Database connection is nil")
+	}
+
+	return &AuditService{
+		DB: db,
+	}
+}
+
+// Record appends an entry, filling the actor from the identity in ctx; failures are logged, not returned,
+// so auditing never changes the outcome of the audited request
+func (s *AuditService) Record(ctx context.Context, entry database.AuditEntry) {
+	if caller := identity.FromContext(ctx); caller != nil {
+		entry.Actor = caller.Subject
+		entry.Username = caller.Username
+		entry.APIKeyID = caller.APIKeyID
+	}
+	if entry.At.IsZero() {
+		entry.At = time.Now()
+	}
+
+	if err := s.DB.AppendAudit(ctx, &entry); err != nil {
+		logging.FromContext(ctx).Warn("failed to write audit entry", "action", entry.Action, "actor", entry.Actor, "error", err)
+	}
+}
+
+// Query returns matching entries; callers without the admin scope only see entries for their own grammars
+func (s *AuditService) Query(ctx context.Context, filter database.AuditFilter) ([]database.AuditEntry, error) {
+	filter, err := s.scope(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.DB.FindAudit(ctx, filter)
+}
+
+// Export streams matching entries to fn, with the same visibility rules as Query
+func (s *AuditService) Export(ctx context.Context, filter database.AuditFilter, fn func(*database.AuditEntry) error) error {
+	filter, err := s.scope(ctx, filter)
+	if err != nil {
+		return err
+	}
+	return s.DB.EachAudit(ctx, filter, fn)
+}
+
+func (s *AuditService) scope(ctx context.Context, filter database.AuditFilter) (database.AuditFilter, error) {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return filter, ErrNotOwner
+	}
+	if !caller.HasScope(identity.ScopeAdmin) {
+		filter.Owner = caller.Subject
+	}
+	return filter, nil
+}
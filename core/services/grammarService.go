@@ -5,12 +5,19 @@ import (
 	"context"
 	"grammarhive-backend/core/database"
 	"grammarhive-backend/core/grammar"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/tracing"
 	"log"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = tracing.Tracer("grammarhive-backend/core/services")
+
 type GrammarGenService struct {
-	DB    *database.MongoDB
-	GrammarService  *grammar.Service
+	DB             *database.MongoDB
+	GrammarService *grammar.Service
 }
 
 func NewGrammarService(db *database.MongoDB) *GrammarGenService {
@@ -19,25 +26,124 @@ func NewGrammarService(db *database.MongoDB) *GrammarGenService {
 	}
 
 	return &GrammarGenService{
-		DB:              db,
-		GrammarService:  grammar.NewGrammarGenService(),
+		DB:             db,
+		GrammarService: grammar.NewGrammarGenService(),
 	}
 }
 
+// Generation is the output of a generation request and the grammar version it came from
+type Generation struct {
+	GrammarID string
+	Version   int
+	Messages  []string
+}
+
+// InlineGrammarID labels generations from grammar content sent with the request instead of
+// a stored grammar; it cannot collide with generated IDs, which are URL-safe base64
+const InlineGrammarID = "(inline)"
+
+// GenerateRequest describes a generation from a stored grammar or from inline content
+type GenerateRequest struct {
+	GrammarID string
+	Content   string
+	Count     int
+	Seed      int64
+	Options   grammar.Options
+}
+
 // Generate handles the logic for generating text from the grammar
-func (s *GrammarGenService) Generate(grammarID string) (string, error) {
-	grammarContent, err := s.DB.GetGrammar(context.Background(), grammarID)
+func (s *GrammarGenService) Generate(ctx context.Context, grammarID string) (_ *Generation, err error) {
+	ctx, span := tracer.Start(ctx, "GrammarGenService.Generate", trace.WithAttributes(
+		attribute.String("grammar.id", grammarID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	g, err := s.loadGrammar(ctx, grammarID)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	text, err := s.GrammarService.ExecuteGrammarGen(ctx, g.Content)
+	if err != nil {
+		return nil, err
 	}
-	return s.GrammarService.ExecuteGrammarGen(grammarContent)
+	return &Generation{GrammarID: grammarID, Version: g.Version, Messages: []string{text}}, nil
 }
 
 // GenerateMultiple handles generating multiple texts
-func (s *GrammarGenService) GenerateMultiple(grammarID string, count int) ([]string, error) {
-	grammarContent, err := s.DB.GetGrammar(context.Background(), grammarID)
+func (s *GrammarGenService) GenerateMultiple(ctx context.Context, grammarID string, count int) (_ *Generation, err error) {
+	ctx, span := tracer.Start(ctx, "GrammarGenService.GenerateMultiple", trace.WithAttributes(
+		attribute.String("grammar.id", grammarID),
+		attribute.Int("grammar.count", count),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	g, err := s.loadGrammar(ctx, grammarID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.GrammarService.GenerateMultiple(ctx, g.Content, count)
 	if err != nil {
 		return nil, err
 	}
-	return s.GrammarService.GenerateMultiple(grammarContent, count)
+	return &Generation{GrammarID: grammarID, Version: g.Version, Messages: messages}, nil
+}
+
+// GenerateWith generates req.Count texts from the stored grammar req.GrammarID, or from
+// req.Content when no ID is given, reproducibly for req.Seed
+func (s *GrammarGenService) GenerateWith(ctx context.Context, req GenerateRequest) (*Generation, error) {
+	return s.GenerateEach(ctx, req, nil)
+}
+
+// GenerateEach is GenerateWith also handing each text to fn, when set, as soon as it is
+// generated along with the grammar version it came from. When fn or generation fails
+// part way, the texts fn accepted are returned along with the error so they can be metered
+func (s *GrammarGenService) GenerateEach(ctx context.Context, req GenerateRequest, fn func(index, version int, text string) error) (_ *Generation, err error) {
+	grammarID := req.GrammarID
+	if grammarID == "" {
+		grammarID = InlineGrammarID
+	}
+	ctx, span := tracer.Start(ctx, "GrammarGenService.GenerateWith", trace.WithAttributes(
+		attribute.String("grammar.id", grammarID),
+		attribute.Int("grammar.count", req.Count),
+		attribute.Int64("grammar.seed", req.Seed),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	content, version := req.Content, 0
+	if req.GrammarID != "" {
+		g, err := s.loadGrammar(ctx, req.GrammarID)
+		if err != nil {
+			return nil, err
+		}
+		content, version = g.Content, g.Version
+	}
+
+	generation := &Generation{GrammarID: grammarID, Version: version, Messages: make([]string, 0, req.Count)}
+	err = s.GrammarService.GenerateEach(ctx, content, req.Count, req.Seed, req.Options, func(index int, text string) error {
+		if fn != nil {
+			if err := fn(index, version, text); err != nil {
+				return err
+			}
+		}
+		generation.Messages = append(generation.Messages, text)
+		return nil
+	})
+	return generation, err
+}
+
+// loadGrammar fetches a grammar, hiding private grammars from everyone but their owner
+func (s *GrammarGenService) loadGrammar(ctx context.Context, grammarID string) (*database.Grammar, error) {
+	return visibleGrammar(ctx, s.DB, grammarID)
+}
+
+// visibleGrammar fetches a grammar, hiding private grammars from everyone but their owner
+func visibleGrammar(ctx context.Context, db *database.MongoDB, grammarID string) (*database.Grammar, error) {
+	g, err := db.GetGrammarByID(ctx, grammarID)
+	if err != nil {
+		return nil, err
+	}
+	if g.Private && !identity.FromContext(ctx).Owns(g.Owner) {
+		return nil, database.ErrGrammarNotFound
+	}
+	return g, nil
 }
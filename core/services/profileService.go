@@ -2,12 +2,49 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/grammar"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/tracing"
 	"log"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrNotOwner is returned when the caller tries to modify a grammar owned by someone else
+var ErrNotOwner = errors.New("grammar is owned by another user")
+
+// ErrNoUsername is returned when a grammar is stored for a caller whose credentials carry no username
+var ErrNoUsername = errors.New("token does not identify a user")
+
+// ErrInvalidName is wrapped by errors for grammar names that cannot be stored
+var ErrInvalidName = errors.New("invalid grammar name")
+
+// ErrInvalidUsername is wrapped by errors for usernames that cannot be looked up
+var ErrInvalidUsername = errors.New("invalid username")
+
+// GrammarIDLength is the length of the IDs given to new grammars
+const GrammarIDLength = 6
+
+// GrammarUpdate lists the fields UpdateGrammar changes; nil fields keep their value
+type GrammarUpdate struct {
+	// Version is the version being replaced; 0 skips the conflict check
+	Version int
+	Name    *string
+	Content *string
+	Private *bool
+}
+
 type ProfileService struct {
-	DB    *database.MongoDB
+	DB *database.MongoDB
+	// Webhooks, when set, is notified of every grammar published, updated or deleted
+	Webhooks *WebhookService
 }
 
 func NewProfileService(db *database.MongoDB) *ProfileService {
@@ -20,6 +57,174 @@ func NewProfileService(db *database.MongoDB) *ProfileService {
 	}
 }
 
-func (p *ProfileService) UploadGrammarToProfile(ctx context.Context, input *database.Grammar) error {
-	return p.DB.StoreGrammar(ctx, input.GrammarID, input.Name, input.Username, input.Content, input.Version)
+// UploadGrammarToProfile stores the grammar on behalf of the identity in ctx, which becomes its owner
+func (p *ProfileService) UploadGrammarToProfile(ctx context.Context, input *database.Grammar) (err error) {
+	ctx, span := tracer.Start(ctx, "ProfileService.UploadGrammarToProfile", trace.WithAttributes(
+		attribute.String("grammar.id", input.GrammarID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return ErrNotOwner
+	}
+
+	event := EventGrammarUpdated
+	existing, err := p.DB.GetGrammarByID(ctx, input.GrammarID)
+	switch {
+	case errors.Is(err, database.ErrGrammarNotFound):
+		event = EventGrammarPublished
+	case err != nil:
+		return err
+	case !caller.Owns(existing.Owner):
+		return ErrNotOwner
+	}
+
+	input.Owner = caller.Subject
+	if err := p.DB.StoreGrammarFor(ctx, input); err != nil {
+		return err
+	}
+
+	stored := *input
+	stored.Version++
+	p.notify(ctx, event, &stored)
+	return nil
+}
+
+// GenerateRandomID generates a random URL-safe base64 string of specified length
+func GenerateRandomID(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random ID: %w", err)
+	}
+	return base64.URLEncoding.EncodeToString(b)[:length], nil
+}
+
+// CreateGrammar checks that content compiles and stores it as a new grammar owned by the caller
+func (p *ProfileService) CreateGrammar(ctx context.Context, name, content string, private bool) (*database.Grammar, error) {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Username == "" {
+		return nil, ErrNoUsername
+	}
+	if err := p.ValidateName(name); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidName, err)
+	}
+	if _, err := grammar.NewRandomTextGenerator(content); err != nil {
+		return nil, err
+	}
+
+	grammarID, err := GenerateRandomID(GrammarIDLength)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	input := &database.Grammar{
+		GrammarID: grammarID,
+		Name:      name,
+		Username:  caller.Username,
+		Content:   content,
+		Private:   private,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := p.UploadGrammarToProfile(ctx, input); err != nil {
+		return nil, err
+	}
+	input.Version++
+	return input, nil
+}
+
+// GetGrammar returns the latest version of a grammar, hiding private grammars from everyone but their owner
+func (p *ProfileService) GetGrammar(ctx context.Context, grammarID string) (*database.Grammar, error) {
+	return visibleGrammar(ctx, p.DB, grammarID)
+}
+
+// ListGrammars lists username's public grammars, plus the private ones the caller owns
+func (p *ProfileService) ListGrammars(ctx context.Context, username string) ([]database.Grammar, error) {
+	if err := p.ValidateUsername(username); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidUsername, err)
+	}
+
+	var viewer string
+	if caller := identity.FromContext(ctx); caller != nil {
+		viewer = caller.Subject
+	}
+	return p.DB.GetGrammarsByUsername(ctx, username, viewer)
+}
+
+// UpdateGrammar stores a new version of one of the caller's grammars
+func (p *ProfileService) UpdateGrammar(ctx context.Context, grammarID string, update GrammarUpdate) (_ *database.Grammar, err error) {
+	ctx, span := tracer.Start(ctx, "ProfileService.UpdateGrammar", trace.WithAttributes(
+		attribute.String("grammar.id", grammarID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	existing, err := p.ownedGrammar(ctx, grammarID)
+	if err != nil {
+		return nil, err
+	}
+	if update.Version != 0 && update.Version != existing.Version {
+		return nil, database.ErrVersionConflict
+	}
+
+	if update.Name != nil {
+		if err := p.ValidateName(*update.Name); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidName, err)
+		}
+		existing.Name = *update.Name
+	}
+	if update.Content != nil {
+		if _, err := grammar.NewRandomTextGenerator(*update.Content); err != nil {
+			return nil, err
+		}
+		existing.Content = *update.Content
+	}
+	if update.Private != nil {
+		existing.Private = *update.Private
+	}
+
+	if err := p.DB.StoreGrammarFor(ctx, existing); err != nil {
+		return nil, err
+	}
+	existing.Version++
+	existing.UpdatedAt = time.Now()
+	p.notify(ctx, EventGrammarUpdated, existing)
+	return existing, nil
+}
+
+// DeleteGrammar deletes every version of one of the caller's grammars
+func (p *ProfileService) DeleteGrammar(ctx context.Context, grammarID string) (err error) {
+	ctx, span := tracer.Start(ctx, "ProfileService.DeleteGrammar", trace.WithAttributes(
+		attribute.String("grammar.id", grammarID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	existing, err := p.ownedGrammar(ctx, grammarID)
+	if err != nil {
+		return err
+	}
+	if err := p.DB.DeleteGrammar(ctx, grammarID); err != nil {
+		return err
+	}
+	p.notify(ctx, EventGrammarDeleted, existing)
+	return nil
+}
+
+func (p *ProfileService) notify(ctx context.Context, event string, g *database.Grammar) {
+	if p.Webhooks != nil {
+		p.Webhooks.Notify(ctx, event, g)
+	}
+}
+
+// ownedGrammar fetches a grammar the caller may modify
+func (p *ProfileService) ownedGrammar(ctx context.Context, grammarID string) (*database.Grammar, error) {
+	existing, err := visibleGrammar(ctx, p.DB, grammarID)
+	if err != nil {
+		return nil, err
+	}
+	if !identity.FromContext(ctx).Owns(existing.Owner) {
+		return nil, ErrNotOwner
+	}
+	return existing, nil
 }
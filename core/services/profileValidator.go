@@ -3,6 +3,7 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -10,6 +11,9 @@ import (
 	"regexp"
 )
 
+// ErrFileTypeNotAllowed is returned for uploads whose sniffed type is not in AllowedMimeTypes
+var ErrFileTypeNotAllowed = errors.New("file type is not allowed")
+
 var AllowedMimeTypes = map[string]bool{
 	"text/plain": true,
 	"application/json": true,
@@ -37,7 +41,7 @@ func (s *ProfileService) ValidateFile(file multipart.File) error {
 	// Retrieve the MIME type
 	mimeType := http.DetectContentType(fileTypeBuffer)
 	if !AllowedMimeTypes[mimeType] {
-		return fmt.Errorf("file type %s is not allowed", mimeType)
+		return fmt.Errorf("%w: %s", ErrFileTypeNotAllowed, mimeType)
 	}
 	return nil
 }
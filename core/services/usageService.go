@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/logging"
+	"grammarhive-backend/core/metrics"
+	"log"
+	"time"
+)
+
+// ScopeUnlimitedUsage exempts a caller from the monthly generation quota
+const ScopeUnlimitedUsage = "usage:unlimited"
+
+// ErrQuotaExceeded is returned when a generation would exceed the caller's monthly quota
+var ErrQuotaExceeded = errors.New("monthly generation quota exceeded")
+
+type UsageService struct {
+	DB           *database.MongoDB
+	MonthlyLimit int64
+}
+
+// Quota is the caller's generation allowance for the current UTC month
+type Quota struct {
+	Unlimited bool      `json:"unlimited"`
+	Limit     int64     `json:"limit,omitempty"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining,omitempty"`
+	ResetsAt  time.Time `json:"resetsAt"`
+}
+
+// UsageDay is the caller's usage over one UTC day, with a breakdown per grammar version
+type UsageDay struct {
+	Day         string                  `json:"day"`
+	Requests    int64                   `json:"requests"`
+	Generations int64                   `json:"generations"`
+	Bytes       int64                   `json:"bytes"`
+	Grammars    []database.UsageCounter `json:"grammars"`
+}
+
+// NewUsageService meters generations; a monthlyLimit of 0 disables quotas
+func NewUsageService(db *database.MongoDB, monthlyLimit int64) *UsageService {
+	if db == nil {
+		log.Fatal("Database connection is nil")
+	}
+
+	return &UsageService{
+		DB:           db,
+		MonthlyLimit: monthlyLimit,
+	}
+}
+
+// Record stores a usage event for the caller; failures are logged as metering must not fail generation
+func (s *UsageService) Record(ctx context.Context, event database.UsageEvent) {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return
+	}
+	event.Subject = caller.Subject
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+
+	if err := s.DB.RecordUsage(ctx, &event); err != nil {
+		logging.FromContext(ctx).Warn("failed to record usage", "subject", caller.Subject, "error", err)
+	}
+}
+
+// RecordGeneration meters a generation against the caller's quota and records its metrics
+func (s *UsageService) RecordGeneration(ctx context.Context, generation *Generation, latency time.Duration) {
+	bytes := 0
+	for _, message := range generation.Messages {
+		bytes += len(message)
+	}
+
+	source := metrics.SourceStored
+	if generation.GrammarID == InlineGrammarID {
+		source = metrics.SourceInline
+	}
+	metrics.GenerationDuration.WithLabelValues(source).Observe(latency.Seconds())
+	metrics.GenerationOutputBytes.WithLabelValues(source).Observe(float64(bytes))
+
+	s.Record(ctx, database.UsageEvent{
+		GrammarID: generation.GrammarID,
+		Version:   generation.Version,
+		Count:     len(generation.Messages),
+		Bytes:     bytes,
+		Latency:   latency,
+	})
+}
+
+// Quota returns the caller's allowance for the current month
+func (s *UsageService) Quota(ctx context.Context) (*Quota, error) {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return nil, ErrNotOwner
+	}
+
+	start, end := currentMonth(time.Now())
+	used, err := s.DB.CountGenerations(ctx, caller.Subject, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	quota := &Quota{Used: used, ResetsAt: end.AddDate(0, 0, 1)}
+	if s.MonthlyLimit <= 0 || caller.HasScope(identity.ScopeAdmin) || caller.HasScope(ScopeUnlimitedUsage) {
+		quota.Unlimited = true
+		return quota, nil
+	}
+
+	quota.Limit = s.MonthlyLimit
+	quota.Remaining = s.MonthlyLimit - used
+	if quota.Remaining < 0 {
+		quota.Remaining = 0
+	}
+	return quota, nil
+}
+
+// ReserveQuota takes count generations from the caller's quota ahead of generating, returning
+// ErrQuotaExceeded if they do not fit; those left undelivered are handed back with ReleaseQuota
+func (s *UsageService) ReserveQuota(ctx context.Context, count int) (*Quota, error) {
+	quota, err := s.Quota(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if quota.Unlimited {
+		return quota, nil
+	}
+
+	subject := identity.FromContext(ctx).Subject
+	ok, err := s.DB.ReserveGenerations(ctx, subject, quota.month(), int64(count), quota.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return quota, ErrQuotaExceeded
+	}
+	return quota, nil
+}
+
+// ReleaseQuota hands back unused generations reserved with quota; failures are logged as
+// they only cost the caller allowance
+func (s *UsageService) ReleaseQuota(ctx context.Context, quota *Quota, unused int) {
+	if quota == nil || quota.Unlimited || unused <= 0 {
+		return
+	}
+
+	subject := identity.FromContext(ctx).Subject
+	if err := s.DB.ReleaseGenerations(ctx, subject, quota.month(), int64(unused)); err != nil {
+		logging.FromContext(ctx).Warn("failed to release reserved generations", "subject", subject, "error", err)
+	}
+}
+
+// month returns the first day of the month the quota is for
+func (q *Quota) month() time.Time {
+	return q.ResetsAt.AddDate(0, -1, 0)
+}
+
+// Usage returns the caller's per-day usage for days in [from, to]
+func (s *UsageService) Usage(ctx context.Context, from, to time.Time) ([]UsageDay, error) {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return nil, ErrNotOwner
+	}
+
+	counters, err := s.DB.GetUsage(ctx, caller.Subject, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	days := []UsageDay{}
+	for _, counter := range counters {
+		if len(days) == 0 || days[len(days)-1].Day != counter.Day {
+			days = append(days, UsageDay{Day: counter.Day})
+		}
+		day := &days[len(days)-1]
+		day.Requests += counter.Requests
+		day.Generations += counter.Generations
+		day.Bytes += counter.Bytes
+		day.Grammars = append(day.Grammars, counter)
+	}
+	return days, nil
+}
+
+// currentMonth returns the first and last day of the UTC month containing now
+func currentMonth(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, -1)
+}
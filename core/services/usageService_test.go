@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/database/dbtest"
+	"grammarhive-backend/core/identity"
+)
+
+func TestReserveQuotaCannotBeOverdrawnConcurrently(t *testing.T) {
+	db := dbtest.Connect(t)
+	usage := NewUsageService(db, 10)
+	ctx := identity.WithIdentity(context.Background(), &identity.Identity{Subject: "auth0|ada"})
+
+	// Generations metered before the first reservation of the month count against it
+	usage.Record(ctx, database.UsageEvent{GrammarID: "g1", Count: 4})
+
+	var reserved atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := usage.ReserveQuota(ctx, 2)
+			switch {
+			case err == nil:
+				reserved.Add(2)
+			case !errors.Is(err, ErrQuotaExceeded):
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := reserved.Load(); got != 6 {
+		t.Fatalf("reserved %d generations over 4 used of 10, want 6", got)
+	}
+
+	quota, err := usage.ReserveQuota(ctx, 1)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("reserving past the quota: %v, want ErrQuotaExceeded", err)
+	}
+	if !quota.ResetsAt.After(time.Now()) {
+		t.Fatalf("quota resets at %s, want a time to retry after", quota.ResetsAt)
+	}
+
+	// Generations handed back fit again
+	usage.ReleaseQuota(ctx, quota, 3)
+	if _, err := usage.ReserveQuota(ctx, 3); err != nil {
+		t.Fatalf("reserving released generations: %v", err)
+	}
+	if _, err := usage.ReserveQuota(ctx, 1); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("reserving past the quota again: %v, want ErrQuotaExceeded", err)
+	}
+}
+
+func TestUnlimitedCallersReserveNothing(t *testing.T) {
+	db := dbtest.Connect(t)
+	usage := NewUsageService(db, 1)
+	ctx := identity.WithIdentity(context.Background(), &identity.Identity{Subject: "auth0|ada", Scopes: []string{ScopeUnlimitedUsage}})
+
+	for i := 0; i < 3; i++ {
+		quota, err := usage.ReserveQuota(ctx, 5)
+		if err != nil || !quota.Unlimited {
+			t.Fatalf("reservation %d: %+v %v, want an unlimited quota", i+1, quota, err)
+		}
+		usage.ReleaseQuota(ctx, quota, 5)
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/identity"
+	"log"
+	"sync"
+	"time"
+)
+
+// lastSeenInterval limits how often a busy user's record is rewritten
+const lastSeenInterval = time.Minute
+
+// ErrInvalidProfile is returned when profile fields fail validation
+var ErrInvalidProfile = errors.New("invalid profile")
+
+type UserService struct {
+	DB *database.MongoDB
+
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+}
+
+// Me is the caller's identity, profile and grammar statistics
+type Me struct {
+	Subject         string         `json:"subject"`
+	Username        string         `json:"username"`
+	Tenant          string         `json:"tenant,omitempty"`
+	Scopes          []string       `json:"scopes"`
+	APIKeyID        string         `json:"apiKeyId,omitempty"`
+	Profile         *database.User `json:"profile"`
+	Quota           *Quota         `json:"quota,omitempty"`
+	PublicGrammars  int64          `json:"publicGrammars"`
+	PrivateGrammars int64          `json:"privateGrammars"`
+}
+
+func NewUserService(db *database.MongoDB) *UserService {
+	if db == nil {
+		log.Fatal("Database connection is nil")
+	}
+
+	return &UserService{
+		DB:       db,
+		lastSeen: make(map[string]time.Time),
+	}
+}
+
+// Touch upserts the caller's user record from its claims, at most once per lastSeenInterval
+func (s *UserService) Touch(ctx context.Context, caller *identity.Identity) error {
+	if caller == nil || caller.Subject == "" {
+		return nil
+	}
+
+	now := time.Now()
+	key := caller.Tenant + "/" + caller.Subject
+	s.mu.Lock()
+	if now.Sub(s.lastSeen[key]) < lastSeenInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	if len(s.lastSeen) > 10000 {
+		for k, seen := range s.lastSeen {
+			if now.Sub(seen) >= lastSeenInterval {
+				delete(s.lastSeen, k)
+			}
+		}
+	}
+	s.lastSeen[key] = now
+	s.mu.Unlock()
+
+	email, _ := caller.Claims["email"].(string)
+	return s.DB.UpsertUser(ctx, caller.Subject, caller.Username, email, now)
+}
+
+// Me describes the caller, creating its user record if this is its first request
+func (s *UserService) Me(ctx context.Context) (*Me, error) {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return nil, ErrNotOwner
+	}
+
+	user, err := s.DB.GetUser(ctx, caller.Subject)
+	if errors.Is(err, database.ErrUserNotFound) {
+		if err := s.DB.UpsertUser(ctx, caller.Subject, caller.Username, "", time.Now()); err != nil {
+			return nil, err
+		}
+		user, err = s.DB.GetUser(ctx, caller.Subject)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	public, private, err := s.DB.CountGrammarsByOwner(ctx, caller.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Me{
+		Subject:         caller.Subject,
+		Username:        caller.Username,
+		Tenant:          caller.Tenant,
+		Scopes:          caller.Scopes,
+		APIKeyID:        caller.APIKeyID,
+		Profile:         user,
+		PublicGrammars:  public,
+		PrivateGrammars: private,
+	}, nil
+}
+
+// UpdateProfile changes the caller's editable profile fields
+func (s *UserService) UpdateProfile(ctx context.Context, displayName, bio *string) (*database.User, error) {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return nil, ErrNotOwner
+	}
+
+	if displayName != nil && len(*displayName) > 100 {
+		return nil, fmt.Errorf("%w: display name cannot exceed 100 characters", ErrInvalidProfile)
+	}
+	if bio != nil && len(*bio) > 500 {
+		return nil, fmt.Errorf("%w: bio cannot exceed 500 characters", ErrInvalidProfile)
+	}
+
+	if err := s.Touch(ctx, caller); err != nil {
+		return nil, err
+	}
+	return s.DB.UpdateUserProfile(ctx, caller.Subject, displayName, bio)
+}
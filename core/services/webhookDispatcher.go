@@ -0,0 +1,323 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/metrics"
+	"io"
+	"log"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Headers sent with every delivery
+const (
+	WebhookEventHeader     = "X-GrammarHive-Event"
+	WebhookDeliveryHeader  = "X-GrammarHive-Delivery"
+	WebhookTimestampHeader = "X-GrammarHive-Timestamp"
+	WebhookSignatureHeader = "X-GrammarHive-Signature-256"
+)
+
+const (
+	webhookPollInterval = 5 * time.Second
+	webhookRetryBase    = 10 * time.Second
+	webhookRetryMax     = time.Hour
+	// maxResponseLog is how much of a receiver's response body is kept in the delivery log
+	maxResponseLog = 512
+)
+
+// errPrivateAddress is returned for deliveries to addresses in privateNetworks
+var errPrivateAddress = errors.New("webhook url resolves to a private network address")
+
+// privateNetworks are the ranges webhooks may not reach unless private networks are allowed:
+// this host, loopback, private, shared and link-local addresses, multicast and broadcast,
+// ranges reserved for protocols, documentation and benchmarking, and NAT64, which translates
+// to any IPv4 address including these
+var privateNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("255.255.255.255/32"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// WebhookDispatcher sends queued deliveries. Any number of dispatchers may share the queue,
+// in one process or several; a delivery is leased to one of them for each attempt
+type WebhookDispatcher struct {
+	DB          *database.MongoDB
+	Client      *http.Client
+	MaxAttempts int
+	// lease is how long an attempt may take before another dispatcher may retry it
+	lease time.Duration
+
+	wake    chan struct{}
+	stop    context.CancelFunc
+	workers sync.WaitGroup
+}
+
+// NewWebhookDispatcher gives up on a delivery after maxAttempts attempts, and on an attempt
+// after timeout. Unless allowPrivate is set, deliveries to addresses on private networks fail
+func NewWebhookDispatcher(db *database.MongoDB, maxAttempts int, timeout time.Duration, allowPrivate bool) *WebhookDispatcher {
+	if db == nil {
+		log.Fatal("Database connection is nil")
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = rejectPrivateAddresses
+	}
+
+	return &WebhookDispatcher{
+		DB: db,
+		Client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				// No proxy: it would be the proxy's address the dialer checks
+				DialContext:         dialer.DialContext,
+				ForceAttemptHTTP2:   true,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: timeout,
+			},
+			// A redirect could lead anywhere; receivers must answer at the registered URL
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		MaxAttempts: maxAttempts,
+		lease:       timeout + time.Minute,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Start starts workers that send deliveries until Stop. The queue's indexes are created
+// alongside them, so an unreachable database delays deliveries rather than startup
+func (d *WebhookDispatcher) Start(workers int) {
+	ctx, stop := context.WithCancel(context.Background())
+	d.stop = stop
+
+	d.workers.Add(1)
+	go func() {
+		defer d.workers.Done()
+		if err := d.DB.EnsureWebhookIndexes(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("failed to create webhook indexes", "error", err)
+		}
+	}()
+	for i := 0; i < workers; i++ {
+		d.workers.Add(1)
+		go func() {
+			defer d.workers.Done()
+			d.work(ctx)
+		}()
+	}
+}
+
+// Stop interrupts the workers and waits for them to return. Attempts they were making are
+// not recorded, and are retried by any dispatcher once their lease expires
+func (d *WebhookDispatcher) Stop(ctx context.Context) error {
+	if d.stop == nil {
+		return nil
+	}
+	d.stop()
+
+	done := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Wake has a worker look for due deliveries now rather than at its next poll
+func (d *WebhookDispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// work sends due deliveries one at a time, and waits to be woken or for the next poll when none are due
+func (d *WebhookDispatcher) work(ctx context.Context) {
+	for {
+		sent, err := d.deliverNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("failed to process webhook delivery", "error", err)
+		}
+		if sent {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-time.After(webhookPollInterval):
+		}
+	}
+}
+
+// deliverNext makes an attempt at the delivery due longest, reporting whether there was one
+func (d *WebhookDispatcher) deliverNext(ctx context.Context) (bool, error) {
+	delivery, err := d.DB.ClaimDelivery(ctx, time.Now(), d.lease)
+	if delivery == nil {
+		return false, err
+	}
+	logger := slog.With("delivery_id", delivery.DeliveryID, "webhook_id", delivery.WebhookID, "event", delivery.Event)
+
+	var (
+		attempt database.DeliveryAttempt
+		status  string
+		next    time.Time
+	)
+	webhook, err := d.DB.GetWebhook(ctx, delivery.WebhookID)
+	switch {
+	case errors.Is(err, database.ErrWebhookNotFound):
+		attempt = database.DeliveryAttempt{At: time.Now(), Error: "webhook was deleted"}
+		status, next = database.DeliveryFailed, attempt.At
+	case err != nil:
+		// Left leased; retried once the lease expires
+		return true, err
+	default:
+		attempt = d.send(ctx, webhook, delivery)
+		if ctx.Err() != nil {
+			return true, nil
+		}
+		status, next = d.outcome(delivery.Attempts+1, attempt)
+	}
+
+	metrics.WebhookAttempts.WithLabelValues(outcomeLabel(status)).Inc()
+	if status == database.DeliveryFailed {
+		logger.Warn("webhook delivery failed", "attempts", delivery.Attempts+1, "status_code", attempt.StatusCode, "error", attempt.Error)
+	}
+
+	return true, d.DB.RecordDeliveryAttempt(ctx, delivery.DeliveryID, attempt, status, next)
+}
+
+// send posts the delivery's payload to the webhook, signed with its secret
+func (d *WebhookDispatcher) send(ctx context.Context, webhook *database.Webhook, delivery *database.WebhookDelivery) (attempt database.DeliveryAttempt) {
+	start := time.Now()
+	attempt.At = start
+	defer func() { attempt.DurationMS = time.Since(start).Milliseconds() }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GrammarHive-Webhooks")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.DeliveryID)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, WebhookSignature(webhook.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseLog))
+	attempt.Response = string(body)
+	// Drain a little more so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return attempt
+}
+
+// outcome decides what follows the attempt-th attempt: success on any 2xx answer, otherwise
+// a retry with exponential backoff until the attempts run out
+func (d *WebhookDispatcher) outcome(attempts int, attempt database.DeliveryAttempt) (string, time.Time) {
+	if attempt.Error == "" && attempt.StatusCode >= 200 && attempt.StatusCode < 300 {
+		return database.DeliverySucceeded, attempt.At
+	}
+	if attempts >= d.MaxAttempts {
+		return database.DeliveryFailed, attempt.At
+	}
+	return database.DeliveryPending, attempt.At.Add(retryDelay(attempts))
+}
+
+// retryDelay doubles from webhookRetryBase up to webhookRetryMax, plus up to a quarter more
+// so deliveries that failed together are not all retried together
+func retryDelay(attempts int) time.Duration {
+	delay := webhookRetryMax
+	if attempts < 20 {
+		delay = min(webhookRetryBase<<(attempts-1), webhookRetryMax)
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay/4)+1))
+}
+
+func outcomeLabel(status string) string {
+	if status == database.DeliveryPending {
+		return "retried"
+	}
+	return status
+}
+
+// WebhookSignature is the value of the signature header: the hex HMAC-SHA256, keyed with the
+// webhook's secret, of the timestamp header, a ".", and the body. Receivers should compute it
+// themselves, compare in constant time, and reject old timestamps to prevent replays
+func WebhookSignature(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// rejectPrivateAddresses refuses connections to addresses a public webhook has no business
+// reaching; it runs after name resolution, so it holds for every address a name resolves to
+func rejectPrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return errPrivateAddress
+	}
+	// IPv4 addresses mapped into IPv6 are checked as IPv4, and zones would match no prefix
+	ip = ip.Unmap().WithZone("")
+	for _, prefix := range privateNetworks {
+		if prefix.Contains(ip) {
+			return errPrivateAddress
+		}
+	}
+	return nil
+}
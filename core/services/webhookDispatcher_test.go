@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/database/dbtest"
+	"grammarhive-backend/core/identity"
+)
+
+func TestRejectPrivateAddresses(t *testing.T) {
+	refused := []string{
+		"0.0.0.0", "0.1.2.3", "10.1.2.3", "100.64.0.1", "100.127.255.254", "127.0.0.1", "169.254.169.254",
+		"172.16.0.1", "192.0.0.8", "192.0.2.1", "192.168.1.1", "198.18.0.1", "198.19.255.255",
+		"198.51.100.7", "203.0.113.9", "224.0.0.251", "240.0.0.1", "255.255.255.255",
+		"::", "::1", "::ffff:10.0.0.1", "::ffff:127.0.0.1", "64:ff9b::a00:1", "2001:db8::1",
+		"fc00::1", "fd12:3456::1", "fe80::1", "fe80::1%eth0", "ff02::1",
+	}
+	for _, host := range refused {
+		if err := rejectPrivateAddresses("tcp", "["+host+"]:443", nil); err != errPrivateAddress {
+			t.Errorf("%s: got %v, want errPrivateAddress", host, err)
+		}
+	}
+
+	allowed := []string{"8.8.8.8", "100.63.255.255", "100.128.0.1", "172.32.0.1", "198.20.0.1", "2606:4700:4700::1111", "::ffff:1.1.1.1"}
+	for _, host := range allowed {
+		if err := rejectPrivateAddresses("tcp", "["+host+"]:443", nil); err != nil {
+			t.Errorf("%s: got %v, want it allowed", host, err)
+		}
+	}
+}
+
+func TestRetriesBackOffUntilTheAttemptsRunOut(t *testing.T) {
+	d := &WebhookDispatcher{MaxAttempts: 4}
+	at := time.Now()
+
+	if status, next := d.outcome(1, database.DeliveryAttempt{At: at, StatusCode: http.StatusNoContent}); status != database.DeliverySucceeded || !next.Equal(at) {
+		t.Fatalf("2xx: got %s at %s, want succeeded", status, next)
+	}
+	for attempts, base := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 3: 40 * time.Second} {
+		for _, attempt := range []database.DeliveryAttempt{
+			{At: at, StatusCode: http.StatusServiceUnavailable},
+			{At: at, StatusCode: http.StatusFound},
+			{At: at, Error: "connection refused"},
+		} {
+			status, next := d.outcome(attempts, attempt)
+			if delay := next.Sub(at); status != database.DeliveryPending || delay < base || delay > base+base/4 {
+				t.Fatalf("attempt %d %+v: got %s in %s, want a retry in %s plus up to a quarter", attempts, attempt, status, delay, base)
+			}
+		}
+	}
+	if status, _ := d.outcome(4, database.DeliveryAttempt{At: at, StatusCode: http.StatusInternalServerError}); status != database.DeliveryFailed {
+		t.Fatalf("last attempt: got %s, want failed", status)
+	}
+
+	for _, attempts := range []int{13, 20, 64} {
+		if delay := retryDelay(attempts); delay < webhookRetryMax || delay > webhookRetryMax+webhookRetryMax/4 {
+			t.Fatalf("retryDelay(%d) = %s, want the maximum of %s plus up to a quarter", attempts, delay, webhookRetryMax)
+		}
+	}
+}
+
+// received is a request a test receiver was sent
+type received struct {
+	header http.Header
+	body   string
+}
+
+// receiver serves webhook requests, answering each with the next status in statuses and
+// the last one once they run out
+func receiver(t *testing.T, statuses ...int) (*httptest.Server, chan received) {
+	t.Helper()
+	requests := make(chan received, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header.Clone(), body: string(body)}
+		status := statuses[0]
+		if len(statuses) > 1 {
+			statuses = statuses[1:]
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, http.StatusText(status))
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+// queueDelivery registers a webhook for url and queues a delivery to it that has already
+// been attempted attempts times
+func queueDelivery(t *testing.T, db *database.MongoDB, url string, attempts int) (*database.Webhook, *database.WebhookDelivery) {
+	t.Helper()
+	ctx := context.Background()
+	webhook := &database.Webhook{WebhookID: "w1", Owner: "sub", URL: url, Secret: "whsec_test", Events: WebhookEvents, CreatedAt: time.Now()}
+	if err := db.CreateWebhook(ctx, webhook); err != nil {
+		t.Fatal(err)
+	}
+	delivery, err := newDelivery(webhook, "e1", EventGrammarUpdated, "g1", `{"id":"e1","type":"grammar.updated"}`, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivery.Attempts = attempts
+	if err := db.QueueDeliveries(ctx, []database.WebhookDelivery{*delivery}); err != nil {
+		t.Fatal(err)
+	}
+	return webhook, delivery
+}
+
+// deliveryState returns the delivery as the delivery log shows it
+func deliveryState(t *testing.T, db *database.MongoDB, deliveryID string) *database.WebhookDelivery {
+	t.Helper()
+	delivery, err := db.GetDelivery(context.Background(), "", "sub", "w1", deliveryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return delivery
+}
+
+func TestDeliveriesAreSigned(t *testing.T) {
+	db := dbtest.Connect(t)
+	server, requests := receiver(t, http.StatusNoContent)
+	webhook, delivery := queueDelivery(t, db, server.URL+"/hook", 0)
+	d := NewWebhookDispatcher(db, 3, 5*time.Second, true)
+
+	if sent, err := d.deliverNext(context.Background()); !sent || err != nil {
+		t.Fatalf("deliverNext = %v, %v, want a delivery sent", sent, err)
+	}
+
+	req := <-requests
+	if req.body != delivery.Payload {
+		t.Fatalf("body = %s, want the queued payload", req.body)
+	}
+	if req.header.Get(WebhookEventHeader) != EventGrammarUpdated || req.header.Get(WebhookDeliveryHeader) != delivery.DeliveryID {
+		t.Fatalf("event and delivery headers = %v", req.header)
+	}
+	timestamp, err := strconv.ParseInt(req.header.Get(WebhookTimestampHeader), 10, 64)
+	if err != nil || time.Since(time.Unix(timestamp, 0)) > time.Minute {
+		t.Fatalf("timestamp header = %q, want the current Unix time", req.header.Get(WebhookTimestampHeader))
+	}
+
+	// Verified as the README tells receivers to
+	mac := hmac.New(sha256.New, []byte(webhook.Secret))
+	mac.Write([]byte(req.header.Get(WebhookTimestampHeader) + "." + req.body))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(req.header.Get(WebhookSignatureHeader)), []byte(want)) {
+		t.Fatalf("signature = %s, want %s", req.header.Get(WebhookSignatureHeader), want)
+	}
+	if WebhookSignature("whsec_other", timestamp, []byte(req.body)) == want {
+		t.Fatal("another secret gives the same signature")
+	}
+
+	state := deliveryState(t, db, delivery.DeliveryID)
+	if state.Status != database.DeliverySucceeded || state.Attempts != 1 || state.DeliveredAt == nil ||
+		len(state.Log) != 1 || state.Log[0].StatusCode != http.StatusNoContent {
+		t.Fatalf("delivery = %+v, want succeeded after one 204", state)
+	}
+}
+
+func TestFailedDeliveriesAreRetriedWithBackoff(t *testing.T) {
+	db := dbtest.Connect(t)
+	server, requests := receiver(t, http.StatusServiceUnavailable)
+	_, delivery := queueDelivery(t, db, server.URL, 0)
+	d := NewWebhookDispatcher(db, 3, 5*time.Second, true)
+
+	before := time.Now()
+	if sent, err := d.deliverNext(context.Background()); !sent || err != nil {
+		t.Fatalf("deliverNext = %v, %v, want a delivery sent", sent, err)
+	}
+	<-requests
+
+	state := deliveryState(t, db, delivery.DeliveryID)
+	if state.Status != database.DeliveryPending || state.Attempts != 1 || len(state.Log) != 1 ||
+		state.Log[0].StatusCode != http.StatusServiceUnavailable || state.Log[0].Response != "Service Unavailable" {
+		t.Fatalf("delivery = %+v, want pending after one logged 503", state)
+	}
+	if delay := state.NextAttemptAt.Sub(before); delay < webhookRetryBase || delay > webhookRetryBase+webhookRetryBase/4+time.Second {
+		t.Fatalf("next attempt in %s, want about %s", delay, webhookRetryBase)
+	}
+
+	// Not due again until then
+	if sent, err := d.deliverNext(context.Background()); sent || err != nil {
+		t.Fatalf("deliverNext = %v, %v, want nothing due", sent, err)
+	}
+}
+
+func TestDeliveriesFailAfterMaxAttempts(t *testing.T) {
+	db := dbtest.Connect(t)
+	server, requests := receiver(t, http.StatusInternalServerError)
+	_, delivery := queueDelivery(t, db, server.URL, 2)
+	d := NewWebhookDispatcher(db, 3, 5*time.Second, true)
+
+	if sent, err := d.deliverNext(context.Background()); !sent || err != nil {
+		t.Fatalf("deliverNext = %v, %v, want a delivery sent", sent, err)
+	}
+	<-requests
+
+	state := deliveryState(t, db, delivery.DeliveryID)
+	if state.Status != database.DeliveryFailed || state.Attempts != 3 || state.DeliveredAt != nil {
+		t.Fatalf("delivery = %+v, want failed after the third attempt", state)
+	}
+	if sent, err := d.deliverNext(context.Background()); sent || err != nil {
+		t.Fatalf("deliverNext = %v, %v, want nothing left to send", sent, err)
+	}
+}
+
+func TestDeliveriesToPrivateAddressesFail(t *testing.T) {
+	db := dbtest.Connect(t)
+	server, requests := receiver(t, http.StatusNoContent)
+	_, delivery := queueDelivery(t, db, server.URL, 0)
+	d := NewWebhookDispatcher(db, 1, 5*time.Second, false)
+
+	if sent, err := d.deliverNext(context.Background()); !sent || err != nil {
+		t.Fatalf("deliverNext = %v, %v, want an attempt made", sent, err)
+	}
+	select {
+	case <-requests:
+		t.Fatal("a loopback receiver was called")
+	default:
+	}
+
+	state := deliveryState(t, db, delivery.DeliveryID)
+	if state.Status != database.DeliveryFailed || len(state.Log) != 1 || !strings.Contains(state.Log[0].Error, errPrivateAddress.Error()) {
+		t.Fatalf("delivery = %+v, want failed with %q", state, errPrivateAddress)
+	}
+}
+
+func TestRedeliveriesResendThePayload(t *testing.T) {
+	db := dbtest.Connect(t)
+	server, requests := receiver(t, http.StatusBadGateway, http.StatusOK)
+	_, original := queueDelivery(t, db, server.URL, 0)
+	d := NewWebhookDispatcher(db, 1, 5*time.Second, true)
+	s := NewWebhookService(db)
+	ctx := identity.WithIdentity(context.Background(), &identity.Identity{Subject: "sub"})
+
+	if sent, err := d.deliverNext(ctx); !sent || err != nil {
+		t.Fatalf("deliverNext = %v, %v, want a delivery sent", sent, err)
+	}
+	first := <-requests
+	if state := deliveryState(t, db, original.DeliveryID); state.Status != database.DeliveryFailed {
+		t.Fatalf("delivery = %+v, want failed", state)
+	}
+
+	other := identity.WithIdentity(context.Background(), &identity.Identity{Subject: "other"})
+	if _, err := s.Redeliver(other, "w1", original.DeliveryID); err != database.ErrWebhookNotFound {
+		t.Fatalf("another caller's redelivery: got %v, want ErrWebhookNotFound", err)
+	}
+
+	redelivery, err := s.Redeliver(ctx, "w1", original.DeliveryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redelivery.DeliveryID == original.DeliveryID || redelivery.RedeliveryOf != original.DeliveryID || redelivery.EventID != original.EventID {
+		t.Fatalf("redelivery = %+v, want a new delivery of event %s", redelivery, original.EventID)
+	}
+
+	if sent, err := d.deliverNext(ctx); !sent || err != nil {
+		t.Fatalf("deliverNext = %v, %v, want the redelivery sent", sent, err)
+	}
+	second := <-requests
+	if second.body != first.body || second.header.Get(WebhookDeliveryHeader) != redelivery.DeliveryID {
+		t.Fatalf("redelivered %s as %s, want %s as %s", second.body, second.header.Get(WebhookDeliveryHeader), first.body, redelivery.DeliveryID)
+	}
+	if state := deliveryState(t, db, redelivery.DeliveryID); state.Status != database.DeliverySucceeded {
+		t.Fatalf("redelivery = %+v, want succeeded", state)
+	}
+}
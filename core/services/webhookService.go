@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"grammarhive-backend/core/database"
+	"grammarhive-backend/core/identity"
+	"grammarhive-backend/core/logging"
+	"log"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// Grammar events webhooks can subscribe to
+const (
+	EventGrammarPublished = "grammar.published"
+	EventGrammarUpdated   = "grammar.updated"
+	EventGrammarDeleted   = "grammar.deleted"
+)
+
+// WebhookEvents lists every event, in the order they are documented
+var WebhookEvents = []string{EventGrammarPublished, EventGrammarUpdated, EventGrammarDeleted}
+
+const (
+	webhookSecretPrefix = "whsec_"
+	maxWebhooks         = 20
+	maxWebhookURLLength = 2048
+	// deliveryRetention is how long deliveries are kept in the delivery log
+	deliveryRetention = 30 * 24 * time.Hour
+)
+
+// ErrInvalidWebhook is wrapped by errors for webhooks that cannot be registered
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+type WebhookService struct {
+	DB *database.MongoDB
+	// Dispatcher, when set, is woken as soon as deliveries are queued instead of at its next poll
+	Dispatcher *WebhookDispatcher
+}
+
+func NewWebhookService(db *database.MongoDB) *WebhookService {
+	if db == nil {
+		log.Fatal("Database connection is nil")
+	}
+
+	return &WebhookService{
+		DB: db,
+	}
+}
+
+// WebhookPayload is the JSON body delivered for an event
+type WebhookPayload struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Grammar   WebhookGrammar `json:"grammar"`
+}
+
+// WebhookGrammar describes the grammar an event is about; its content is left out, as
+// receivers may fetch it with their own credentials
+type WebhookGrammar struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Version  int    `json:"version"`
+	Username string `json:"username"`
+	Private  bool   `json:"private"`
+}
+
+// Create registers a webhook for the caller and returns it with its signing secret, which is
+// only ever returned here. An empty grammarID subscribes to every grammar of the caller, and
+// no events to every event
+func (s *WebhookService) Create(ctx context.Context, rawURL, grammarID string, events []string) (*database.Webhook, string, error) {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return nil, "", ErrNotOwner
+	}
+	if err := validateWebhookURL(rawURL); err != nil {
+		return nil, "", err
+	}
+	if len(events) == 0 {
+		events = WebhookEvents
+	}
+	for _, event := range events {
+		if !slices.Contains(WebhookEvents, event) {
+			return nil, "", fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+	}
+	if grammarID != "" {
+		g, err := visibleGrammar(ctx, s.DB, grammarID)
+		if err != nil {
+			return nil, "", err
+		}
+		if !caller.Owns(g.Owner) {
+			return nil, "", ErrNotOwner
+		}
+	}
+
+	existing, err := s.DB.ListWebhooks(ctx, caller.Tenant, caller.Subject)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(existing) >= maxWebhooks {
+		return nil, "", fmt.Errorf("%w: at most %d webhooks may be registered", ErrInvalidWebhook, maxWebhooks)
+	}
+
+	events = slices.Clone(events)
+	slices.Sort(events)
+
+	webhookID, err := randomString(8)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomString(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	webhook := &database.Webhook{
+		WebhookID: webhookID,
+		Owner:     caller.Subject,
+		Tenant:    caller.Tenant,
+		GrammarID: grammarID,
+		URL:       rawURL,
+		Secret:    webhookSecretPrefix + secret,
+		Events:    slices.Compact(events),
+		CreatedAt: time.Now(),
+	}
+	if err := s.DB.CreateWebhook(ctx, webhook); err != nil {
+		return nil, "", err
+	}
+	return webhook, webhook.Secret, nil
+}
+
+// List returns the caller's webhooks without their secrets
+func (s *WebhookService) List(ctx context.Context) ([]database.Webhook, error) {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return nil, ErrNotOwner
+	}
+	return s.DB.ListWebhooks(ctx, caller.Tenant, caller.Subject)
+}
+
+// Delete removes one of the caller's webhooks
+func (s *WebhookService) Delete(ctx context.Context, webhookID string) error {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return ErrNotOwner
+	}
+	return s.DB.DeleteWebhook(ctx, caller.Tenant, caller.Subject, webhookID)
+}
+
+// Deliveries returns the latest deliveries of one of the caller's webhooks
+func (s *WebhookService) Deliveries(ctx context.Context, webhookID string, limit int64) ([]database.WebhookDelivery, error) {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return nil, ErrNotOwner
+	}
+	if _, err := s.ownedWebhook(ctx, caller, webhookID); err != nil {
+		return nil, err
+	}
+	return s.DB.ListDeliveries(ctx, caller.Tenant, caller.Subject, webhookID, limit)
+}
+
+// Redeliver queues the payload of one of the caller's deliveries again, as a new delivery
+func (s *WebhookService) Redeliver(ctx context.Context, webhookID, deliveryID string) (*database.WebhookDelivery, error) {
+	caller := identity.FromContext(ctx)
+	if caller == nil || caller.Subject == "" {
+		return nil, ErrNotOwner
+	}
+	webhook, err := s.ownedWebhook(ctx, caller, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	original, err := s.DB.GetDelivery(ctx, caller.Tenant, caller.Subject, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := newDelivery(webhook, original.EventID, original.Event, original.GrammarID, original.Payload, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	delivery.RedeliveryOf = original.DeliveryID
+	if err := s.DB.QueueDeliveries(ctx, []database.WebhookDelivery{*delivery}); err != nil {
+		return nil, err
+	}
+	s.wake()
+	return delivery, nil
+}
+
+// Notify queues a delivery of event to every webhook of the grammar's owner subscribed to it.
+// Failures are logged, not returned, so webhooks never change the outcome of the mutation
+// that triggered them
+func (s *WebhookService) Notify(ctx context.Context, event string, g *database.Grammar) {
+	// The mutation has happened; queue its deliveries even if the caller has gone away
+	ctx = context.WithoutCancel(ctx)
+	logger := logging.FromContext(ctx).With("event", event, "grammar_id", g.GrammarID)
+
+	webhooks, err := s.DB.FindWebhooksFor(ctx, database.TenantFromContext(ctx), g.Owner, g.GrammarID, event)
+	if err != nil {
+		logger.Warn("failed to find webhooks", "error", err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	eventID, err := randomString(16)
+	if err != nil {
+		logger.Warn("failed to queue webhook deliveries", "error", err)
+		return
+	}
+	now := time.Now()
+	payload, err := json.Marshal(WebhookPayload{
+		ID:        eventID,
+		Type:      event,
+		CreatedAt: now,
+		Grammar: WebhookGrammar{
+			ID:       g.GrammarID,
+			Name:     g.Name,
+			Version:  g.Version,
+			Username: g.Username,
+			Private:  g.Private,
+		},
+	})
+	if err != nil {
+		logger.Warn("failed to queue webhook deliveries", "error", err)
+		return
+	}
+
+	deliveries := make([]database.WebhookDelivery, 0, len(webhooks))
+	for i := range webhooks {
+		delivery, err := newDelivery(&webhooks[i], eventID, event, g.GrammarID, string(payload), now)
+		if err != nil {
+			logger.Warn("failed to queue webhook deliveries", "error", err)
+			return
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	if err := s.DB.QueueDeliveries(ctx, deliveries); err != nil {
+		logger.Warn("failed to queue webhook deliveries", "error", err)
+		return
+	}
+	s.wake()
+}
+
+func (s *WebhookService) wake() {
+	if s.Dispatcher != nil {
+		s.Dispatcher.Wake()
+	}
+}
+
+// ownedWebhook fetches one of the caller's webhooks; others' webhooks are reported as not found
+func (s *WebhookService) ownedWebhook(ctx context.Context, caller *identity.Identity, webhookID string) (*database.Webhook, error) {
+	webhook, err := s.DB.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.Owner != caller.Subject || webhook.Tenant != caller.Tenant {
+		return nil, database.ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// newDelivery returns a delivery of payload to webhook, due now
+func newDelivery(webhook *database.Webhook, eventID, event, grammarID, payload string, now time.Time) (*database.WebhookDelivery, error) {
+	deliveryID, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+	return &database.WebhookDelivery{
+		DeliveryID:    deliveryID,
+		WebhookID:     webhook.WebhookID,
+		Owner:         webhook.Owner,
+		Tenant:        webhook.Tenant,
+		EventID:       eventID,
+		Event:         event,
+		GrammarID:     grammarID,
+		Payload:       payload,
+		Status:        database.DeliveryPending,
+		NextAttemptAt: now,
+		Log:           []database.DeliveryAttempt{},
+		CreatedAt:     now,
+		ExpireAt:      now.Add(deliveryRetention),
+	}, nil
+}
+
+// validateWebhookURL accepts absolute http and https URLs without credentials; where they may
+// point is checked when they are delivered to, as names can resolve differently later
+func validateWebhookURL(rawURL string) error {
+	if len(rawURL) > maxWebhookURLLength {
+		return fmt.Errorf("%w: url must be at most %d characters", ErrInvalidWebhook, maxWebhookURLLength)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: url must not contain credentials", ErrInvalidWebhook)
+	}
+	return nil
+}
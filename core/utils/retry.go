@@ -3,10 +3,18 @@ package utils
 import (
 	"context"
 	"time"
+
+	"grammarhive-backend/core/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Retry executes the provided function with retries on failure
+// Retry executes the provided function with retries on failure, recording each failed
+// attempt as an event on the current span
 func Retry(ctx context.Context, attempts int, sleep time.Duration, fn func() error) error {
+	span := trace.SpanFromContext(ctx)
+
 	var err error
 	for i := 0; i < attempts; i++ {
 		err = fn()
@@ -14,6 +22,14 @@ func Retry(ctx context.Context, attempts int, sleep time.Duration, fn func() err
 			// successful execution
 			return nil
 		}
+		span.AddEvent("retry.attempt_failed", trace.WithAttributes(
+			attribute.Int("retry.attempt", i+1),
+			attribute.String("error", err.Error()),
+		))
+		if i == attempts-1 {
+			break
+		}
+		metrics.Retries.WithLabelValues("retry").Inc()
 		select {
 		case <-ctx.Done():
 			// return if context is done
@@ -23,5 +39,7 @@ func Retry(ctx context.Context, attempts int, sleep time.Duration, fn func() err
 			sleep *= 2
 		}
 	}
+	metrics.Retries.WithLabelValues("exhausted").Inc()
+	span.AddEvent("retry.exhausted", trace.WithAttributes(attribute.Int("retry.attempts", attempts)))
 	return err // return the last error
 }
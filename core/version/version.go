@@ -0,0 +1,43 @@
+// core/version/version.go
+package version
+
+import (
+	"os"
+	"runtime/debug"
+)
+
+// Version and Commit are set at build time with
+// -ldflags "-X grammarhive-backend/core/version.Version=v1.2.3 -X grammarhive-backend/core/version.Commit=abc123"
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+// Build describes the running binary
+type Build struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Current returns the build information, falling back to the commit Vercel deployed
+// or the VCS revision stamped by the Go toolchain when Commit was not set at build time
+func Current() Build {
+	build := Build{Version: Version, Commit: Commit}
+
+	info, ok := debug.ReadBuildInfo()
+	if ok {
+		build.GoVersion = info.GoVersion
+	}
+	if build.Commit == "" {
+		build.Commit = os.Getenv("VERCEL_GIT_COMMIT_SHA")
+	}
+	if build.Commit == "" && ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				build.Commit = setting.Value
+			}
+		}
+	}
+	return build
+}
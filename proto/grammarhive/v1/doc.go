@@ -0,0 +1,7 @@
+// Package grammarhivev1 holds the gRPC API generated from grammarhive.proto.
+// Regenerate it after editing the .proto with protoc, protoc-gen-go and protoc-gen-go-grpc:
+//
+//	go generate ./proto/...
+package grammarhivev1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative grammarhive/v1/grammarhive.proto
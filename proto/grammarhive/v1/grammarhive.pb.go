@@ -0,0 +1,1499 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: grammarhive/v1/grammarhive.proto
+
+package grammarhivev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GenerateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Source:
+	//	*GenerateRequest_GrammarId
+	//	*GenerateRequest_Content
+	Source isGenerateRequest_Source `protobuf_oneof:"source"`
+	// Number of texts, from 1 to 100 for Generate and 1 to 1000 for GenerateStream; 0 means 1.
+	Count int32 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	// Seeds the random choices; one is drawn when unset. The same seed, grammar and
+	// options always produce the same texts.
+	Seed *int64 `protobuf:"varint,4,opt,name=seed,proto3,oneof" json:"seed,omitempty"`
+	// Non-terminal to expand instead of <start>.
+	StartSymbol string `protobuf:"bytes,5,opt,name=start_symbol,json=startSymbol,proto3" json:"start_symbol,omitempty"`
+	// Fixed text for the named non-terminals, overriding the grammar's rules for them.
+	Variables map[string]string `protobuf:"bytes,6,rep,name=variables,proto3" json:"variables,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *GenerateRequest) Reset() {
+	*x = GenerateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GenerateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateRequest) ProtoMessage() {}
+
+func (x *GenerateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateRequest.ProtoReflect.Descriptor instead.
+func (*GenerateRequest) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{0}
+}
+
+func (m *GenerateRequest) GetSource() isGenerateRequest_Source {
+	if m != nil {
+		return m.Source
+	}
+	return nil
+}
+
+func (x *GenerateRequest) GetGrammarId() string {
+	if x, ok := x.GetSource().(*GenerateRequest_GrammarId); ok {
+		return x.GrammarId
+	}
+	return ""
+}
+
+func (x *GenerateRequest) GetContent() string {
+	if x, ok := x.GetSource().(*GenerateRequest_Content); ok {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *GenerateRequest) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetSeed() int64 {
+	if x != nil && x.Seed != nil {
+		return *x.Seed
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetStartSymbol() string {
+	if x != nil {
+		return x.StartSymbol
+	}
+	return ""
+}
+
+func (x *GenerateRequest) GetVariables() map[string]string {
+	if x != nil {
+		return x.Variables
+	}
+	return nil
+}
+
+type isGenerateRequest_Source interface {
+	isGenerateRequest_Source()
+}
+
+type GenerateRequest_GrammarId struct {
+	// A stored grammar to generate from.
+	GrammarId string `protobuf:"bytes,1,opt,name=grammar_id,json=grammarId,proto3,oneof"`
+}
+
+type GenerateRequest_Content struct {
+	// Grammar source to generate from without storing it.
+	Content string `protobuf:"bytes,2,opt,name=content,proto3,oneof"`
+}
+
+func (*GenerateRequest_GrammarId) isGenerateRequest_Source() {}
+
+func (*GenerateRequest_Content) isGenerateRequest_Source() {}
+
+type GenerateResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The grammar's ID, or "(inline)" for inline content.
+	GrammarId string `protobuf:"bytes,1,opt,name=grammar_id,json=grammarId,proto3" json:"grammar_id,omitempty"`
+	// The stored grammar's version; 0 for inline content.
+	Version int32    `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Seed    int64    `protobuf:"varint,3,opt,name=seed,proto3" json:"seed,omitempty"`
+	Texts   []string `protobuf:"bytes,4,rep,name=texts,proto3" json:"texts,omitempty"`
+}
+
+func (x *GenerateResponse) Reset() {
+	*x = GenerateResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GenerateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateResponse) ProtoMessage() {}
+
+func (x *GenerateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateResponse.ProtoReflect.Descriptor instead.
+func (*GenerateResponse) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GenerateResponse) GetGrammarId() string {
+	if x != nil {
+		return x.GrammarId
+	}
+	return ""
+}
+
+func (x *GenerateResponse) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *GenerateResponse) GetSeed() int64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+func (x *GenerateResponse) GetTexts() []string {
+	if x != nil {
+		return x.Texts
+	}
+	return nil
+}
+
+type GeneratedText struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Position of the text in the stream, from 0.
+	Index     int32  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Text      string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	GrammarId string `protobuf:"bytes,3,opt,name=grammar_id,json=grammarId,proto3" json:"grammar_id,omitempty"`
+	Version   int32  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	Seed      int64  `protobuf:"varint,5,opt,name=seed,proto3" json:"seed,omitempty"`
+}
+
+func (x *GeneratedText) Reset() {
+	*x = GeneratedText{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GeneratedText) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeneratedText) ProtoMessage() {}
+
+func (x *GeneratedText) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeneratedText.ProtoReflect.Descriptor instead.
+func (*GeneratedText) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GeneratedText) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *GeneratedText) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *GeneratedText) GetGrammarId() string {
+	if x != nil {
+		return x.GrammarId
+	}
+	return ""
+}
+
+func (x *GeneratedText) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *GeneratedText) GetSeed() int64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+type ParseRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Content string `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+}
+
+func (x *ParseRequest) Reset() {
+	*x = ParseRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ParseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseRequest) ProtoMessage() {}
+
+func (x *ParseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseRequest.ProtoReflect.Descriptor instead.
+func (*ParseRequest) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ParseRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type ParseResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	StartSymbol string `protobuf:"bytes,1,opt,name=start_symbol,json=startSymbol,proto3" json:"start_symbol,omitempty"`
+	// Rules ordered by name.
+	Rules []*Rule `protobuf:"bytes,2,rep,name=rules,proto3" json:"rules,omitempty"`
+}
+
+func (x *ParseResponse) Reset() {
+	*x = ParseResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ParseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseResponse) ProtoMessage() {}
+
+func (x *ParseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseResponse.ProtoReflect.Descriptor instead.
+func (*ParseResponse) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ParseResponse) GetStartSymbol() string {
+	if x != nil {
+		return x.StartSymbol
+	}
+	return ""
+}
+
+func (x *ParseResponse) GetRules() []*Rule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+type Rule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The non-terminal's name, without angle brackets.
+	Name        string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Productions []string `protobuf:"bytes,2,rep,name=productions,proto3" json:"productions,omitempty"`
+}
+
+func (x *Rule) Reset() {
+	*x = Rule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Rule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rule) ProtoMessage() {}
+
+func (x *Rule) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rule.ProtoReflect.Descriptor instead.
+func (*Rule) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Rule) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Rule) GetProductions() []string {
+	if x != nil {
+		return x.Productions
+	}
+	return nil
+}
+
+type ValidateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Content string `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+}
+
+func (x *ValidateRequest) Reset() {
+	*x = ValidateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValidateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateRequest) ProtoMessage() {}
+
+func (x *ValidateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateRequest.ProtoReflect.Descriptor instead.
+func (*ValidateRequest) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ValidateRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type ValidateResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Valid bool `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	// Why the content does not compile, when it is not valid.
+	Error string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *ValidateResponse) Reset() {
+	*x = ValidateResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValidateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateResponse) ProtoMessage() {}
+
+func (x *ValidateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateResponse.ProtoReflect.Descriptor instead.
+func (*ValidateResponse) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ValidateResponse) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+func (x *ValidateResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type Grammar struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	GrammarId string `protobuf:"bytes,1,opt,name=grammar_id,json=grammarId,proto3" json:"grammar_id,omitempty"`
+	Version   int32  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Name      string `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Username  string `protobuf:"bytes,4,opt,name=username,proto3" json:"username,omitempty"`
+	// Subject of the grammar's owner.
+	Owner     string                 `protobuf:"bytes,5,opt,name=owner,proto3" json:"owner,omitempty"`
+	Private   bool                   `protobuf:"varint,6,opt,name=private,proto3" json:"private,omitempty"`
+	Content   string                 `protobuf:"bytes,7,opt,name=content,proto3" json:"content,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+}
+
+func (x *Grammar) Reset() {
+	*x = Grammar{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Grammar) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Grammar) ProtoMessage() {}
+
+func (x *Grammar) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Grammar.ProtoReflect.Descriptor instead.
+func (*Grammar) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Grammar) GetGrammarId() string {
+	if x != nil {
+		return x.GrammarId
+	}
+	return ""
+}
+
+func (x *Grammar) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Grammar) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Grammar) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Grammar) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Grammar) GetPrivate() bool {
+	if x != nil {
+		return x.Private
+	}
+	return false
+}
+
+func (x *Grammar) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Grammar) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Grammar) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type CreateGrammarRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name    string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Content string `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Private bool   `protobuf:"varint,3,opt,name=private,proto3" json:"private,omitempty"`
+}
+
+func (x *CreateGrammarRequest) Reset() {
+	*x = CreateGrammarRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateGrammarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateGrammarRequest) ProtoMessage() {}
+
+func (x *CreateGrammarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateGrammarRequest.ProtoReflect.Descriptor instead.
+func (*CreateGrammarRequest) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CreateGrammarRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateGrammarRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *CreateGrammarRequest) GetPrivate() bool {
+	if x != nil {
+		return x.Private
+	}
+	return false
+}
+
+type GetGrammarRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	GrammarId string `protobuf:"bytes,1,opt,name=grammar_id,json=grammarId,proto3" json:"grammar_id,omitempty"`
+}
+
+func (x *GetGrammarRequest) Reset() {
+	*x = GetGrammarRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetGrammarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGrammarRequest) ProtoMessage() {}
+
+func (x *GetGrammarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGrammarRequest.ProtoReflect.Descriptor instead.
+func (*GetGrammarRequest) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetGrammarRequest) GetGrammarId() string {
+	if x != nil {
+		return x.GrammarId
+	}
+	return ""
+}
+
+type ListGrammarsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Whose grammars to list; the caller's when empty.
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+}
+
+func (x *ListGrammarsRequest) Reset() {
+	*x = ListGrammarsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListGrammarsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGrammarsRequest) ProtoMessage() {}
+
+func (x *ListGrammarsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGrammarsRequest.ProtoReflect.Descriptor instead.
+func (*ListGrammarsRequest) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListGrammarsRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+type ListGrammarsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Grammars []*Grammar `protobuf:"bytes,1,rep,name=grammars,proto3" json:"grammars,omitempty"`
+}
+
+func (x *ListGrammarsResponse) Reset() {
+	*x = ListGrammarsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListGrammarsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGrammarsResponse) ProtoMessage() {}
+
+func (x *ListGrammarsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGrammarsResponse.ProtoReflect.Descriptor instead.
+func (*ListGrammarsResponse) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListGrammarsResponse) GetGrammars() []*Grammar {
+	if x != nil {
+		return x.Grammars
+	}
+	return nil
+}
+
+type UpdateGrammarRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	GrammarId string `protobuf:"bytes,1,opt,name=grammar_id,json=grammarId,proto3" json:"grammar_id,omitempty"`
+	// The version being replaced; the update fails with ABORTED if the grammar has moved on.
+	// 0 skips the check.
+	Version int32 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	// Fields left unset keep their current value.
+	Name    *string `protobuf:"bytes,3,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	Content *string `protobuf:"bytes,4,opt,name=content,proto3,oneof" json:"content,omitempty"`
+	Private *bool   `protobuf:"varint,5,opt,name=private,proto3,oneof" json:"private,omitempty"`
+}
+
+func (x *UpdateGrammarRequest) Reset() {
+	*x = UpdateGrammarRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdateGrammarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateGrammarRequest) ProtoMessage() {}
+
+func (x *UpdateGrammarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateGrammarRequest.ProtoReflect.Descriptor instead.
+func (*UpdateGrammarRequest) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UpdateGrammarRequest) GetGrammarId() string {
+	if x != nil {
+		return x.GrammarId
+	}
+	return ""
+}
+
+func (x *UpdateGrammarRequest) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateGrammarRequest) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *UpdateGrammarRequest) GetContent() string {
+	if x != nil && x.Content != nil {
+		return *x.Content
+	}
+	return ""
+}
+
+func (x *UpdateGrammarRequest) GetPrivate() bool {
+	if x != nil && x.Private != nil {
+		return *x.Private
+	}
+	return false
+}
+
+type DeleteGrammarRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	GrammarId string `protobuf:"bytes,1,opt,name=grammar_id,json=grammarId,proto3" json:"grammar_id,omitempty"`
+}
+
+func (x *DeleteGrammarRequest) Reset() {
+	*x = DeleteGrammarRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteGrammarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteGrammarRequest) ProtoMessage() {}
+
+func (x *DeleteGrammarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteGrammarRequest.ProtoReflect.Descriptor instead.
+func (*DeleteGrammarRequest) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DeleteGrammarRequest) GetGrammarId() string {
+	if x != nil {
+		return x.GrammarId
+	}
+	return ""
+}
+
+type DeleteGrammarResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *DeleteGrammarResponse) Reset() {
+	*x = DeleteGrammarResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteGrammarResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteGrammarResponse) ProtoMessage() {}
+
+func (x *DeleteGrammarResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grammarhive_v1_grammarhive_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteGrammarResponse.ProtoReflect.Descriptor instead.
+func (*DeleteGrammarResponse) Descriptor() ([]byte, []int) {
+	return file_grammarhive_v1_grammarhive_proto_rawDescGZIP(), []int{15}
+}
+
+var File_grammarhive_v1_grammarhive_proto protoreflect.FileDescriptor
+
+var file_grammarhive_v1_grammarhive_proto_rawDesc = []byte{
+	0x0a, 0x20, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2f, 0x76, 0x31,
+	0x2f, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x0e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2e,
+	0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x22, 0xbf, 0x02, 0x0a, 0x0f, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0a, 0x67, 0x72, 0x61, 0x6d, 0x6d,
+	0x61, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x09, 0x67,
+	0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x49, 0x64, 0x12, 0x1a, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74,
+	0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x07, 0x63, 0x6f, 0x6e,
+	0x74, 0x65, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x17, 0x0a, 0x04, 0x73, 0x65,
+	0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x48, 0x01, 0x52, 0x04, 0x73, 0x65, 0x65, 0x64,
+	0x88, 0x01, 0x01, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x73, 0x79, 0x6d,
+	0x62, 0x6f, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x53, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x4c, 0x0a, 0x09, 0x76, 0x61, 0x72, 0x69, 0x61, 0x62,
+	0x6c, 0x65, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2e, 0x2e, 0x67, 0x72, 0x61, 0x6d,
+	0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72,
+	0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x56, 0x61, 0x72, 0x69, 0x61,
+	0x62, 0x6c, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x09, 0x76, 0x61, 0x72, 0x69, 0x61,
+	0x62, 0x6c, 0x65, 0x73, 0x1a, 0x3c, 0x0a, 0x0e, 0x56, 0x61, 0x72, 0x69, 0x61, 0x62, 0x6c, 0x65,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02,
+	0x38, 0x01, 0x42, 0x08, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x42, 0x07, 0x0a, 0x05,
+	0x5f, 0x73, 0x65, 0x65, 0x64, 0x22, 0x75, 0x0a, 0x10, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74,
+	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x67, 0x72, 0x61,
+	0x6d, 0x6d, 0x61, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67,
+	0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x65, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x04, 0x73, 0x65, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x65, 0x78, 0x74, 0x73, 0x18,
+	0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x74, 0x65, 0x78, 0x74, 0x73, 0x22, 0x86, 0x01, 0x0a,
+	0x0d, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x64, 0x54, 0x65, 0x78, 0x74, 0x12, 0x14,
+	0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x69,
+	0x6e, 0x64, 0x65, 0x78, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x67, 0x72, 0x61, 0x6d,
+	0x6d, 0x61, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67, 0x72,
+	0x61, 0x6d, 0x6d, 0x61, 0x72, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x65, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x04, 0x73, 0x65, 0x65, 0x64, 0x22, 0x28, 0x0a, 0x0c, 0x50, 0x61, 0x72, 0x73, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x22,
+	0x5e, 0x0a, 0x0d, 0x50, 0x61, 0x72, 0x73, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x72, 0x74, 0x53, 0x79, 0x6d,
+	0x62, 0x6f, 0x6c, 0x12, 0x2a, 0x0a, 0x05, 0x72, 0x75, 0x6c, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x14, 0x2e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65,
+	0x2e, 0x76, 0x31, 0x2e, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x05, 0x72, 0x75, 0x6c, 0x65, 0x73, 0x22,
+	0x3c, 0x0a, 0x04, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x70,
+	0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x0b, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x2b, 0x0a,
+	0x0f, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x22, 0x3e, 0x0a, 0x10, 0x56, 0x61,
+	0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0xb2, 0x02, 0x0a, 0x07, 0x47,
+	0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61,
+	0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67, 0x72, 0x61, 0x6d,
+	0x6d, 0x61, 0x72, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12,
+	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x6f, 0x77, 0x6e, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x63, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x64, 0x41, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f,
+	0x61, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x22,
+	0x5e, 0x0a, 0x14, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x63,
+	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f,
+	0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x22,
+	0x32, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61,
+	0x72, 0x49, 0x64, 0x22, 0x31, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x47, 0x72, 0x61, 0x6d, 0x6d,
+	0x61, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73,
+	0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73,
+	0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x4b, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x47, 0x72,
+	0x61, 0x6d, 0x6d, 0x61, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x33,
+	0x0a, 0x08, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x17, 0x2e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x76,
+	0x31, 0x2e, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x52, 0x08, 0x67, 0x72, 0x61, 0x6d, 0x6d,
+	0x61, 0x72, 0x73, 0x22, 0xc7, 0x01, 0x0a, 0x14, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x47, 0x72,
+	0x61, 0x6d, 0x6d, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a,
+	0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x17, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1d,
+	0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x48,
+	0x01, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x88, 0x01, 0x01, 0x12, 0x1d, 0x0a,
+	0x07, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x48, 0x02,
+	0x52, 0x07, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x88, 0x01, 0x01, 0x42, 0x07, 0x0a, 0x05,
+	0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
+	0x74, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x22, 0x35, 0x0a,
+	0x14, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67, 0x72, 0x61, 0x6d, 0x6d,
+	0x61, 0x72, 0x49, 0x64, 0x22, 0x17, 0x0a, 0x15, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x47, 0x72,
+	0x61, 0x6d, 0x6d, 0x61, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0xeb, 0x05,
+	0x0a, 0x0e, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x12, 0x4d, 0x0a, 0x08, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x12, 0x1f, 0x2e, 0x67,
+	0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65,
+	0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e,
+	0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47,
+	0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x52, 0x0a, 0x0e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x53, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x12, 0x1f, 0x2e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2e,
+	0x76, 0x31, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65,
+	0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x64, 0x54, 0x65, 0x78,
+	0x74, 0x30, 0x01, 0x12, 0x44, 0x0a, 0x05, 0x50, 0x61, 0x72, 0x73, 0x65, 0x12, 0x1c, 0x2e, 0x67,
+	0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61,
+	0x72, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x67, 0x72, 0x61,
+	0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x72, 0x73,
+	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4d, 0x0a, 0x08, 0x56, 0x61, 0x6c,
+	0x69, 0x64, 0x61, 0x74, 0x65, 0x12, 0x1f, 0x2e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68,
+	0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72,
+	0x68, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a, 0x0d, 0x43, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x12, 0x24, 0x2e, 0x67, 0x72, 0x61, 0x6d,
+	0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x17, 0x2e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31,
+	0x2e, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x12, 0x48, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x47,
+	0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x12, 0x21, 0x2e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72,
+	0x68, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x47, 0x72, 0x61, 0x6d, 0x6d,
+	0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x61, 0x6d,
+	0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x72, 0x61, 0x6d, 0x6d,
+	0x61, 0x72, 0x12, 0x59, 0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61,
+	0x72, 0x73, 0x12, 0x23, 0x2e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65,
+	0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61,
+	0x72, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x47, 0x72, 0x61,
+	0x6d, 0x6d, 0x61, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a,
+	0x0d, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x12, 0x24,
+	0x2e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69,
+	0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x12, 0x5c, 0x0a,
+	0x0d, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x12, 0x24,
+	0x2e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e,
+	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x47, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x25, 0x2e, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69,
+	0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x47, 0x72, 0x61, 0x6d,
+	0x6d, 0x61, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x38, 0x5a, 0x36, 0x67,
+	0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68, 0x69, 0x76, 0x65, 0x2d, 0x62, 0x61, 0x63, 0x6b, 0x65,
+	0x6e, 0x64, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72,
+	0x68, 0x69, 0x76, 0x65, 0x2f, 0x76, 0x31, 0x3b, 0x67, 0x72, 0x61, 0x6d, 0x6d, 0x61, 0x72, 0x68,
+	0x69, 0x76, 0x65, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_grammarhive_v1_grammarhive_proto_rawDescOnce sync.Once
+	file_grammarhive_v1_grammarhive_proto_rawDescData = file_grammarhive_v1_grammarhive_proto_rawDesc
+)
+
+func file_grammarhive_v1_grammarhive_proto_rawDescGZIP() []byte {
+	file_grammarhive_v1_grammarhive_proto_rawDescOnce.Do(func() {
+		file_grammarhive_v1_grammarhive_proto_rawDescData = protoimpl.X.CompressGZIP(file_grammarhive_v1_grammarhive_proto_rawDescData)
+	})
+	return file_grammarhive_v1_grammarhive_proto_rawDescData
+}
+
+var file_grammarhive_v1_grammarhive_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_grammarhive_v1_grammarhive_proto_goTypes = []any{
+	(*GenerateRequest)(nil),       // 0: grammarhive.v1.GenerateRequest
+	(*GenerateResponse)(nil),      // 1: grammarhive.v1.GenerateResponse
+	(*GeneratedText)(nil),         // 2: grammarhive.v1.GeneratedText
+	(*ParseRequest)(nil),          // 3: grammarhive.v1.ParseRequest
+	(*ParseResponse)(nil),         // 4: grammarhive.v1.ParseResponse
+	(*Rule)(nil),                  // 5: grammarhive.v1.Rule
+	(*ValidateRequest)(nil),       // 6: grammarhive.v1.ValidateRequest
+	(*ValidateResponse)(nil),      // 7: grammarhive.v1.ValidateResponse
+	(*Grammar)(nil),               // 8: grammarhive.v1.Grammar
+	(*CreateGrammarRequest)(nil),  // 9: grammarhive.v1.CreateGrammarRequest
+	(*GetGrammarRequest)(nil),     // 10: grammarhive.v1.GetGrammarRequest
+	(*ListGrammarsRequest)(nil),   // 11: grammarhive.v1.ListGrammarsRequest
+	(*ListGrammarsResponse)(nil),  // 12: grammarhive.v1.ListGrammarsResponse
+	(*UpdateGrammarRequest)(nil),  // 13: grammarhive.v1.UpdateGrammarRequest
+	(*DeleteGrammarRequest)(nil),  // 14: grammarhive.v1.DeleteGrammarRequest
+	(*DeleteGrammarResponse)(nil), // 15: grammarhive.v1.DeleteGrammarResponse
+	nil,                           // 16: grammarhive.v1.GenerateRequest.VariablesEntry
+	(*timestamppb.Timestamp)(nil), // 17: google.protobuf.Timestamp
+}
+var file_grammarhive_v1_grammarhive_proto_depIdxs = []int32{
+	16, // 0: grammarhive.v1.GenerateRequest.variables:type_name -> grammarhive.v1.GenerateRequest.VariablesEntry
+	5,  // 1: grammarhive.v1.ParseResponse.rules:type_name -> grammarhive.v1.Rule
+	17, // 2: grammarhive.v1.Grammar.created_at:type_name -> google.protobuf.Timestamp
+	17, // 3: grammarhive.v1.Grammar.updated_at:type_name -> google.protobuf.Timestamp
+	8,  // 4: grammarhive.v1.ListGrammarsResponse.grammars:type_name -> grammarhive.v1.Grammar
+	0,  // 5: grammarhive.v1.GrammarService.Generate:input_type -> grammarhive.v1.GenerateRequest
+	0,  // 6: grammarhive.v1.GrammarService.GenerateStream:input_type -> grammarhive.v1.GenerateRequest
+	3,  // 7: grammarhive.v1.GrammarService.Parse:input_type -> grammarhive.v1.ParseRequest
+	6,  // 8: grammarhive.v1.GrammarService.Validate:input_type -> grammarhive.v1.ValidateRequest
+	9,  // 9: grammarhive.v1.GrammarService.CreateGrammar:input_type -> grammarhive.v1.CreateGrammarRequest
+	10, // 10: grammarhive.v1.GrammarService.GetGrammar:input_type -> grammarhive.v1.GetGrammarRequest
+	11, // 11: grammarhive.v1.GrammarService.ListGrammars:input_type -> grammarhive.v1.ListGrammarsRequest
+	13, // 12: grammarhive.v1.GrammarService.UpdateGrammar:input_type -> grammarhive.v1.UpdateGrammarRequest
+	14, // 13: grammarhive.v1.GrammarService.DeleteGrammar:input_type -> grammarhive.v1.DeleteGrammarRequest
+	1,  // 14: grammarhive.v1.GrammarService.Generate:output_type -> grammarhive.v1.GenerateResponse
+	2,  // 15: grammarhive.v1.GrammarService.GenerateStream:output_type -> grammarhive.v1.GeneratedText
+	4,  // 16: grammarhive.v1.GrammarService.Parse:output_type -> grammarhive.v1.ParseResponse
+	7,  // 17: grammarhive.v1.GrammarService.Validate:output_type -> grammarhive.v1.ValidateResponse
+	8,  // 18: grammarhive.v1.GrammarService.CreateGrammar:output_type -> grammarhive.v1.Grammar
+	8,  // 19: grammarhive.v1.GrammarService.GetGrammar:output_type -> grammarhive.v1.Grammar
+	12, // 20: grammarhive.v1.GrammarService.ListGrammars:output_type -> grammarhive.v1.ListGrammarsResponse
+	8,  // 21: grammarhive.v1.GrammarService.UpdateGrammar:output_type -> grammarhive.v1.Grammar
+	15, // 22: grammarhive.v1.GrammarService.DeleteGrammar:output_type -> grammarhive.v1.DeleteGrammarResponse
+	14, // [14:23] is the sub-list for method output_type
+	5,  // [5:14] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_grammarhive_v1_grammarhive_proto_init() }
+func file_grammarhive_v1_grammarhive_proto_init() {
+	if File_grammarhive_v1_grammarhive_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_grammarhive_v1_grammarhive_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*GenerateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*GenerateResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*GeneratedText); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*ParseRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*ParseResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*Rule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*ValidateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*ValidateResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*Grammar); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*CreateGrammarRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*GetGrammarRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*ListGrammarsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*ListGrammarsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[13].Exporter = func(v any, i int) any {
+			switch v := v.(*UpdateGrammarRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[14].Exporter = func(v any, i int) any {
+			switch v := v.(*DeleteGrammarRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_grammarhive_v1_grammarhive_proto_msgTypes[15].Exporter = func(v any, i int) any {
+			switch v := v.(*DeleteGrammarResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_grammarhive_v1_grammarhive_proto_msgTypes[0].OneofWrappers = []any{
+		(*GenerateRequest_GrammarId)(nil),
+		(*GenerateRequest_Content)(nil),
+	}
+	file_grammarhive_v1_grammarhive_proto_msgTypes[13].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_grammarhive_v1_grammarhive_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_grammarhive_v1_grammarhive_proto_goTypes,
+		DependencyIndexes: file_grammarhive_v1_grammarhive_proto_depIdxs,
+		MessageInfos:      file_grammarhive_v1_grammarhive_proto_msgTypes,
+	}.Build()
+	File_grammarhive_v1_grammarhive_proto = out.File
+	file_grammarhive_v1_grammarhive_proto_rawDesc = nil
+	file_grammarhive_v1_grammarhive_proto_goTypes = nil
+	file_grammarhive_v1_grammarhive_proto_depIdxs = nil
+}
@@ -0,0 +1,472 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: grammarhive/v1/grammarhive.proto
+
+package grammarhivev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	GrammarService_Generate_FullMethodName       = "/grammarhive.v1.GrammarService/Generate"
+	GrammarService_GenerateStream_FullMethodName = "/grammarhive.v1.GrammarService/GenerateStream"
+	GrammarService_Parse_FullMethodName          = "/grammarhive.v1.GrammarService/Parse"
+	GrammarService_Validate_FullMethodName       = "/grammarhive.v1.GrammarService/Validate"
+	GrammarService_CreateGrammar_FullMethodName  = "/grammarhive.v1.GrammarService/CreateGrammar"
+	GrammarService_GetGrammar_FullMethodName     = "/grammarhive.v1.GrammarService/GetGrammar"
+	GrammarService_ListGrammars_FullMethodName   = "/grammarhive.v1.GrammarService/ListGrammars"
+	GrammarService_UpdateGrammar_FullMethodName  = "/grammarhive.v1.GrammarService/UpdateGrammar"
+	GrammarService_DeleteGrammar_FullMethodName  = "/grammarhive.v1.GrammarService/DeleteGrammar"
+)
+
+// GrammarServiceClient is the client API for GrammarService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// GrammarService generates text from context-free grammars and manages stored grammars.
+//
+// Calls authenticate like the HTTP API: send `authorization: Bearer <token>`,
+// `authorization: ApiKey <key>` or `x-api-key: <key>` as metadata. Failed calls carry a
+// google.rpc.ErrorInfo detail whose reason is the HTTP API's problem code.
+type GrammarServiceClient interface {
+	// Generate returns texts from a stored or inline grammar. Requires grammar:generate.
+	Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (*GenerateResponse, error)
+	// GenerateStream sends each text as soon as it is generated. Requires grammar:generate.
+	GenerateStream(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (GrammarService_GenerateStreamClient, error)
+	// Parse returns the rules read from grammar content, without checking that they compile.
+	Parse(ctx context.Context, in *ParseRequest, opts ...grpc.CallOption) (*ParseResponse, error)
+	// Validate reports whether grammar content compiles.
+	Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateResponse, error)
+	// CreateGrammar stores a new grammar owned by the caller. Requires grammar:write.
+	CreateGrammar(ctx context.Context, in *CreateGrammarRequest, opts ...grpc.CallOption) (*Grammar, error)
+	// GetGrammar returns the latest version of a grammar. Requires grammar:read.
+	GetGrammar(ctx context.Context, in *GetGrammarRequest, opts ...grpc.CallOption) (*Grammar, error)
+	// ListGrammars lists a user's public grammars, and private ones the caller owns. Requires grammar:read.
+	ListGrammars(ctx context.Context, in *ListGrammarsRequest, opts ...grpc.CallOption) (*ListGrammarsResponse, error)
+	// UpdateGrammar stores a new version of one of the caller's grammars. Requires grammar:write.
+	UpdateGrammar(ctx context.Context, in *UpdateGrammarRequest, opts ...grpc.CallOption) (*Grammar, error)
+	// DeleteGrammar deletes every version of one of the caller's grammars. Requires grammar:write.
+	DeleteGrammar(ctx context.Context, in *DeleteGrammarRequest, opts ...grpc.CallOption) (*DeleteGrammarResponse, error)
+}
+
+type grammarServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGrammarServiceClient(cc grpc.ClientConnInterface) GrammarServiceClient {
+	return &grammarServiceClient{cc}
+}
+
+func (c *grammarServiceClient) Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (*GenerateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GenerateResponse)
+	err := c.cc.Invoke(ctx, GrammarService_Generate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grammarServiceClient) GenerateStream(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (GrammarService_GenerateStreamClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GrammarService_ServiceDesc.Streams[0], GrammarService_GenerateStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grammarServiceGenerateStreamClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GrammarService_GenerateStreamClient interface {
+	Recv() (*GeneratedText, error)
+	grpc.ClientStream
+}
+
+type grammarServiceGenerateStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *grammarServiceGenerateStreamClient) Recv() (*GeneratedText, error) {
+	m := new(GeneratedText)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *grammarServiceClient) Parse(ctx context.Context, in *ParseRequest, opts ...grpc.CallOption) (*ParseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ParseResponse)
+	err := c.cc.Invoke(ctx, GrammarService_Parse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grammarServiceClient) Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateResponse)
+	err := c.cc.Invoke(ctx, GrammarService_Validate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grammarServiceClient) CreateGrammar(ctx context.Context, in *CreateGrammarRequest, opts ...grpc.CallOption) (*Grammar, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Grammar)
+	err := c.cc.Invoke(ctx, GrammarService_CreateGrammar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grammarServiceClient) GetGrammar(ctx context.Context, in *GetGrammarRequest, opts ...grpc.CallOption) (*Grammar, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Grammar)
+	err := c.cc.Invoke(ctx, GrammarService_GetGrammar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grammarServiceClient) ListGrammars(ctx context.Context, in *ListGrammarsRequest, opts ...grpc.CallOption) (*ListGrammarsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListGrammarsResponse)
+	err := c.cc.Invoke(ctx, GrammarService_ListGrammars_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grammarServiceClient) UpdateGrammar(ctx context.Context, in *UpdateGrammarRequest, opts ...grpc.CallOption) (*Grammar, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Grammar)
+	err := c.cc.Invoke(ctx, GrammarService_UpdateGrammar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grammarServiceClient) DeleteGrammar(ctx context.Context, in *DeleteGrammarRequest, opts ...grpc.CallOption) (*DeleteGrammarResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteGrammarResponse)
+	err := c.cc.Invoke(ctx, GrammarService_DeleteGrammar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GrammarServiceServer is the server API for GrammarService service.
+// All implementations must embed UnimplementedGrammarServiceServer
+// for forward compatibility
+//
+// GrammarService generates text from context-free grammars and manages stored grammars.
+//
+// Calls authenticate like the HTTP API: send `authorization: Bearer <token>`,
+// `authorization: ApiKey <key>` or `x-api-key: <key>` as metadata. Failed calls carry a
+// google.rpc.ErrorInfo detail whose reason is the HTTP API's problem code.
+type GrammarServiceServer interface {
+	// Generate returns texts from a stored or inline grammar. Requires grammar:generate.
+	Generate(context.Context, *GenerateRequest) (*GenerateResponse, error)
+	// GenerateStream sends each text as soon as it is generated. Requires grammar:generate.
+	GenerateStream(*GenerateRequest, GrammarService_GenerateStreamServer) error
+	// Parse returns the rules read from grammar content, without checking that they compile.
+	Parse(context.Context, *ParseRequest) (*ParseResponse, error)
+	// Validate reports whether grammar content compiles.
+	Validate(context.Context, *ValidateRequest) (*ValidateResponse, error)
+	// CreateGrammar stores a new grammar owned by the caller. Requires grammar:write.
+	CreateGrammar(context.Context, *CreateGrammarRequest) (*Grammar, error)
+	// GetGrammar returns the latest version of a grammar. Requires grammar:read.
+	GetGrammar(context.Context, *GetGrammarRequest) (*Grammar, error)
+	// ListGrammars lists a user's public grammars, and private ones the caller owns. Requires grammar:read.
+	ListGrammars(context.Context, *ListGrammarsRequest) (*ListGrammarsResponse, error)
+	// UpdateGrammar stores a new version of one of the caller's grammars. Requires grammar:write.
+	UpdateGrammar(context.Context, *UpdateGrammarRequest) (*Grammar, error)
+	// DeleteGrammar deletes every version of one of the caller's grammars. Requires grammar:write.
+	DeleteGrammar(context.Context, *DeleteGrammarRequest) (*DeleteGrammarResponse, error)
+	mustEmbedUnimplementedGrammarServiceServer()
+}
+
+// UnimplementedGrammarServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedGrammarServiceServer struct {
+}
+
+func (UnimplementedGrammarServiceServer) Generate(context.Context, *GenerateRequest) (*GenerateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Generate not implemented")
+}
+func (UnimplementedGrammarServiceServer) GenerateStream(*GenerateRequest, GrammarService_GenerateStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method GenerateStream not implemented")
+}
+func (UnimplementedGrammarServiceServer) Parse(context.Context, *ParseRequest) (*ParseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Parse not implemented")
+}
+func (UnimplementedGrammarServiceServer) Validate(context.Context, *ValidateRequest) (*ValidateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Validate not implemented")
+}
+func (UnimplementedGrammarServiceServer) CreateGrammar(context.Context, *CreateGrammarRequest) (*Grammar, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateGrammar not implemented")
+}
+func (UnimplementedGrammarServiceServer) GetGrammar(context.Context, *GetGrammarRequest) (*Grammar, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGrammar not implemented")
+}
+func (UnimplementedGrammarServiceServer) ListGrammars(context.Context, *ListGrammarsRequest) (*ListGrammarsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGrammars not implemented")
+}
+func (UnimplementedGrammarServiceServer) UpdateGrammar(context.Context, *UpdateGrammarRequest) (*Grammar, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateGrammar not implemented")
+}
+func (UnimplementedGrammarServiceServer) DeleteGrammar(context.Context, *DeleteGrammarRequest) (*DeleteGrammarResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteGrammar not implemented")
+}
+func (UnimplementedGrammarServiceServer) mustEmbedUnimplementedGrammarServiceServer() {}
+
+// UnsafeGrammarServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GrammarServiceServer will
+// result in compilation errors.
+type UnsafeGrammarServiceServer interface {
+	mustEmbedUnimplementedGrammarServiceServer()
+}
+
+func RegisterGrammarServiceServer(s grpc.ServiceRegistrar, srv GrammarServiceServer) {
+	s.RegisterService(&GrammarService_ServiceDesc, srv)
+}
+
+func _GrammarService_Generate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrammarServiceServer).Generate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrammarService_Generate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrammarServiceServer).Generate(ctx, req.(*GenerateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GrammarService_GenerateStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GenerateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GrammarServiceServer).GenerateStream(m, &grammarServiceGenerateStreamServer{ServerStream: stream})
+}
+
+type GrammarService_GenerateStreamServer interface {
+	Send(*GeneratedText) error
+	grpc.ServerStream
+}
+
+type grammarServiceGenerateStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *grammarServiceGenerateStreamServer) Send(m *GeneratedText) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _GrammarService_Parse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ParseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrammarServiceServer).Parse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrammarService_Parse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrammarServiceServer).Parse(ctx, req.(*ParseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GrammarService_Validate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrammarServiceServer).Validate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrammarService_Validate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrammarServiceServer).Validate(ctx, req.(*ValidateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GrammarService_CreateGrammar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateGrammarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrammarServiceServer).CreateGrammar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrammarService_CreateGrammar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrammarServiceServer).CreateGrammar(ctx, req.(*CreateGrammarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GrammarService_GetGrammar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGrammarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrammarServiceServer).GetGrammar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrammarService_GetGrammar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrammarServiceServer).GetGrammar(ctx, req.(*GetGrammarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GrammarService_ListGrammars_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGrammarsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrammarServiceServer).ListGrammars(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrammarService_ListGrammars_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrammarServiceServer).ListGrammars(ctx, req.(*ListGrammarsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GrammarService_UpdateGrammar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateGrammarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrammarServiceServer).UpdateGrammar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrammarService_UpdateGrammar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrammarServiceServer).UpdateGrammar(ctx, req.(*UpdateGrammarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GrammarService_DeleteGrammar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteGrammarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrammarServiceServer).DeleteGrammar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrammarService_DeleteGrammar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrammarServiceServer).DeleteGrammar(ctx, req.(*DeleteGrammarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// GrammarService_ServiceDesc is the grpc.ServiceDesc for GrammarService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GrammarService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "grammarhive.v1.GrammarService",
+	HandlerType: (*GrammarServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Generate",
+			Handler:    _GrammarService_Generate_Handler,
+		},
+		{
+			MethodName: "Parse",
+			Handler:    _GrammarService_Parse_Handler,
+		},
+		{
+			MethodName: "Validate",
+			Handler:    _GrammarService_Validate_Handler,
+		},
+		{
+			MethodName: "CreateGrammar",
+			Handler:    _GrammarService_CreateGrammar_Handler,
+		},
+		{
+			MethodName: "GetGrammar",
+			Handler:    _GrammarService_GetGrammar_Handler,
+		},
+		{
+			MethodName: "ListGrammars",
+			Handler:    _GrammarService_ListGrammars_Handler,
+		},
+		{
+			MethodName: "UpdateGrammar",
+			Handler:    _GrammarService_UpdateGrammar_Handler,
+		},
+		{
+			MethodName: "DeleteGrammar",
+			Handler:    _GrammarService_DeleteGrammar_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GenerateStream",
+			Handler:       _GrammarService_GenerateStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "grammarhive/v1/grammarhive.proto",
+}